@@ -14,7 +14,11 @@
 
 package analysis
 
-import "istio.io/istio/galley/pkg/config/scope"
+import (
+	"fmt"
+
+	"istio.io/istio/galley/pkg/config/scope"
+)
 
 // Analyzer is an interface for analyzing configuration.
 type Analyzer interface {
@@ -22,26 +26,54 @@ type Analyzer interface {
 	Analyze(c Context)
 }
 
-type combinedAnalyzers struct {
+// CombinedAnalyzer is an Analyzer that runs a set of analyzers in sequence.
+type CombinedAnalyzer struct {
 	name      string
 	analyzers []Analyzer
 }
 
+var _ Analyzer = &CombinedAnalyzer{}
+
 // Combine multiple analyzers into a single one.
-func Combine(name string, analyzers ...Analyzer) Analyzer {
-	return &combinedAnalyzers{
+func Combine(name string, analyzers ...Analyzer) *CombinedAnalyzer {
+	return &CombinedAnalyzer{
 		name:      name,
 		analyzers: analyzers,
 	}
 }
 
 // Name implements Analyzer
-func (c *combinedAnalyzers) Name() string {
+func (c *CombinedAnalyzer) Name() string {
 	return c.name
 }
 
+// Analyzers returns the analyzers that are combined by this analyzer.
+func (c *CombinedAnalyzer) Analyzers() []Analyzer {
+	return c.analyzers
+}
+
+// Select returns a new CombinedAnalyzer with only the analyzers of the given names. An error is returned if any of
+// the names do not match an analyzer.
+func (c *CombinedAnalyzer) Select(names []string) (*CombinedAnalyzer, error) {
+	byName := make(map[string]Analyzer, len(c.analyzers))
+	for _, a := range c.analyzers {
+		byName[a.Name()] = a
+	}
+
+	var selected []Analyzer
+	for _, n := range names {
+		a, ok := byName[n]
+		if !ok {
+			return nil, fmt.Errorf("unknown analyzer %q", n)
+		}
+		selected = append(selected, a)
+	}
+
+	return Combine(c.name, selected...), nil
+}
+
 // Analyze implements Analyzer
-func (c *combinedAnalyzers) Analyze(ctx Context) {
+func (c *CombinedAnalyzer) Analyze(ctx Context) {
 	for _, a := range c.analyzers {
 		scope.Analysis.Debugf("Started analyzer %q...", a.Name())
 		if ctx.Canceled() {
@@ -0,0 +1,46 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+type namedAnalyzer string
+
+func (a namedAnalyzer) Name() string {
+	return string(a)
+}
+
+func (a namedAnalyzer) Analyze(Context) {}
+
+func TestCombinedAnalyzer_Select(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	a1 := namedAnalyzer("a1")
+	a2 := namedAnalyzer("a2")
+	a3 := namedAnalyzer("a3")
+	c := Combine("all", a1, a2, a3)
+
+	s, err := c.Select([]string{"a3", "a1"})
+	g.Expect(err).To(BeNil())
+	g.Expect(s.Name()).To(Equal("all"))
+	g.Expect(s.Analyzers()).To(Equal([]Analyzer{a3, a1}))
+
+	_, err = c.Select([]string{"a4"})
+	g.Expect(err).NotTo(BeNil())
+}
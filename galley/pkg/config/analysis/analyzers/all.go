@@ -16,11 +16,26 @@ package analyzers
 
 import (
 	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/auth"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/injection"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/service"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/sidecar"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/virtualservice"
 )
 
 // All returns all analyzers
-func All() analysis.Analyzer {
+func All() *analysis.CombinedAnalyzer {
 	return analysis.Combine("all",
 		&SampleAnalyzer{},
+		&auth.MTLSAnalyzer{},
+		&gateway.ConflictingGatewayAnalyzer{},
+		&injection.Analyzer{},
+		&service.PortNameAnalyzer{},
+		&service.PortProtocolAnalyzer{},
+		&sidecar.DefaultSelectorAnalyzer{},
+		&sidecar.EgressHostAnalyzer{},
+		&virtualservice.DestinationHostAnalyzer{},
+		&virtualservice.DestinationRuleAnalyzer{},
 	)
 }
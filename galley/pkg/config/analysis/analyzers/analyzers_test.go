@@ -0,0 +1,165 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzers
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/auth"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/gateway"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/injection"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/service"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/sidecar"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/virtualservice"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/local"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+)
+
+type message struct {
+	code   string
+	origin string
+}
+
+type testCase struct {
+	name       string
+	inputFiles []string
+	analyzer   analysis.Analyzer
+	expected   []message
+}
+
+var testGrid = []testCase{
+	{
+		name:       "virtualServiceDestinationHosts",
+		inputFiles: []string{"testdata/virtualservice_destinationhosts.yaml"},
+		analyzer:   &virtualservice.DestinationHostAnalyzer{},
+		expected: []message{
+			{"IST0102", "VirtualService/default/reviews"},
+			{"IST0102", "VirtualService/default/external"},
+		},
+	},
+	{
+		name:       "virtualServiceDestinationRules",
+		inputFiles: []string{"testdata/virtualservice_destinationrules.yaml"},
+		analyzer:   &virtualservice.DestinationRuleAnalyzer{},
+		expected: []message{
+			{"IST0103", "VirtualService/default/reviews"},
+		},
+	},
+	{
+		name:       "conflictingGateways",
+		inputFiles: []string{"testdata/conflicting-gateways.yaml"},
+		analyzer:   &gateway.ConflictingGatewayAnalyzer{},
+		expected: []message{
+			{"IST0104", "Gateway/istio-system/gw-a"},
+			{"IST0104", "Gateway/default/gw-b"},
+			{"IST0104", "Gateway/foo/gw-d"},
+			{"IST0104", "Gateway/bar/gw-e"},
+			{"IST0104", "Gateway/foo/gw-g"},
+			{"IST0104", "Gateway/baz/gw-h"},
+			{"IST0104", "Gateway/bar/gw-i"},
+			{"IST0104", "Gateway/qux/gw-j"},
+		},
+	},
+	{
+		name:       "mtlsConflicts",
+		inputFiles: []string{"testdata/mtls-conflicts.yaml"},
+		analyzer:   &auth.MTLSAnalyzer{},
+		expected: []message{
+			{"IST0105", "DestinationRule/default/reviews"},
+			{"IST0105", "DestinationRule/istio-system/default"},
+			{"IST0105", "DestinationRule/baz/legacy"},
+		},
+	},
+	{
+		name:       "sidecarEgressHosts",
+		inputFiles: []string{"testdata/sidecar-egresshosts.yaml"},
+		analyzer:   &sidecar.EgressHostAnalyzer{},
+		expected: []message{
+			{"IST0106", "Sidecar/bar/invalid"},
+			{"IST0106", "Sidecar/bar/invalid"},
+			{"IST0106", "Sidecar/bar/invalid"},
+		},
+	},
+	{
+		name:       "sidecarDefaultSelector",
+		inputFiles: []string{"testdata/sidecar-defaultselector.yaml"},
+		analyzer:   &sidecar.DefaultSelectorAnalyzer{},
+		expected: []message{
+			{"IST0107", "Sidecar/default/default"},
+			{"IST0107", "Sidecar/default/other"},
+		},
+	},
+	{
+		name:       "servicePortName",
+		inputFiles: []string{"testdata/service-portname.yaml"},
+		analyzer:   &service.PortNameAnalyzer{},
+		expected: []message{
+			{"IST0108", "Service/default/reviews"},
+			{"IST0108", "Service/default/reviews"},
+		},
+	},
+	{
+		name:       "injection",
+		inputFiles: []string{"testdata/injection.yaml"},
+		analyzer:   &injection.Analyzer{},
+		expected: []message{
+			{"IST0109", "Pod/default/details-v1-pod-old"},
+		},
+	},
+	{
+		name:       "servicePortProtocol",
+		inputFiles: []string{"testdata/service-portprotocol.yaml"},
+		analyzer:   &service.PortProtocolAnalyzer{},
+		expected: []message{
+			{"IST0110", "Pod/default/details-pod"},
+		},
+	},
+}
+
+// TestAnalyzers allows for table-based testing of Analyzers.
+func TestAnalyzers(t *testing.T) {
+	for _, tc := range testGrid {
+		tc := tc // Capture range variable so subtests work correctly
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			cancel := make(chan struct{})
+
+			sa := local.NewSourceAnalyzer(metadata.MustGet(), tc.analyzer)
+			err := sa.AddFileKubeSource(tc.inputFiles)
+			g.Expect(err).To(BeNil())
+
+			msgs, err := sa.Analyze(cancel)
+			g.Expect(err).To(BeNil())
+
+			g.Expect(extractFields(msgs)).To(ConsistOf(tc.expected))
+		})
+	}
+}
+
+func extractFields(msgs diag.Messages) []message {
+	result := make([]message, 0)
+	for _, m := range msgs {
+		result = append(result, message{
+			code:   m.Code,
+			origin: m.Origin.FriendlyName(),
+		})
+	}
+	return result
+}
@@ -0,0 +1,313 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	authn "istio.io/api/authentication/v1alpha1"
+	"istio.io/api/mesh/v1alpha1"
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/host"
+)
+
+// MTLSAnalyzer checks for services where the authentication policy and the destination rule disagree on whether
+// mutual TLS is used, which causes requests to fail with 503s. This is the static equivalent of
+// `istioctl authn tls-check`, evaluated from the point of view of a client in the service's namespace.
+type MTLSAnalyzer struct{}
+
+var _ analysis.Analyzer = &MTLSAnalyzer{}
+
+// authProtocol is a bitmask of the protocols a side of the connection is willing to speak. It follows the values
+// used by Pilot's /debug/authenticationz endpoint.
+type authProtocol int
+
+const (
+	authnHTTP       authProtocol = 1
+	authnMTLS       authProtocol = 2
+	authnPermissive              = authnHTTP | authnMTLS
+	authnCustomTLS  authProtocol = 4
+	authnCustomMTLS authProtocol = 8
+)
+
+func (p authProtocol) String() string {
+	switch p {
+	case authnHTTP:
+		return "HTTP"
+	case authnMTLS:
+		return "mTLS"
+	case authnPermissive:
+		return "HTTP/mTLS"
+	case authnCustomTLS:
+		return "TLS"
+	case authnCustomMTLS:
+		return "custom mTLS"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Name implements Analyzer
+func (a *MTLSAnalyzer) Name() string {
+	return "auth.MTLSAnalyzer"
+}
+
+// Analyze implements Analyzer
+func (a *MTLSAnalyzer) Analyze(ctx analysis.Context) {
+	rootNamespace := constants.IstioSystemNamespace
+	ctx.ForEach(metadata.IstioMeshV1Alpha1MeshConfig, func(r *resource.Entry) bool {
+		if ns := r.Item.(*v1alpha1.MeshConfig).GetRootNamespace(); ns != "" {
+			rootNamespace = ns
+		}
+		return true
+	})
+
+	var meshPolicy *resource.Entry
+	ctx.ForEach(metadata.IstioAuthenticationV1Alpha1Meshpolicies, func(r *resource.Entry) bool {
+		if r.Metadata.Name.String() == constants.DefaultAuthenticationPolicyName {
+			meshPolicy = r
+		}
+		return true
+	})
+
+	policies := make(map[string][]*resource.Entry)
+	ctx.ForEach(metadata.IstioAuthenticationV1Alpha1Policies, func(r *resource.Entry) bool {
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		policies[ns] = append(policies[ns], r)
+		return true
+	})
+	for _, entries := range policies {
+		sortByName(entries)
+	}
+
+	rules := make(map[string]*destinationRules)
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Destinationrules, func(r *resource.Entry) bool {
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		rs, ok := rules[ns]
+		if !ok {
+			rs = &destinationRules{byHost: make(map[host.Name]*resource.Entry)}
+			rules[ns] = rs
+		}
+		rs.add(ns, r)
+		return true
+	})
+	for _, rs := range rules {
+		sort.Sort(rs.hosts)
+	}
+
+	ctx.ForEach(metadata.K8SCoreV1Services, func(r *resource.Entry) bool {
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		svcHost := util.ServiceFQDN(r.Metadata.Name)
+
+		// Destination rules in the root namespace only apply to other namespaces if they are exported.
+		dr := rules[ns].find(svcHost, false)
+		if dr == nil && ns != rootNamespace {
+			dr = rules[rootNamespace].find(svcHost, true)
+		}
+
+		svc := r.Item.(*v1.ServiceSpec)
+		for _, p := range svc.Ports {
+			policy, policyCollection := findPolicy(svcHost, ns, p, policies[ns]), metadata.IstioAuthenticationV1Alpha1Policies
+			if policy == nil {
+				policy, policyCollection = meshPolicy, metadata.IstioAuthenticationV1Alpha1Meshpolicies
+			}
+			a.analyzePort(ctx, svcHost, p, policy, policyCollection, dr)
+		}
+		return true
+	})
+}
+
+func (a *MTLSAnalyzer) analyzePort(ctx analysis.Context, svcHost host.Name, p v1.ServicePort,
+	policy *resource.Entry, policyCollection collection.Name, dr *resource.Entry) {
+
+	serverProtocol := authnHTTP
+	if policy != nil {
+		serverProtocol = serverAuthProtocol(policy.Item.(*authn.Policy))
+	}
+
+	clientProtocol := authnHTTP
+	if dr != nil {
+		clientProtocol = clientAuthProtocol(dr.Item.(*v1alpha3.DestinationRule), p)
+	}
+
+	// A client using custom mTLS settings may or may not be compatible with the server, so only report the cases
+	// that are certain to fail.
+	if clientProtocol&serverProtocol != 0 || (clientProtocol == authnCustomMTLS && serverProtocol != authnHTTP) {
+		return
+	}
+
+	// Prefer to report on the destination rule, as that is the configuration clients are acting on. Without
+	// either resource both sides use plain text, so at least one of them is set here.
+	r, c := dr, metadata.IstioNetworkingV1Alpha3Destinationrules
+	if r == nil {
+		r, c = policy, policyCollection
+	}
+	ctx.Report(c, msg.MTLSPolicyConflict(r, string(svcHost), int(p.Port),
+		serverProtocol.String(), entryName(policy), clientProtocol.String(), entryName(dr)))
+}
+
+// findPolicy returns the authentication policy that applies to the given service port, following the precedence
+// rules of Pilot: a policy targeting the service wins over a namespace-wide policy.
+func findPolicy(svcHost host.Name, ns string, p v1.ServicePort, policies []*resource.Entry) *resource.Entry {
+	var out *resource.Entry
+	currentMatchLevel := 0
+	for _, r := range policies {
+		policy := r.Item.(*authn.Policy)
+
+		// 0 - no match, 2 - namespace scope, 3 - service scope.
+		matchLevel := 0
+		if len(policy.GetTargets()) == 0 {
+			matchLevel = 2
+		}
+		for _, target := range policy.GetTargets() {
+			// Label selectors can not be evaluated without workloads, so they never match a service here.
+			if len(target.GetLabels()) != 0 || util.ConvertHostToFQDN(ns, target.GetName()) != svcHost {
+				continue
+			}
+			if len(target.GetPorts()) > 0 && !portMatched(p, target.GetPorts()) {
+				continue
+			}
+			matchLevel = 3
+			break
+		}
+
+		if matchLevel > currentMatchLevel {
+			currentMatchLevel = matchLevel
+			out = r
+		}
+	}
+	return out
+}
+
+func portMatched(p v1.ServicePort, selectors []*authn.PortSelector) bool {
+	for _, s := range selectors {
+		switch s.GetPort().(type) {
+		case *authn.PortSelector_Name:
+			if s.GetName() == p.Name {
+				return true
+			}
+		case *authn.PortSelector_Number:
+			if s.GetNumber() == uint32(p.Port) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serverAuthProtocol returns the protocols accepted by workloads the policy applies to.
+func serverAuthProtocol(policy *authn.Policy) authProtocol {
+	for _, method := range policy.GetPeers() {
+		if _, ok := method.GetParams().(*authn.PeerAuthenticationMethod_Mtls); !ok {
+			continue
+		}
+		if method.GetMtls().GetMode() == authn.MutualTls_PERMISSIVE {
+			return authnPermissive
+		}
+		return authnMTLS
+	}
+	return authnHTTP
+}
+
+// clientAuthProtocol returns the protocol clients use for the given port according to the destination rule.
+func clientAuthProtocol(dr *v1alpha3.DestinationRule, p v1.ServicePort) authProtocol {
+	tls := dr.GetTrafficPolicy().GetTls()
+	for _, pls := range dr.GetTrafficPolicy().GetPortLevelSettings() {
+		if pls.GetPort().GetNumber() == uint32(p.Port) || (pls.GetPort().GetName() != "" && pls.GetPort().GetName() == p.Name) {
+			tls = pls.GetTls()
+			break
+		}
+	}
+
+	switch tls.GetMode() {
+	case v1alpha3.TLSSettings_ISTIO_MUTUAL:
+		return authnMTLS
+	case v1alpha3.TLSSettings_SIMPLE:
+		return authnCustomTLS
+	case v1alpha3.TLSSettings_MUTUAL:
+		return authnCustomMTLS
+	default:
+		return authnHTTP
+	}
+}
+
+// destinationRules holds the destination rules of a single namespace, keyed by their fully qualified host.
+type destinationRules struct {
+	hosts  host.Names
+	byHost map[host.Name]*resource.Entry
+}
+
+func (d *destinationRules) add(ns string, r *resource.Entry) {
+	h := util.ConvertHostToFQDN(ns, r.Item.(*v1alpha3.DestinationRule).GetHost())
+	if _, ok := d.byHost[h]; ok {
+		// Pilot only uses the first rule for a host.
+		return
+	}
+	d.hosts = append(d.hosts, h)
+	d.byHost[h] = r
+}
+
+// find returns the most specific destination rule matching the host, or nil.
+func (d *destinationRules) find(h host.Name, exportedOnly bool) *resource.Entry {
+	if d == nil {
+		return nil
+	}
+	for _, candidate := range d.hosts {
+		if !h.Matches(candidate) {
+			continue
+		}
+		r := d.byHost[candidate]
+		if exportedOnly && !isExported(r.Item.(*v1alpha3.DestinationRule)) {
+			continue
+		}
+		return r
+	}
+	return nil
+}
+
+func isExported(dr *v1alpha3.DestinationRule) bool {
+	if len(dr.GetExportTo()) == 0 {
+		return true
+	}
+	for _, e := range dr.GetExportTo() {
+		if e == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func entryName(r *resource.Entry) string {
+	if r == nil {
+		return "-"
+	}
+	return r.Metadata.Name.String()
+}
+
+func sortByName(entries []*resource.Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Metadata.Name.String() < entries[j].Metadata.Name.String()
+	})
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/gateway"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+// ConflictingGatewayAnalyzer checks for gateways that bind the same port on the same workloads in ways that Pilot
+// can not merge. Pilot silently drops one of the conflicting servers in that case.
+type ConflictingGatewayAnalyzer struct{}
+
+var _ analysis.Analyzer = &ConflictingGatewayAnalyzer{}
+
+// Name implements Analyzer
+func (a *ConflictingGatewayAnalyzer) Name() string {
+	return "gateway.ConflictingGatewayAnalyzer"
+}
+
+// Analyze implements Analyzer
+func (a *ConflictingGatewayAnalyzer) Analyze(ctx analysis.Context) {
+	var gateways []*resource.Entry
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Gateways, func(r *resource.Entry) bool {
+		gateways = append(gateways, r)
+		return true
+	})
+
+	// Sort to produce the messages in a stable order.
+	sort.Slice(gateways, func(i, j int) bool {
+		return gateways[i].Metadata.Name.String() < gateways[j].Metadata.Name.String()
+	})
+
+	for i := 0; i < len(gateways); i++ {
+		for j := i + 1; j < len(gateways); j++ {
+			a.analyzeGatewayPair(ctx, gateways[i], gateways[j])
+		}
+	}
+}
+
+func (a *ConflictingGatewayAnalyzer) analyzeGatewayPair(ctx analysis.Context, r1, r2 *resource.Entry) {
+	gw1 := r1.Item.(*v1alpha3.Gateway)
+	gw2 := r2.Item.(*v1alpha3.Gateway)
+
+	if !selectorsOverlap(gw1.GetSelector(), gw2.GetSelector()) {
+		return
+	}
+
+	ns1, _ := r1.Metadata.Name.InterpretAsNamespaceAndName()
+	ns2, _ := r2.Metadata.Name.InterpretAsNamespaceAndName()
+
+	reported := make(map[string]struct{})
+	for _, s1 := range gw1.GetServers() {
+		for _, s2 := range gw2.GetServers() {
+			if s1.GetPort().GetNumber() != s2.GetPort().GetNumber() {
+				continue
+			}
+
+			reason := serverConflict(s1, ns1, s2, ns2)
+			if reason == "" {
+				continue
+			}
+
+			port := int(s1.GetPort().GetNumber())
+			key := fmt.Sprintf("%d/%s", port, reason)
+			if _, ok := reported[key]; ok {
+				continue
+			}
+			reported[key] = struct{}{}
+
+			ctx.Report(metadata.IstioNetworkingV1Alpha3Gateways,
+				msg.ConflictingGateways(r1, port, r2.Metadata.Name.String(), reason))
+			ctx.Report(metadata.IstioNetworkingV1Alpha3Gateways,
+				msg.ConflictingGateways(r2, port, r1.Metadata.Name.String(), reason))
+		}
+	}
+}
+
+// selectorsOverlap returns true if there can be a workload that is selected by both selectors. An empty selector
+// selects all workloads.
+func selectorsOverlap(s1, s2 map[string]string) bool {
+	for k, v1 := range s1 {
+		if v2, ok := s2[k]; ok && v1 != v2 {
+			return false
+		}
+	}
+	return true
+}
+
+// serverConflict returns the reason why two servers on the same port can not be merged by Pilot, or an empty string
+// if they can be merged. This follows the rules in model.MergeGateways. The namespaces are the ones of the gateways
+// the servers belong to.
+func serverConflict(s1 *v1alpha3.Server, ns1 string, s2 *v1alpha3.Server, ns2 string) string {
+	tls1 := gateway.IsTLSServer(s1)
+	tls2 := gateway.IsTLSServer(s2)
+
+	switch {
+	case !tls1 && !tls2:
+		// Plaintext servers can only share a port if they are both using the same HTTP based protocol.
+		p1 := protocol.Parse(s1.GetPort().GetProtocol())
+		p2 := protocol.Parse(s2.GetPort().GetProtocol())
+		if p1 != p2 {
+			return fmt.Sprintf("protocol %s conflicts with protocol %s", p1, p2)
+		}
+		if !p1.IsHTTP() {
+			return fmt.Sprintf("multiple %s servers can not share a port", p1)
+		}
+		return ""
+
+	case tls1 != tls2:
+		return "plaintext and TLS servers can not share a port"
+
+	default:
+		// TLS servers are distinguished by SNI, so they must not have any hosts in common.
+		if dups := duplicateHosts(serverHosts(s1, ns1), serverHosts(s2, ns2)); len(dups) > 0 {
+			return fmt.Sprintf("TLS servers have duplicate hosts: %s", strings.Join(dups, ", "))
+		}
+		return ""
+	}
+}
+
+// serverHosts returns the hosts of the server in the form Pilot compares them, following
+// model.sanitizeServerHostNamespace: "./host" is qualified with the namespace of the gateway, "*/host" is the same as
+// "host", and "*/*" matches all hosts.
+func serverHosts(s *v1alpha3.Server, namespace string) []string {
+	hosts := make([]string, 0, len(s.GetHosts()))
+	for _, h := range s.GetHosts() {
+		if strings.Contains(h, "/") {
+			parts := strings.SplitN(h, "/", 2)
+			switch parts[0] {
+			case ".":
+				h = fmt.Sprintf("%s/%s", namespace, parts[1])
+			case "*":
+				if parts[1] == "*" {
+					return []string{"*"}
+				}
+				h = parts[1]
+			}
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts
+}
+
+func duplicateHosts(h1, h2 []string) []string {
+	known := make(map[string]struct{}, len(h1))
+	for _, h := range h1 {
+		known[h] = struct{}{}
+	}
+
+	var dups []string
+	for _, h := range h2 {
+		if _, ok := known[h]; ok {
+			dups = append(dups, h)
+		}
+	}
+	return dups
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package injection
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/api/annotation"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+const (
+	// InjectionLabelName is the label on namespaces that enables automatic sidecar injection.
+	InjectionLabelName = "istio-injection"
+
+	// InjectionLabelEnableValue is the value of InjectionLabelName that enables injection.
+	InjectionLabelEnableValue = "enabled"
+
+	proxyContainerName = "istio-proxy"
+)
+
+// Analyzer checks for pods in namespaces with automatic sidecar injection enabled, that are missing the sidecar.
+// These pods were typically created before injection was enabled, or the injection webhook failed to process them.
+type Analyzer struct{}
+
+var _ analysis.Analyzer = &Analyzer{}
+
+// Name implements Analyzer
+func (a *Analyzer) Name() string {
+	return "injection.Analyzer"
+}
+
+// Analyze implements Analyzer
+func (a *Analyzer) Analyze(ctx analysis.Context) {
+	injectedNamespaces := make(map[string]struct{})
+	ctx.ForEach(metadata.K8SCoreV1Namespaces, func(r *resource.Entry) bool {
+		if r.Metadata.Labels[InjectionLabelName] == InjectionLabelEnableValue {
+			injectedNamespaces[r.Metadata.Name.String()] = struct{}{}
+		}
+		return true
+	})
+
+	ctx.ForEach(metadata.K8SCoreV1Pods, func(r *resource.Entry) bool {
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		if _, ok := injectedNamespaces[ns]; !ok {
+			return true
+		}
+
+		pod := r.Item.(*v1.Pod)
+		if !injectionExpected(r, pod) || hasProxy(pod) {
+			return true
+		}
+
+		ctx.Report(metadata.K8SCoreV1Pods, msg.PodMissingProxy(r, ns))
+		return true
+	})
+}
+
+// injectionExpected returns false for the pods the injection webhook skips, even in an injection enabled namespace.
+func injectionExpected(r *resource.Entry, pod *v1.Pod) bool {
+	if pod.Spec.HostNetwork {
+		return false
+	}
+
+	// Follows the webhook: any value other than an explicit yes opts the pod out.
+	switch strings.ToLower(r.Metadata.Annotations[annotation.SidecarInject.Name]) {
+	case "", "y", "yes", "true", "on":
+		return true
+	default:
+		return false
+	}
+}
+
+func hasProxy(pod *v1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == proxyContainerName {
+			return true
+		}
+	}
+	return false
+}
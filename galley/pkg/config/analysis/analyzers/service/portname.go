@@ -0,0 +1,62 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+const kubeSystemNamespace = "kube-system"
+
+// PortNameAnalyzer checks that the ports of each Kubernetes service are named after their protocol (e.g. "http" or
+// "http-web"), which is what Pilot uses to detect the protocol of the traffic on a port.
+type PortNameAnalyzer struct{}
+
+var _ analysis.Analyzer = &PortNameAnalyzer{}
+
+// Name implements Analyzer
+func (a *PortNameAnalyzer) Name() string {
+	return "service.PortNameAnalyzer"
+}
+
+// Analyze implements Analyzer
+func (a *PortNameAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.ForEach(metadata.K8SCoreV1Services, func(r *resource.Entry) bool {
+		a.analyzeService(r, ctx)
+		return true
+	})
+}
+
+func (a *PortNameAnalyzer) analyzeService(r *resource.Entry, ctx analysis.Context) {
+	ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+	if ns == kubeSystemNamespace {
+		// Istio does not manage traffic to the Kubernetes control plane.
+		return
+	}
+
+	svc := r.Item.(*v1.ServiceSpec)
+	for _, p := range svc.Ports {
+		if kube.ConvertProtocol(p.Port, p.Name, p.Protocol) == protocol.Unsupported {
+			ctx.Report(metadata.K8SCoreV1Services, msg.PortNameIsNotUnderNamingConvention(r, p.Name, int(p.Port)))
+		}
+	}
+}
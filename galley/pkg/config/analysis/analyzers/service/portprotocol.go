@@ -0,0 +1,132 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
+)
+
+// PortProtocolAnalyzer checks for pods that are selected by multiple services, where the services disagree on the
+// protocol of the same pod port. Pilot can only apply one protocol to the inbound traffic of a port.
+type PortProtocolAnalyzer struct{}
+
+var _ analysis.Analyzer = &PortProtocolAnalyzer{}
+
+// Name implements Analyzer
+func (a *PortProtocolAnalyzer) Name() string {
+	return "service.PortProtocolAnalyzer"
+}
+
+// Analyze implements Analyzer
+func (a *PortProtocolAnalyzer) Analyze(ctx analysis.Context) {
+	services := make(map[string][]*resource.Entry)
+	ctx.ForEach(metadata.K8SCoreV1Services, func(r *resource.Entry) bool {
+		if len(r.Item.(*v1.ServiceSpec).Selector) == 0 {
+			// Services without a selector do not select any pods.
+			return true
+		}
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		services[ns] = append(services[ns], r)
+		return true
+	})
+
+	ctx.ForEach(metadata.K8SCoreV1Pods, func(r *resource.Entry) bool {
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		a.analyzePod(r, ctx, services[ns])
+		return true
+	})
+}
+
+func (a *PortProtocolAnalyzer) analyzePod(r *resource.Entry, ctx analysis.Context, services []*resource.Entry) {
+	pod := r.Item.(*v1.Pod)
+
+	// Protocols of each pod port, along with the services that use the port with that protocol.
+	protocols := make(map[int32]map[protocol.Instance][]string)
+	for _, s := range services {
+		svc := s.Item.(*v1.ServiceSpec)
+		if !labels.Instance(svc.Selector).SubsetOf(labels.Instance(r.Metadata.Labels)) {
+			continue
+		}
+
+		_, svcName := s.Metadata.Name.InterpretAsNamespaceAndName()
+		for _, p := range svc.Ports {
+			targetPort, ok := resolveTargetPort(pod, p)
+			if !ok {
+				continue
+			}
+			if protocols[targetPort] == nil {
+				protocols[targetPort] = make(map[protocol.Instance][]string)
+			}
+			proto := kube.ConvertProtocol(p.Port, p.Name, p.Protocol)
+			protocols[targetPort][proto] = append(protocols[targetPort][proto], svcName)
+		}
+	}
+
+	ports := make([]int, 0, len(protocols))
+	for port := range protocols {
+		ports = append(ports, int(port))
+	}
+	sort.Ints(ports)
+
+	for _, port := range ports {
+		byProtocol := protocols[int32(port)]
+		if len(byProtocol) < 2 {
+			continue
+		}
+
+		var usages []string
+		for proto, svcNames := range byProtocol {
+			for _, svcName := range svcNames {
+				usages = append(usages, fmt.Sprintf("%s (%s)", svcName, proto))
+			}
+		}
+		sort.Strings(usages)
+
+		ctx.Report(metadata.K8SCoreV1Pods, msg.ConflictingServicePortProtocols(r, port, strings.Join(usages, ", ")))
+	}
+}
+
+// resolveTargetPort returns the number of the pod port that the service port forwards traffic to.
+func resolveTargetPort(pod *v1.Pod, p v1.ServicePort) (int32, bool) {
+	switch p.TargetPort.Type {
+	case intstr.String:
+		for _, c := range pod.Spec.Containers {
+			for _, cp := range c.Ports {
+				if cp.Name == p.TargetPort.StrVal {
+					return cp.ContainerPort, true
+				}
+			}
+		}
+		return 0, false
+	default:
+		if p.TargetPort.IntVal == 0 {
+			return p.Port, true
+		}
+		return p.TargetPort.IntVal, true
+	}
+}
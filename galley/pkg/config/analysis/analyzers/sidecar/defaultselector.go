@@ -0,0 +1,71 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"sort"
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+// DefaultSelectorAnalyzer checks for namespaces with more than one Sidecar without a workload selector. Pilot uses
+// only one of them as the namespace default, and which one is not deterministic.
+type DefaultSelectorAnalyzer struct{}
+
+var _ analysis.Analyzer = &DefaultSelectorAnalyzer{}
+
+// Name implements Analyzer
+func (a *DefaultSelectorAnalyzer) Name() string {
+	return "sidecar.DefaultSelectorAnalyzer"
+}
+
+// Analyze implements Analyzer
+func (a *DefaultSelectorAnalyzer) Analyze(ctx analysis.Context) {
+	defaults := make(map[string][]*resource.Entry)
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Sidecars, func(r *resource.Entry) bool {
+		sc := r.Item.(*v1alpha3.Sidecar)
+		if sc.GetWorkloadSelector() != nil {
+			return true
+		}
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		defaults[ns] = append(defaults[ns], r)
+		return true
+	})
+
+	for ns, entries := range defaults {
+		if len(entries) < 2 {
+			continue
+		}
+
+		names := make([]string, 0, len(entries))
+		for _, r := range entries {
+			_, name := r.Metadata.Name.InterpretAsNamespaceAndName()
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, r := range entries {
+			ctx.Report(metadata.IstioNetworkingV1Alpha3Sidecars,
+				msg.MultipleSidecarsWithoutWorkloadSelectors(r, ns, strings.Join(names, ", ")))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"strings"
+
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/host"
+)
+
+const (
+	wildcardNamespace = "*"
+	currentNamespace  = "."
+)
+
+// EgressHostAnalyzer checks that every egress host of a Sidecar selects at least one service or service entry.
+type EgressHostAnalyzer struct{}
+
+var _ analysis.Analyzer = &EgressHostAnalyzer{}
+
+// Name implements Analyzer
+func (a *EgressHostAnalyzer) Name() string {
+	return "sidecar.EgressHostAnalyzer"
+}
+
+// Analyze implements Analyzer
+func (a *EgressHostAnalyzer) Analyze(ctx analysis.Context) {
+	hostsByNamespace := initHostsByNamespace(ctx)
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Sidecars, func(r *resource.Entry) bool {
+		a.analyzeSidecar(r, ctx, hostsByNamespace)
+		return true
+	})
+}
+
+func (a *EgressHostAnalyzer) analyzeSidecar(r *resource.Entry, ctx analysis.Context,
+	hostsByNamespace map[string][]host.Name) {
+
+	sc := r.Item.(*v1alpha3.Sidecar)
+	sidecarNs, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+
+	for _, egress := range sc.GetEgress() {
+		for _, h := range egress.GetHosts() {
+			parts := strings.SplitN(h, "/", 2)
+			if len(parts) != 2 {
+				// Malformed hosts are rejected by validation.
+				continue
+			}
+			ns, pattern := parts[0], host.Name(parts[1])
+			if ns == currentNamespace {
+				ns = sidecarNs
+			}
+
+			if !hostSelected(ns, pattern, hostsByNamespace) {
+				ctx.Report(metadata.IstioNetworkingV1Alpha3Sidecars, msg.SidecarEgressHostNotFound(r, h))
+			}
+		}
+	}
+}
+
+// initHostsByNamespace collects the fully qualified hosts of all Kubernetes services and service entries, keyed by
+// the namespace they are defined in.
+func initHostsByNamespace(ctx analysis.Context) map[string][]host.Name {
+	hosts := make(map[string][]host.Name)
+
+	ctx.ForEach(metadata.K8SCoreV1Services, func(r *resource.Entry) bool {
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		hosts[ns] = append(hosts[ns], util.ServiceFQDN(r.Metadata.Name))
+		return true
+	})
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Serviceentries, func(r *resource.Entry) bool {
+		se := r.Item.(*v1alpha3.ServiceEntry)
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		for _, h := range se.GetHosts() {
+			hosts[ns] = append(hosts[ns], util.ConvertHostToFQDN(ns, h))
+		}
+		return true
+	})
+
+	return hosts
+}
+
+func hostSelected(ns string, pattern host.Name, hostsByNamespace map[string][]host.Name) bool {
+	for candidateNs, hosts := range hostsByNamespace {
+		if ns != wildcardNamespace && ns != candidateNs {
+			continue
+		}
+		for _, h := range hosts {
+			if pattern.Matches(h) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/host"
+)
+
+// DefaultKubernetesDomain is the domain suffix that is assumed for Kubernetes services when resolving short names.
+const DefaultKubernetesDomain = "cluster.local"
+
+// ConvertHostToFQDN returns the fully qualified form of the given host, using the given namespace to resolve short
+// names. This follows the same rules that Pilot uses when resolving hosts in networking configuration.
+func ConvertHostToFQDN(namespace, h string) host.Name {
+	// Wildcards and anything that already contains a dot are treated as fully qualified.
+	if h == "*" || strings.Contains(h, ".") {
+		return host.Name(h)
+	}
+	return host.Name(h + "." + namespace + ".svc." + DefaultKubernetesDomain)
+}
+
+// ServiceFQDN returns the fully qualified host name of the Kubernetes service with the given resource name.
+func ServiceFQDN(name resource.Name) host.Name {
+	ns, n := name.InterpretAsNamespaceAndName()
+	if ns == "" {
+		ns = "default"
+	}
+	return host.Name(n + "." + ns + ".svc." + DefaultKubernetesDomain)
+}
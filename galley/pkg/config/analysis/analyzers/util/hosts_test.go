@@ -0,0 +1,52 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/host"
+)
+
+func TestConvertHostToFQDN(t *testing.T) {
+	cases := []struct {
+		namespace string
+		host      string
+		expected  host.Name
+	}{
+		{"ns", "reviews", "reviews.ns.svc.cluster.local"},
+		{"ns", "reviews.other", "reviews.other"},
+		{"ns", "reviews.other.svc.cluster.local", "reviews.other.svc.cluster.local"},
+		{"ns", "*.foo.com", "*.foo.com"},
+		{"ns", "*", "*"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.host, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			g.Expect(ConvertHostToFQDN(c.namespace, c.host)).To(Equal(c.expected))
+		})
+	}
+}
+
+func TestServiceFQDN(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ServiceFQDN(resource.NewName("ns", "reviews"))).To(Equal(host.Name("reviews.ns.svc.cluster.local")))
+	g.Expect(ServiceFQDN(resource.NewName("", "reviews"))).To(Equal(host.Name("reviews.default.svc.cluster.local")))
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/host"
+)
+
+// DestinationHostAnalyzer checks the destination hosts associated with each virtual service
+type DestinationHostAnalyzer struct{}
+
+var _ analysis.Analyzer = &DestinationHostAnalyzer{}
+
+// Name implements Analyzer
+func (a *DestinationHostAnalyzer) Name() string {
+	return "virtualservice.DestinationHostAnalyzer"
+}
+
+// Analyze implements Analyzer
+func (a *DestinationHostAnalyzer) Analyze(ctx analysis.Context) {
+	knownHosts := initKnownHosts(ctx)
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Virtualservices, func(r *resource.Entry) bool {
+		a.analyzeVirtualService(r, ctx, knownHosts)
+		return true
+	})
+}
+
+func (a *DestinationHostAnalyzer) analyzeVirtualService(r *resource.Entry, ctx analysis.Context, knownHosts []host.Name) {
+	vs := r.Item.(*v1alpha3.VirtualService)
+	ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+
+	for _, d := range getRouteDestinations(vs) {
+		fqdn := util.ConvertHostToFQDN(ns, d.GetHost())
+		if !hostKnown(fqdn, knownHosts) {
+			ctx.Report(metadata.IstioNetworkingV1Alpha3Virtualservices, msg.DestinationHostNotFound(r, d.GetHost()))
+		}
+	}
+}
+
+// initKnownHosts collects the hosts of all Kubernetes services and service entries, in fully qualified form.
+func initKnownHosts(ctx analysis.Context) []host.Name {
+	var hosts []host.Name
+
+	ctx.ForEach(metadata.K8SCoreV1Services, func(r *resource.Entry) bool {
+		hosts = append(hosts, util.ServiceFQDN(r.Metadata.Name))
+		return true
+	})
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Serviceentries, func(r *resource.Entry) bool {
+		se := r.Item.(*v1alpha3.ServiceEntry)
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		for _, h := range se.GetHosts() {
+			hosts = append(hosts, util.ConvertHostToFQDN(ns, h))
+		}
+		return true
+	})
+
+	return hosts
+}
+
+func hostKnown(h host.Name, knownHosts []host.Name) bool {
+	for _, k := range knownHosts {
+		if h.SubsetOf(k) {
+			return true
+		}
+	}
+	return false
+}
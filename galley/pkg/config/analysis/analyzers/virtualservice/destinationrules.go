@@ -0,0 +1,101 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/pkg/config/host"
+)
+
+// DestinationRuleAnalyzer checks the destination rules associated with each virtual service
+type DestinationRuleAnalyzer struct{}
+
+var _ analysis.Analyzer = &DestinationRuleAnalyzer{}
+
+// Name implements Analyzer
+func (d *DestinationRuleAnalyzer) Name() string {
+	return "virtualservice.DestinationRuleAnalyzer"
+}
+
+// Analyze implements Analyzer
+func (d *DestinationRuleAnalyzer) Analyze(ctx analysis.Context) {
+	// To avoid repeated iteration, precompute the set of existing subsets for each destination host.
+	subsets := initSubsetsByHost(ctx)
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Virtualservices, func(r *resource.Entry) bool {
+		d.analyzeVirtualService(r, ctx, subsets)
+		return true
+	})
+}
+
+func (d *DestinationRuleAnalyzer) analyzeVirtualService(r *resource.Entry, ctx analysis.Context,
+	subsets map[host.Name]map[string]struct{}) {
+
+	vs := r.Item.(*v1alpha3.VirtualService)
+	ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+
+	for _, dest := range getRouteDestinations(vs) {
+		if dest.GetSubset() == "" {
+			continue
+		}
+		fqdn := util.ConvertHostToFQDN(ns, dest.GetHost())
+		if !subsetKnown(fqdn, dest.GetSubset(), subsets) {
+			ctx.Report(metadata.IstioNetworkingV1Alpha3Virtualservices,
+				msg.DestinationSubsetNotFound(r, dest.GetSubset(), dest.GetHost()))
+		}
+	}
+}
+
+// initSubsetsByHost collects the subset names defined by destination rules, keyed by the fully qualified
+// (possibly wildcarded) host of each destination rule.
+func initSubsetsByHost(ctx analysis.Context) map[host.Name]map[string]struct{} {
+	subsets := make(map[host.Name]map[string]struct{})
+
+	ctx.ForEach(metadata.IstioNetworkingV1Alpha3Destinationrules, func(r *resource.Entry) bool {
+		dr := r.Item.(*v1alpha3.DestinationRule)
+		ns, _ := r.Metadata.Name.InterpretAsNamespaceAndName()
+		fqdn := util.ConvertHostToFQDN(ns, dr.GetHost())
+
+		names, ok := subsets[fqdn]
+		if !ok {
+			names = make(map[string]struct{})
+			subsets[fqdn] = names
+		}
+		for _, ss := range dr.GetSubsets() {
+			names[ss.GetName()] = struct{}{}
+		}
+		return true
+	})
+
+	return subsets
+}
+
+func subsetKnown(h host.Name, subset string, subsets map[host.Name]map[string]struct{}) bool {
+	for drHost, names := range subsets {
+		if !h.SubsetOf(drHost) {
+			continue
+		}
+		if _, ok := names[subset]; ok {
+			return true
+		}
+	}
+	return false
+}
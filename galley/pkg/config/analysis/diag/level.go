@@ -14,6 +14,11 @@
 
 package diag
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Level is the severity level of a message.
 type Level string
 
@@ -27,3 +32,39 @@ const (
 	// Error level is for error messages
 	Error Level = "Error"
 )
+
+// levelSeverity orders the known levels. Higher values are more severe.
+var levelSeverity = map[Level]int{
+	Info:    0,
+	Warning: 1,
+	Error:   2,
+}
+
+// IsWorseThanOrEqualTo returns true if l is at least as severe as the given level.
+func (l Level) IsWorseThanOrEqualTo(o Level) bool {
+	return levelSeverity[l] >= levelSeverity[o]
+}
+
+// GetAllLevels returns all the known levels, in ascending order of severity.
+func GetAllLevels() []Level {
+	return []Level{Info, Warning, Error}
+}
+
+// GetAllLevelStrings returns the names of all the known levels, in ascending order of severity.
+func GetAllLevelStrings() []string {
+	var result []string
+	for _, l := range GetAllLevels() {
+		result = append(result, string(l))
+	}
+	return result
+}
+
+// ParseLevel returns the level with the given name. The match is case-insensitive.
+func ParseLevel(name string) (Level, error) {
+	for _, l := range GetAllLevels() {
+		if strings.EqualFold(string(l), name) {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unknown level %q, must be one of %v", name, GetAllLevelStrings())
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diag
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLevel_IsWorseThanOrEqualTo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(Error.IsWorseThanOrEqualTo(Warning)).To(BeTrue())
+	g.Expect(Warning.IsWorseThanOrEqualTo(Warning)).To(BeTrue())
+	g.Expect(Info.IsWorseThanOrEqualTo(Warning)).To(BeFalse())
+	g.Expect(Warning.IsWorseThanOrEqualTo(Error)).To(BeFalse())
+}
+
+func TestParseLevel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	l, err := ParseLevel("error")
+	g.Expect(err).To(BeNil())
+	g.Expect(l).To(Equal(Error))
+
+	l, err = ParseLevel("Warn")
+	g.Expect(err).To(BeNil())
+	g.Expect(l).To(Equal(Warning))
+
+	_, err = ParseLevel("fatal")
+	g.Expect(err).NotTo(BeNil())
+}
@@ -15,6 +15,7 @@
 package diag
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"istio.io/istio/galley/pkg/config/resource"
@@ -49,14 +50,45 @@ func (m *Message) StatusString() string {
 	return m.toString(false)
 }
 
+// Position returns the position of the message origin within its source file, or nil if it is not known.
+func (m *Message) Position() *resource.Position {
+	if po, ok := m.Origin.(resource.PositionedOrigin); ok {
+		return po.SourcePosition()
+	}
+	return nil
+}
+
 func (m *Message) toString(includeOrigin bool) string {
 	origin := ""
 	if includeOrigin && m.Origin != nil {
-		origin = "(" + m.Origin.FriendlyName() + ")"
+		origin = m.Origin.FriendlyName()
+		if p := m.Position(); p != nil {
+			origin += " " + p.String()
+		}
+		origin = "(" + origin + ")"
 	}
 	return fmt.Sprintf("%v [%v]%s %s", m.Level, m.Code, origin, fmt.Sprintf(m.template, m.Parameters...))
 }
 
+// MarshalJSON implements json.Marshaler
+func (m *Message) MarshalJSON() ([]byte, error) {
+	origin := ""
+	if m.Origin != nil {
+		origin = m.Origin.FriendlyName()
+	}
+	result := map[string]interface{}{
+		"code":       m.Code,
+		"level":      m.Level,
+		"origin":     origin,
+		"message":    fmt.Sprintf(m.template, m.Parameters...),
+		"parameters": m.Parameters,
+	}
+	if p := m.Position(); p != nil {
+		result["position"] = p
+	}
+	return json.Marshal(result)
+}
+
 // NewMessage returns a new Message instance.
 func NewMessage(l Level, c string, o resource.Origin, template string, p ...interface{}) Message {
 	return Message{
@@ -15,9 +15,12 @@
 package diag
 
 import (
+	"encoding/json"
 	"testing"
 
 	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/resource"
 )
 
 type testOrigin string
@@ -26,6 +29,20 @@ func (o testOrigin) FriendlyName() string {
 	return string(o)
 }
 
+type testPositionedOrigin struct {
+	testOrigin
+	position *resource.Position
+}
+
+func (o testPositionedOrigin) SourcePosition() *resource.Position {
+	return o.position
+}
+
+var positionedOrigin = testPositionedOrigin{
+	testOrigin: "toppings/cheese",
+	position:   &resource.Position{Filename: "pizza.yaml", Document: 1, Line: 42},
+}
+
 func TestMessage_String(t *testing.T) {
 	g := NewGomegaWithT(t)
 	m := NewMessage(Error, "IST-0042", nil, "Cheese type not found: %q", "Feta")
@@ -40,3 +57,32 @@ func TestMessageWithOrigin_String(t *testing.T) {
 
 	g.Expect(m.String()).To(Equal(`Error [IST-0042](toppings/cheese) Cheese type not found: "Feta"`))
 }
+
+func TestMessage_JSON(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := testOrigin("toppings/cheese")
+	m := NewMessage(Error, "IST-0042", o, "Cheese type not found: %q", "Feta")
+
+	j, err := json.Marshal(&m)
+	g.Expect(err).To(BeNil())
+	g.Expect(string(j)).To(Equal(`{"code":"IST-0042","level":"Error","message":"Cheese type not found: \"Feta\"",` +
+		`"origin":"toppings/cheese","parameters":["Feta"]}`))
+}
+
+func TestMessageWithPosition_String(t *testing.T) {
+	g := NewGomegaWithT(t)
+	m := NewMessage(Error, "IST-0042", positionedOrigin, "Cheese type not found: %q", "Feta")
+
+	g.Expect(m.String()).To(Equal(`Error [IST-0042](toppings/cheese pizza.yaml:42) Cheese type not found: "Feta"`))
+	g.Expect(m.StatusString()).To(Equal(`Error [IST-0042] Cheese type not found: "Feta"`))
+}
+
+func TestMessageWithPosition_JSON(t *testing.T) {
+	g := NewGomegaWithT(t)
+	m := NewMessage(Error, "IST-0042", positionedOrigin, "Cheese type not found: %q", "Feta")
+
+	j, err := json.Marshal(&m)
+	g.Expect(err).To(BeNil())
+	g.Expect(string(j)).To(Equal(`{"code":"IST-0042","level":"Error","message":"Cheese type not found: \"Feta\"",` +
+		`"origin":"toppings/cheese","parameters":["Feta"],"position":{"filename":"pizza.yaml","document":1,"line":42}}`))
+}
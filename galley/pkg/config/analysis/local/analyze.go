@@ -17,6 +17,7 @@ package local
 import (
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 
 	"istio.io/istio/galley/pkg/config/analysis"
@@ -28,6 +29,7 @@ import (
 	"istio.io/istio/galley/pkg/config/schema"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
 	"istio.io/istio/galley/pkg/config/source/kube/inmemory"
+	"istio.io/istio/galley/pkg/runtime/groups"
 	"istio.io/istio/galley/pkg/source/kube/client"
 )
 
@@ -61,7 +63,8 @@ func (sa *SourceAnalyzer) Analyze(cancel chan struct{}) (diag.Messages, error) {
 	src := newPrecedenceSource(sa.sources)
 
 	updater := &snapshotter.InMemoryStatusUpdater{}
-	distributor := snapshotter.NewAnalyzingDistributor(updater, sa.analyzer, snapshotter.NewInMemoryDistributor())
+	distributor := snapshotter.NewAnalyzingDistributor(updater, sa.analyzer, snapshotter.NewInMemoryDistributor(),
+		groups.LocalAnalysis)
 	rt, err := processor.Initialize(sa.m, domainSuffix, event.CombineSources(src, meshsrc), distributor)
 	if err != nil {
 		return nil, err
@@ -94,6 +97,32 @@ func (sa *SourceAnalyzer) AddFileKubeSource(files []string) error {
 	return nil
 }
 
+// ReaderSource is a named source of k8s yaml, such as the standard input.
+type ReaderSource struct {
+	// Name is used to refer to the source in analysis messages.
+	Name string
+	// Reader provides the yaml content.
+	Reader io.Reader
+}
+
+// AddReaderKubeSource adds a source based on the specified k8s yaml readers to the current SourceAnalyzer
+func (sa *SourceAnalyzer) AddReaderKubeSource(readers []ReaderSource) error {
+	src := inmemory.NewKubeSource(sa.m.KubeSource().Resources())
+
+	for _, r := range readers {
+		by, err := ioutil.ReadAll(r.Reader)
+		if err != nil {
+			return err
+		}
+		if err = src.ApplyContent(r.Name, string(by)); err != nil {
+			return err
+		}
+	}
+
+	sa.sources = append(sa.sources, src)
+	return nil
+}
+
 // AddRunningKubeSource adds a source based on a running k8s cluster to the current SourceAnalyzer
 func (sa *SourceAnalyzer) AddRunningKubeSource(k client.Interfaces) {
 	o := apiserver.Options{
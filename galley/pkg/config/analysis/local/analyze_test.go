@@ -16,6 +16,7 @@ package local
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -107,3 +108,13 @@ func TestAddFileKubeSource(t *testing.T) {
 	sa.AddFileKubeSource([]string{tmpfile.Name()})
 	g.Expect(sa.sources).To(HaveLen(1))
 }
+
+func TestAddReaderKubeSource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	sa := NewSourceAnalyzer(k8smeta.MustGet(), nil)
+
+	err := sa.AddReaderKubeSource([]ReaderSource{{Name: "stdin", Reader: strings.NewReader(data.YamlN1I1V1)}})
+	g.Expect(err).To(BeNil())
+	g.Expect(sa.sources).To(HaveLen(1))
+}
@@ -0,0 +1,164 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+// SuppressionAnnotation is the annotation that can be set on a resource to suppress analysis messages for that
+// resource. The value is a comma separated list of message codes, or "*" to suppress all messages.
+const SuppressionAnnotation = "galley.istio.io/analyze-suppress"
+
+// AnalysisSuppression describes a message code that should be suppressed, optionally only for some resources.
+type AnalysisSuppression struct {
+	// Code is the message code to suppress, e.g. "IST0101".
+	Code string
+
+	// ResourceName is a glob pattern that is matched against the friendly name of the message origin, e.g.
+	// "VirtualService/default/*". If empty, the code is suppressed for all resources.
+	ResourceName string
+}
+
+// ParseSuppression parses a suppression of the form "CODE" or "CODE=RESOURCE".
+func ParseSuppression(s string) (AnalysisSuppression, error) {
+	parts := strings.SplitN(s, "=", 2)
+	code := strings.TrimSpace(parts[0])
+	if code == "" {
+		return AnalysisSuppression{}, fmt.Errorf("invalid suppression %q: code must not be empty", s)
+	}
+
+	result := AnalysisSuppression{Code: code}
+	if len(parts) == 2 {
+		result.ResourceName = strings.TrimSpace(parts[1])
+		if _, err := path.Match(result.ResourceName, ""); err != nil {
+			return AnalysisSuppression{}, fmt.Errorf("invalid suppression %q: %v", s, err)
+		}
+	}
+	return result, nil
+}
+
+func (s AnalysisSuppression) matches(m diag.Message) bool {
+	if s.Code != m.Code {
+		return false
+	}
+	if s.ResourceName == "" {
+		return true
+	}
+	if m.Origin == nil {
+		return false
+	}
+	matched, _ := path.Match(s.ResourceName, m.Origin.FriendlyName())
+	return matched
+}
+
+// suppressingAnalyzer is an analysis.Analyzer that drops the messages reported by the wrapped analyzer that are
+// suppressed, either explicitly or through the SuppressionAnnotation on the offending resource.
+type suppressingAnalyzer struct {
+	analyzer     analysis.Analyzer
+	suppressions []AnalysisSuppression
+}
+
+var _ analysis.Analyzer = &suppressingAnalyzer{}
+
+// NewSuppressingAnalyzer returns an analyzer that runs the given analyzer, dropping any messages that match one of
+// the given suppressions, or that are suppressed through the SuppressionAnnotation of the offending resource.
+func NewSuppressingAnalyzer(a analysis.Analyzer, suppressions []AnalysisSuppression) analysis.Analyzer {
+	return &suppressingAnalyzer{
+		analyzer:     a,
+		suppressions: suppressions,
+	}
+}
+
+// Name implements analysis.Analyzer
+func (a *suppressingAnalyzer) Name() string {
+	return a.analyzer.Name()
+}
+
+// Analyze implements analysis.Analyzer
+func (a *suppressingAnalyzer) Analyze(ctx analysis.Context) {
+	a.analyzer.Analyze(&suppressingContext{
+		Context:      ctx,
+		suppressions: a.suppressions,
+		annotated:    make(map[collection.Name]map[string][]string),
+	})
+}
+
+type suppressingContext struct {
+	analysis.Context
+
+	suppressions []AnalysisSuppression
+
+	// Suppressed codes by origin friendly name, for each collection. Lazily populated.
+	annotated map[collection.Name]map[string][]string
+}
+
+var _ analysis.Context = &suppressingContext{}
+
+// Report implements analysis.Context
+func (c *suppressingContext) Report(coll collection.Name, m diag.Message) {
+	if c.isSuppressed(coll, m) {
+		scope.Analysis.Debugf("Suppressed message: %v", m.String())
+		return
+	}
+	c.Context.Report(coll, m)
+}
+
+func (c *suppressingContext) isSuppressed(coll collection.Name, m diag.Message) bool {
+	for _, s := range c.suppressions {
+		if s.matches(m) {
+			return true
+		}
+	}
+
+	if m.Origin == nil {
+		return false
+	}
+	for _, code := range c.annotatedCodes(coll)[m.Origin.FriendlyName()] {
+		if code == "*" || code == m.Code {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *suppressingContext) annotatedCodes(coll collection.Name) map[string][]string {
+	codes, ok := c.annotated[coll]
+	if ok {
+		return codes
+	}
+
+	codes = make(map[string][]string)
+	c.Context.ForEach(coll, func(r *resource.Entry) bool {
+		v, ok := r.Metadata.Annotations[SuppressionAnnotation]
+		if !ok || r.Origin == nil {
+			return true
+		}
+		for _, code := range strings.Split(v, ",") {
+			codes[r.Origin.FriendlyName()] = append(codes[r.Origin.FriendlyName()], strings.TrimSpace(code))
+		}
+		return true
+	})
+	c.annotated[coll] = codes
+	return codes
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/resource"
+)
+
+var suppressionTestCollection = collection.NewName("collection")
+
+type testOrigin string
+
+func (o testOrigin) FriendlyName() string {
+	return string(o)
+}
+
+type fakeContext struct {
+	entries  []*resource.Entry
+	messages diag.Messages
+}
+
+var _ analysis.Context = &fakeContext{}
+
+func (c *fakeContext) Report(_ collection.Name, m diag.Message) {
+	c.messages.Add(m)
+}
+
+func (c *fakeContext) Find(_ collection.Name, name resource.Name) *resource.Entry {
+	for _, e := range c.entries {
+		if e.Metadata.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+func (c *fakeContext) ForEach(_ collection.Name, fn analysis.IteratorFn) {
+	for _, e := range c.entries {
+		if !fn(e) {
+			return
+		}
+	}
+}
+
+func (c *fakeContext) Canceled() bool {
+	return false
+}
+
+func createSuppressionTestResource(name string, annotations map[string]string) *resource.Entry {
+	r := createTestResource(name, "v1")
+	r.Metadata.Annotations = annotations
+	r.Origin = testOrigin("Kind/ns/" + name)
+	return r
+}
+
+func TestParseSuppression(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, err := ParseSuppression("IST0101")
+	g.Expect(err).To(BeNil())
+	g.Expect(s).To(Equal(AnalysisSuppression{Code: "IST0101"}))
+
+	s, err = ParseSuppression("IST0101=VirtualService/default/*")
+	g.Expect(err).To(BeNil())
+	g.Expect(s).To(Equal(AnalysisSuppression{Code: "IST0101", ResourceName: "VirtualService/default/*"}))
+
+	_, err = ParseSuppression("=VirtualService/default/*")
+	g.Expect(err).NotTo(BeNil())
+
+	_, err = ParseSuppression("IST0101=[")
+	g.Expect(err).NotTo(BeNil())
+}
+
+func TestSuppressingAnalyzer(t *testing.T) {
+	r1 := createSuppressionTestResource("r1", nil)
+	r2 := createSuppressionTestResource("r2", map[string]string{SuppressionAnnotation: "IST0001, IST0002"})
+	r3 := createSuppressionTestResource("r3", map[string]string{SuppressionAnnotation: "*"})
+
+	report := func(ctx analysis.Context) {
+		for _, r := range []*resource.Entry{r1, r2, r3} {
+			ctx.Report(suppressionTestCollection, msg.InternalError(r, "msg"))
+			ctx.Report(suppressionTestCollection, msg.Deprecated(r, "msg"))
+		}
+	}
+
+	cases := []struct {
+		name         string
+		suppressions []AnalysisSuppression
+		expected     diag.Messages
+	}{
+		{
+			name: "annotations only",
+			expected: diag.Messages{
+				msg.InternalError(r1, "msg"),
+				msg.Deprecated(r1, "msg"),
+				msg.Deprecated(r2, "msg"),
+			},
+		},
+		{
+			name:         "global suppression",
+			suppressions: []AnalysisSuppression{{Code: "IST0004"}},
+			expected: diag.Messages{
+				msg.InternalError(r1, "msg"),
+			},
+		},
+		{
+			name:         "per-resource suppression",
+			suppressions: []AnalysisSuppression{{Code: "IST0001", ResourceName: "Kind/ns/r*"}},
+			expected: diag.Messages{
+				msg.Deprecated(r1, "msg"),
+				msg.Deprecated(r2, "msg"),
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			ctx := &fakeContext{entries: []*resource.Entry{r1, r2, r3}}
+			a := NewSuppressingAnalyzer(&testAnalyzer{fn: report}, c.suppressions)
+			a.Analyze(ctx)
+
+			g.Expect(ctx.messages).To(ConsistOf(c.expected))
+		})
+	}
+}
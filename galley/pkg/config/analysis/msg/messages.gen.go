@@ -75,6 +75,145 @@ func ReferencedResourceNotFound(entry *resource.Entry, reftype string, refval st
 	)
 }
 
+// DestinationHostNotFound returns a new diag.Message for message "Destination Host Not Found".
+//
+// A route destination host does not match any known Kubernetes Service or ServiceEntry.
+func DestinationHostNotFound(entry *resource.Entry, host string) diag.Message {
+	return diag.NewMessage(
+		diag.Error,
+		"IST0102",
+		originOrNil(entry),
+		"Destination host not found: %q",
+		host,
+	)
+}
+
+// DestinationSubsetNotFound returns a new diag.Message for message "Destination Subset Not Found".
+//
+// A route destination subset is not defined in any DestinationRule for the destination host.
+func DestinationSubsetNotFound(entry *resource.Entry, subset string, host string) diag.Message {
+	return diag.NewMessage(
+		diag.Error,
+		"IST0103",
+		originOrNil(entry),
+		"Subset %q not found in any DestinationRule for host %q",
+		subset,
+
+		host,
+	)
+}
+
+// ConflictingGateways returns a new diag.Message for message "Conflicting Gateways".
+//
+// Gateways that apply to the same workloads bind the same port in a way that can not be merged. One of the servers will be dropped.
+func ConflictingGateways(entry *resource.Entry, port int, gateway string, reason string) diag.Message {
+	return diag.NewMessage(
+		diag.Error,
+		"IST0104",
+		originOrNil(entry),
+		"Server on port %d conflicts with gateway %q: %s",
+		port,
+
+		gateway,
+
+		reason,
+	)
+}
+
+// MTLSPolicyConflict returns a new diag.Message for message "MTLS Policy Conflict".
+//
+// An authentication Policy and a DestinationRule disagree on whether mutual TLS is used for a service, so requests to it will fail.
+func MTLSPolicyConflict(entry *resource.Entry, host string, port int, serverProtocol string, policy string, clientProtocol string, destinationRule string) diag.Message {
+	return diag.NewMessage(
+		diag.Error,
+		"IST0105",
+		originOrNil(entry),
+		"mTLS conflict for host %s port %d: the server accepts %s (Policy %q) but clients use %s (DestinationRule %q)",
+		host,
+
+		port,
+
+		serverProtocol,
+
+		policy,
+
+		clientProtocol,
+
+		destinationRule,
+	)
+}
+
+// SidecarEgressHostNotFound returns a new diag.Message for message "Sidecar Egress Host Not Found".
+//
+// An egress host of a Sidecar does not select any service or service entry, so workloads using the Sidecar can not reach it.
+func SidecarEgressHostNotFound(entry *resource.Entry, host string) diag.Message {
+	return diag.NewMessage(
+		diag.Warning,
+		"IST0106",
+		originOrNil(entry),
+		"Egress host %q does not match any service or service entry",
+		host,
+	)
+}
+
+// MultipleSidecarsWithoutWorkloadSelectors returns a new diag.Message for message "Multiple Sidecars Without Workload Selectors".
+//
+// A namespace contains more than one Sidecar without a workload selector. Only one of them is applied, and which one is not deterministic.
+func MultipleSidecarsWithoutWorkloadSelectors(entry *resource.Entry, namespace string, sidecars string) diag.Message {
+	return diag.NewMessage(
+		diag.Warning,
+		"IST0107",
+		originOrNil(entry),
+		"Namespace %q has multiple Sidecars without a workload selector: %s",
+		namespace,
+
+		sidecars,
+	)
+}
+
+// PortNameIsNotUnderNamingConvention returns a new diag.Message for message "Port Name Is Not Under Naming Convention".
+//
+// A port name does not follow the Istio naming convention of <protocol>[-<suffix>], so Istio can not detect the protocol of its traffic and treats it as plain TCP.
+func PortNameIsNotUnderNamingConvention(entry *resource.Entry, portName string, port int) diag.Message {
+	return diag.NewMessage(
+		diag.Info,
+		"IST0108",
+		originOrNil(entry),
+		"Port name %q (port %d) does not follow the Istio naming convention of <protocol>[-<suffix>]",
+		portName,
+
+		port,
+	)
+}
+
+// PodMissingProxy returns a new diag.Message for message "Pod Missing Proxy".
+//
+// A pod in a namespace with automatic sidecar injection enabled is missing the Istio proxy.
+func PodMissingProxy(entry *resource.Entry, namespace string) diag.Message {
+	return diag.NewMessage(
+		diag.Warning,
+		"IST0109",
+		originOrNil(entry),
+		"The pod is missing the Istio proxy, although injection is enabled for namespace %q. Restarting the pod may fix this",
+		namespace,
+	)
+}
+
+// ConflictingServicePortProtocols returns a new diag.Message for message "Conflicting Service Port Protocols".
+//
+// A pod port is selected by several services that imply different protocols for it. Only one of these protocols is applied to the inbound traffic of the pod.
+func ConflictingServicePortProtocols(entry *resource.Entry, port int, services string) diag.Message {
+	return diag.NewMessage(
+		diag.Warning,
+		"IST0110",
+		originOrNil(entry),
+		"Pod port %d is selected by services with conflicting protocols: %s",
+		port,
+
+		services,
+	)
+}
+
 func originOrNil(e *resource.Entry) resource.Origin {
 	var o resource.Origin
 	if e != nil {
@@ -23,12 +23,13 @@ import (
 	"istio.io/istio/galley/pkg/config/resource"
 )
 
-// AnalyzingDistributor is an snapshotter.Distributor implementation that will perform analysis on a snapshot before
-// publishing. It will update the CRD status with the analysis results.
+// AnalyzingDistributor is an snapshotter.Distributor implementation that will perform analysis on a snapshot. It will
+// update the CRD status with the analysis results.
 type AnalyzingDistributor struct {
 	updater     StatusUpdater
 	analyzer    analysis.Analyzer
 	distributor Distributor
+	snapshot    string
 
 	analysisMu     sync.Mutex
 	cancelAnalysis chan struct{}
@@ -50,21 +51,20 @@ type InMemoryStatusUpdater struct {
 
 var _ StatusUpdater = &InMemoryStatusUpdater{}
 
-// NewAnalyzingDistributor returns a new instance of AnalyzingDistributor.
-func NewAnalyzingDistributor(u StatusUpdater, a analysis.Analyzer, d Distributor) *AnalyzingDistributor {
+// NewAnalyzingDistributor returns a new instance of AnalyzingDistributor. Only the snapshot with the given name is
+// analyzed, and it is not passed on to the distributor. All other snapshots are passed through to the distributor as-is.
+func NewAnalyzingDistributor(u StatusUpdater, a analysis.Analyzer, d Distributor, snapshot string) *AnalyzingDistributor {
 	return &AnalyzingDistributor{
 		updater:     u,
 		analyzer:    a,
 		distributor: d,
+		snapshot:    snapshot,
 	}
 }
 
 // Distribute implements snapshotter.Distributor
 func (d *AnalyzingDistributor) Distribute(name string, s *Snapshot) {
-	// Make use of the fact that "default" is the main snapshot group, currently. Once/if this changes, we will need to
-	// redesign this approach.
-
-	if name != "default" {
+	if name != d.snapshot {
 		d.distributor.Distribute(name, s)
 		return
 	}
@@ -81,11 +81,10 @@ func (d *AnalyzingDistributor) Distribute(name string, s *Snapshot) {
 	// start a new analysis session
 	cancelAnalysis := make(chan struct{})
 	d.cancelAnalysis = cancelAnalysis
-	go d.analyzeAndDistribute(cancelAnalysis, s)
-
+	go d.analyze(cancelAnalysis, s)
 }
 
-func (d *AnalyzingDistributor) analyzeAndDistribute(cancelCh chan struct{}, s *Snapshot) {
+func (d *AnalyzingDistributor) analyze(cancelCh chan struct{}, s *Snapshot) {
 	ctx := &context{
 		sn:       s,
 		cancelCh: cancelCh,
@@ -96,8 +95,6 @@ func (d *AnalyzingDistributor) analyzeAndDistribute(cancelCh chan struct{}, s *S
 	if !ctx.Canceled() {
 		d.updater.Update(ctx.messages)
 	}
-
-	d.distributor.Distribute("default", s)
 }
 
 type context struct {
@@ -137,9 +134,14 @@ func (c *context) Canceled() bool {
 func (u *InMemoryStatusUpdater) Update(m diag.Messages) {
 	u.mu.Lock()
 	defer u.mu.Unlock()
+	// Use a non-nil value, so that an empty report can be distinguished from no report.
+	if m == nil {
+		m = diag.Messages{}
+	}
 	u.m = m
 	if u.waitCh != nil {
 		close(u.waitCh)
+		u.waitCh = nil
 	}
 }
 
@@ -154,6 +156,7 @@ func (u *InMemoryStatusUpdater) Get() diag.Messages {
 func (u *InMemoryStatusUpdater) WaitForReport(cancelCh chan struct{}) bool {
 	u.mu.Lock()
 	if u.m != nil {
+		u.mu.Unlock()
 		return true
 	}
 
@@ -0,0 +1,56 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/collection"
+)
+
+type reportingAnalyzer struct {
+	m diag.Message
+}
+
+func (a *reportingAnalyzer) Name() string { return "reporting" }
+
+func (a *reportingAnalyzer) Analyze(ctx analysis.Context) {
+	ctx.Report(collection.NewName("foo"), a.m)
+}
+
+func TestAnalyzingDistributor(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	m := diag.NewMessage(diag.Error, "IST0001", nil, "first")
+	u := &InMemoryStatusUpdater{}
+	d := NewInMemoryDistributor()
+	ad := NewAnalyzingDistributor(u, &reportingAnalyzer{m: m}, d, "analyzed")
+
+	sDefault := &Snapshot{set: collection.NewSet(nil)}
+	ad.Distribute("default", sDefault)
+	sAnalyzed := &Snapshot{set: collection.NewSet(nil)}
+	ad.Distribute("analyzed", sAnalyzed)
+
+	g.Expect(u.WaitForReport(nil)).To(BeTrue())
+	g.Expect(u.Get()).To(Equal(diag.Messages{m}))
+
+	// Only the snapshot that is not analyzed is distributed.
+	g.Expect(d.GetSnapshot("default")).To(BeIdenticalTo(sDefault))
+	g.Expect(d.GetSnapshot("analyzed")).To(BeNil())
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"sync"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// AsyncStatusUpdater is a StatusUpdater that passes the messages to another StatusUpdater in the background, so that
+// slow status writes do not hold up the caller. Only the latest batch of messages is kept while a write is in
+// progress, older batches are dropped.
+type AsyncStatusUpdater struct {
+	updater StatusUpdater
+
+	mu         sync.Mutex
+	pending    diag.Messages
+	hasPending bool
+
+	notifyCh chan struct{}
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+var _ StatusUpdater = &AsyncStatusUpdater{}
+
+// NewAsyncStatusUpdater returns a new AsyncStatusUpdater writing through the given StatusUpdater.
+func NewAsyncStatusUpdater(u StatusUpdater) *AsyncStatusUpdater {
+	return &AsyncStatusUpdater{
+		updater:  u,
+		notifyCh: make(chan struct{}, 1),
+	}
+}
+
+// Start the background writer.
+func (u *AsyncStatusUpdater) Start() {
+	u.stopCh = make(chan struct{})
+	u.doneCh = make(chan struct{})
+	go u.run(u.stopCh, u.doneCh)
+}
+
+// Stop the background writer, and wait for the write in progress to finish. Pending messages are dropped.
+func (u *AsyncStatusUpdater) Stop() {
+	if u.stopCh == nil {
+		return
+	}
+	close(u.stopCh)
+	<-u.doneCh
+	u.stopCh = nil
+	u.doneCh = nil
+}
+
+// Update implements StatusUpdater
+func (u *AsyncStatusUpdater) Update(m diag.Messages) {
+	u.mu.Lock()
+	u.pending = m
+	u.hasPending = true
+	u.mu.Unlock()
+
+	select {
+	case u.notifyCh <- struct{}{}:
+	default:
+		// The writer is already notified, and will pick up the latest messages.
+	}
+}
+
+func (u *AsyncStatusUpdater) run(stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-u.notifyCh:
+		}
+
+		u.mu.Lock()
+		m, ok := u.pending, u.hasPending
+		u.pending = nil
+		u.hasPending = false
+		u.mu.Unlock()
+
+		if ok {
+			u.updater.Update(m)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+)
+
+// blockingStatusUpdater blocks each update until it is released.
+type blockingStatusUpdater struct {
+	mu       sync.Mutex
+	updates  []diag.Messages
+	started  chan struct{}
+	releaseC chan struct{}
+}
+
+func (u *blockingStatusUpdater) Update(m diag.Messages) {
+	u.started <- struct{}{}
+	<-u.releaseC
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.updates = append(u.updates, m)
+}
+
+func (u *blockingStatusUpdater) get() []diag.Messages {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.updates
+}
+
+func TestAsyncStatusUpdater_KeepsLatest(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	b := &blockingStatusUpdater{started: make(chan struct{}), releaseC: make(chan struct{})}
+	u := NewAsyncStatusUpdater(b)
+	u.Start()
+	defer u.Stop()
+
+	m1 := diag.Messages{diag.NewMessage(diag.Error, "IST0001", nil, "first")}
+	m2 := diag.Messages{diag.NewMessage(diag.Error, "IST0001", nil, "second")}
+	m3 := diag.Messages{diag.NewMessage(diag.Error, "IST0001", nil, "third")}
+
+	// Update returns right away, while the first write is in progress.
+	u.Update(m1)
+	<-b.started
+	u.Update(m2)
+	u.Update(m3)
+
+	// The first write finishes, and only the latest of the pending batches is written next.
+	b.releaseC <- struct{}{}
+	<-b.started
+	b.releaseC <- struct{}{}
+
+	g.Eventually(b.get).Should(Equal([]diag.Messages{m1, m3}))
+}
+
+func TestAsyncStatusUpdater_StopWithoutStart(t *testing.T) {
+	u := NewAsyncStatusUpdater(&InMemoryStatusUpdater{})
+	u.Stop()
+}
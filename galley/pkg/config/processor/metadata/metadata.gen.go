@@ -598,6 +598,70 @@ snapshots:
       - "istio/config/v1alpha2/legacy/tracespans"
       - "istio/config/v1alpha2/legacy/zipkins"
 
+  # Used by local analysis, which also needs the collections that are not distributed to Galley's clients.
+  - name: "localAnalysis"
+    strategy: immediate
+    collections:
+      - "istio/authentication/v1alpha1/meshpolicies"
+      - "istio/authentication/v1alpha1/policies"
+      - "istio/config/v1alpha2/adapters"
+      - "istio/config/v1alpha2/httpapispecs"
+      - "istio/config/v1alpha2/httpapispecbindings"
+      - "istio/config/v1alpha2/templates"
+      - "istio/mesh/v1alpha1/MeshConfig"
+      - "istio/mixer/v1/config/client/quotaspecbindings"
+      - "istio/mixer/v1/config/client/quotaspecs"
+      - "istio/networking/v1alpha3/destinationrules"
+      - "istio/networking/v1alpha3/envoyfilters"
+      - "istio/networking/v1alpha3/gateways"
+      - "istio/networking/v1alpha3/serviceentries"
+      - "istio/networking/v1alpha3/sidecars"
+      - "istio/networking/v1alpha3/virtualservices"
+      - "istio/policy/v1beta1/attributemanifests"
+      - "istio/policy/v1beta1/handlers"
+      - "istio/policy/v1beta1/instances"
+      - "istio/policy/v1beta1/rules"
+      - "istio/rbac/v1alpha1/clusterrbacconfigs"
+      - "istio/rbac/v1alpha1/rbacconfigs"
+      - "istio/rbac/v1alpha1/servicerolebindings"
+      - "istio/rbac/v1alpha1/serviceroles"
+      - "istio/security/v1beta1/authorizationpolicies"
+      - "k8s/core/v1/namespaces"
+      - "k8s/core/v1/pods"
+      - "k8s/core/v1/services"
+      # Legacy Mixer CRDs
+      - "istio/config/v1alpha2/legacy/apikeys"
+      - "istio/config/v1alpha2/legacy/authorizations"
+      - "istio/config/v1alpha2/legacy/bypasses"
+      - "istio/config/v1alpha2/legacy/checknothings"
+      - "istio/config/v1alpha2/legacy/circonuses"
+      - "istio/config/v1alpha2/legacy/cloudwatches"
+      - "istio/config/v1alpha2/legacy/deniers"
+      - "istio/config/v1alpha2/legacy/dogstatsds"
+      - "istio/config/v1alpha2/legacy/edges"
+      - "istio/config/v1alpha2/legacy/fluentds"
+      - "istio/config/v1alpha2/legacy/kuberneteses"
+      - "istio/config/v1alpha2/legacy/kubernetesenvs"
+      - "istio/config/v1alpha2/legacy/listcheckers"
+      - "istio/config/v1alpha2/legacy/listentries"
+      - "istio/config/v1alpha2/legacy/logentries"
+      - "istio/config/v1alpha2/legacy/memquotas"
+      - "istio/config/v1alpha2/legacy/metrics"
+      - "istio/config/v1alpha2/legacy/noops"
+      - "istio/config/v1alpha2/legacy/opas"
+      - "istio/config/v1alpha2/legacy/prometheuses"
+      - "istio/config/v1alpha2/legacy/quotas"
+      - "istio/config/v1alpha2/legacy/rbacs"
+      - "istio/config/v1alpha2/legacy/redisquotas"
+      - "istio/config/v1alpha2/legacy/reportnothings"
+      - "istio/config/v1alpha2/legacy/signalfxs"
+      - "istio/config/v1alpha2/legacy/solarwindses"
+      - "istio/config/v1alpha2/legacy/stackdrivers"
+      - "istio/config/v1alpha2/legacy/statsds"
+      - "istio/config/v1alpha2/legacy/stdios"
+      - "istio/config/v1alpha2/legacy/tracespans"
+      - "istio/config/v1alpha2/legacy/zipkins"
+
   - name: "syntheticServiceEntry"
     strategy: immediate
     collections:
@@ -1027,6 +1091,7 @@ transforms:
       "k8s/rbac.istio.io/v1alpha1/serviceroles":              "istio/rbac/v1alpha1/serviceroles"
       "k8s/security.istio.io/v1beta1/authorizationpolicies":  "istio/security/v1beta1/authorizationpolicies"
       "k8s/core/v1/namespaces":                               "k8s/core/v1/namespaces"
+      "k8s/core/v1/pods":                                     "k8s/core/v1/pods"
       "k8s/core/v1/services":                                 "k8s/core/v1/services"
       "istio/mesh/v1alpha1/MeshConfig":                       "istio/mesh/v1alpha1/MeshConfig"
 
@@ -14,7 +14,34 @@
 
 package resource
 
+import "fmt"
+
 // Origin of a resource. This is source-implementation dependent.
 type Origin interface {
 	FriendlyName() string
 }
+
+// PositionedOrigin is an Origin that also knows the position of the resource within its source file.
+type PositionedOrigin interface {
+	Origin
+
+	// SourcePosition returns the position of the resource, or nil if it is not known.
+	SourcePosition() *Position
+}
+
+// Position of a resource within a source file.
+type Position struct {
+	// Filename is the path of the source file.
+	Filename string `json:"filename"`
+
+	// Document is the 0-based index of the resource's document within a multi-document yaml file.
+	Document int `json:"document"`
+
+	// Line is the 1-based line number at which the resource starts.
+	Line int `json:"line"`
+}
+
+// String implements io.Stringer
+func (p *Position) String() string {
+	return fmt.Sprintf("%s:%d", p.Filename, p.Line)
+}
@@ -43,6 +43,19 @@ func (m *Metadata) Snapshots() []*Snapshot {
 	return result
 }
 
+// ExcludeSnapshots returns a copy of the metadata without the snapshots with the given names.
+func (m *Metadata) ExcludeSnapshots(names ...string) *Metadata {
+	result := *m
+	result.snapshots = make(map[string]*Snapshot, len(m.snapshots))
+	for name, s := range m.snapshots {
+		result.snapshots[name] = s
+	}
+	for _, name := range names {
+		delete(result.snapshots, name)
+	}
+	return &result
+}
+
 // Sources is all known sources
 func (m *Metadata) Sources() []Source {
 	result := make([]Source, len(m.sources))
@@ -323,6 +323,21 @@ func TestSchemaBasic(t *testing.T) {
 	g.Expect(s.KubeSource().Resources()[0].CanonicalResourceName()).To(Equal("networking.istio.io/v1alpha3/VirtualService"))
 }
 
+func TestSchema_ExcludeSnapshots(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, err := ParseAndBuild(input)
+	g.Expect(err).To(BeNil())
+
+	excluded := s.ExcludeSnapshots("default")
+	g.Expect(excluded.Snapshots()).To(BeEmpty())
+	g.Expect(excluded.AllCollectionsInSnapshots()).To(BeEmpty())
+	g.Expect(excluded.Collections()).To(Equal(s.Collections()))
+
+	// The original metadata is unchanged.
+	g.Expect(s.Snapshots()).To(HaveLen(1))
+}
+
 func TestSchema_Find(t *testing.T) {
 	g := NewGomegaWithT(t)
 
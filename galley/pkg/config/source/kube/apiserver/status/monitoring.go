@@ -0,0 +1,81 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"istio.io/istio/galley/pkg/config/scope"
+)
+
+const code = "code"
+
+var (
+	// CodeTag holds the code of an analysis message.
+	CodeTag tag.Key
+
+	analysisMessages = stats.Int64(
+		"galley/analysis/messages",
+		"The number of analysis messages currently reported for the cluster, by message code",
+		stats.UnitDimensionless)
+
+	statusUpdateFailures = stats.Int64(
+		"galley/analysis/status_update_failures_total",
+		"The number of times writing analysis results to a resource failed",
+		stats.UnitDimensionless)
+)
+
+func recordMessageCount(c string, count int64) {
+	ctx, err := tag.New(context.Background(), tag.Insert(CodeTag, c))
+	if err != nil {
+		scope.Analysis.Errorf("error creating context to record analysis message count: %v", err)
+		return
+	}
+	stats.Record(ctx, analysisMessages.M(count))
+}
+
+func recordStatusUpdateFailure() {
+	stats.Record(context.Background(), statusUpdateFailures.M(1))
+}
+
+func newView(measure stats.Measure, keys []tag.Key, aggregation *view.Aggregation) *view.View {
+	return &view.View{
+		Name:        measure.Name(),
+		Description: measure.Description(),
+		Measure:     measure,
+		TagKeys:     keys,
+		Aggregation: aggregation,
+	}
+}
+
+func init() {
+	var err error
+	if CodeTag, err = tag.NewKey(code); err != nil {
+		panic(err)
+	}
+
+	var noKeys []tag.Key
+	err = view.Register(
+		newView(analysisMessages, []tag.Key{CodeTag}, view.LastValue()),
+		newView(statusUpdateFailures, noKeys, view.Count()),
+	)
+	if err != nil {
+		panic(err)
+	}
+}
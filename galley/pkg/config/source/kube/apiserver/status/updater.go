@@ -0,0 +1,350 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeSchema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/collection"
+	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/schema"
+	"istio.io/istio/galley/pkg/config/scope"
+	"istio.io/istio/galley/pkg/config/source/kube"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+)
+
+// component is the name of the event source that is used when recording events.
+const component = "galley"
+
+// Options for the Updater.
+type Options struct {
+	// Client is used to access the Kubernetes API server.
+	Client kube.Interfaces
+
+	// Resources are the Kubernetes resources that analysis messages can originate from.
+	Resources schema.KubeResources
+
+	// WriteStatus enables writing analysis messages to the status of the offending resources. Status is only written
+	// for custom resources.
+	WriteStatus bool
+
+	// WriteEvents enables recording newly reported analysis messages as events on the offending resources.
+	WriteEvents bool
+}
+
+// Updater is a snapshotter.StatusUpdater implementation that writes analysis messages back to the Kubernetes
+// resources that they originate from. Messages that are no longer reported are cleared from the resource status.
+type Updater struct {
+	options Options
+
+	mu      sync.Mutex
+	dynamic dynamic.Interface
+	kube    kubernetes.Interface
+
+	// The status messages last written for each resource.
+	last map[key]*state
+
+	// The message codes last recorded in metrics.
+	lastCodes map[string]struct{}
+}
+
+var _ snapshotter.StatusUpdater = &Updater{}
+
+type key struct {
+	col  collection.Name
+	name resource.Name
+}
+
+type state struct {
+	origin   *rt.Origin
+	messages diag.Messages
+	statuses []string
+}
+
+// NewUpdater returns a new Updater.
+func NewUpdater(o Options) *Updater {
+	return &Updater{
+		options:   o,
+		last:      make(map[key]*state),
+		lastCodes: make(map[string]struct{}),
+	}
+}
+
+// Update implements snapshotter.StatusUpdater
+func (u *Updater) Update(messages diag.Messages) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.recordMetrics(messages)
+
+	current := make(map[key]*state)
+	for _, m := range messages {
+		o, ok := m.Origin.(*rt.Origin)
+		if !ok {
+			scope.Analysis.Debugf("Skipping status update for message without a Kubernetes origin: %v", m.String())
+			continue
+		}
+
+		k := key{col: o.Collection, name: o.Name}
+		s, ok := current[k]
+		if !ok {
+			s = &state{origin: o}
+			current[k] = s
+		}
+		s.messages = append(s.messages, m)
+		s.statuses = append(s.statuses, m.StatusString())
+	}
+
+	next := make(map[key]*state, len(current))
+	for k, s := range current {
+		prev := u.last[k]
+		if prev != nil && reflect.DeepEqual(prev.statuses, s.statuses) {
+			next[k] = s
+			continue
+		}
+
+		if err := u.apply(s, prev); err != nil {
+			scope.Analysis.Errorf("Error writing analysis results for %s: %v", s.origin.FriendlyName(), err)
+			recordStatusUpdateFailure()
+			// Do not track the new state, so that the write is retried on the next update.
+			if prev != nil {
+				next[k] = prev
+			}
+			continue
+		}
+		next[k] = s
+	}
+
+	for k, prev := range u.last {
+		if _, ok := current[k]; ok {
+			continue
+		}
+
+		if err := u.clear(prev); err != nil {
+			scope.Analysis.Errorf("Error clearing analysis results for %s: %v", prev.origin.FriendlyName(), err)
+			recordStatusUpdateFailure()
+			// Keep tracking the old state, so that the clear is retried on the next update.
+			next[k] = prev
+		}
+	}
+
+	u.last = next
+}
+
+func (u *Updater) recordMetrics(messages diag.Messages) {
+	counts := make(map[string]int64)
+	for _, m := range messages {
+		counts[m.Code]++
+	}
+
+	// Reset the codes that are no longer reported.
+	for c := range u.lastCodes {
+		if _, ok := counts[c]; !ok {
+			recordMessageCount(c, 0)
+		}
+	}
+
+	u.lastCodes = make(map[string]struct{}, len(counts))
+	for c, n := range counts {
+		recordMessageCount(c, n)
+		u.lastCodes[c] = struct{}{}
+	}
+}
+
+func (u *Updater) apply(s *state, prev *state) error {
+	r, ok := u.findResource(s.origin.Collection)
+	if !ok {
+		return fmt.Errorf("unknown collection: %v", s.origin.Collection)
+	}
+
+	if u.options.WriteStatus && !isBuiltIn(r) {
+		if err := u.writeStatus(r, s.origin, s.messages); err != nil {
+			return err
+		}
+	}
+
+	if u.options.WriteEvents {
+		for _, m := range s.messages {
+			if prev != nil && contains(prev.statuses, m.StatusString()) {
+				continue
+			}
+			if err := u.recordEvent(r, s.origin, m); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (u *Updater) clear(prev *state) error {
+	r, ok := u.findResource(prev.origin.Collection)
+	if !ok {
+		return fmt.Errorf("unknown collection: %v", prev.origin.Collection)
+	}
+
+	if u.options.WriteStatus && !isBuiltIn(r) {
+		return u.writeStatus(r, prev.origin, nil)
+	}
+	return nil
+}
+
+// writeStatus sets the validation messages in the status of the given resource. If there are no messages, the
+// validation messages are removed from the status. Only the validation messages are patched, so that concurrent
+// changes to the rest of the resource are neither overwritten nor conflicting.
+func (u *Updater) writeStatus(r schema.KubeResource, o *rt.Origin, messages diag.Messages) error {
+	d, err := u.dynamicResource(r)
+	if err != nil {
+		return err
+	}
+
+	ns, name := o.Name.InterpretAsNamespaceAndName()
+	var ri dynamic.ResourceInterface = d
+	if ns != "" {
+		ri = d.Namespace(ns)
+	}
+
+	// A null value removes the field in a JSON merge patch.
+	var vms []interface{}
+	if len(messages) > 0 {
+		vms = make([]interface{}, 0, len(messages))
+		for _, m := range messages {
+			vms = append(vms, map[string]interface{}{
+				"code":    m.Code,
+				"level":   string(m.Level),
+				"message": m.StatusString(),
+			})
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"validationMessages": vms,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = ri.Patch(name, types.MergePatchType, patch, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		// The resource is gone, so there is nothing to update.
+		return nil
+	}
+	return err
+}
+
+func (u *Updater) recordEvent(r schema.KubeResource, o *rt.Origin, m diag.Message) error {
+	k, err := u.kubeClient()
+	if err != nil {
+		return err
+	}
+
+	ns, name := o.Name.InterpretAsNamespaceAndName()
+	if ns == "" {
+		ns = v1.NamespaceDefault
+	}
+
+	eventType := v1.EventTypeNormal
+	if m.Level.IsWorseThanOrEqualTo(diag.Warning) {
+		eventType = v1.EventTypeWarning
+	}
+
+	now := metav1.Now()
+	e := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + ".",
+			Namespace:    ns,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:            r.Kind,
+			APIVersion:      kubeSchema.GroupVersion{Group: r.Group, Version: r.Version}.String(),
+			Namespace:       ns,
+			Name:            name,
+			ResourceVersion: string(o.Version),
+		},
+		Reason:         m.Code,
+		Message:        m.StatusString(),
+		Type:           eventType,
+		Source:         v1.EventSource{Component: component},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	_, err = k.CoreV1().Events(ns).Create(e)
+	return err
+}
+
+func (u *Updater) findResource(col collection.Name) (schema.KubeResource, bool) {
+	for _, r := range u.options.Resources {
+		if r.Collection.Name == col {
+			return r, true
+		}
+	}
+	return schema.KubeResource{}, false
+}
+
+func (u *Updater) dynamicResource(r schema.KubeResource) (dynamic.NamespaceableResourceInterface, error) {
+	if u.dynamic == nil {
+		d, err := u.options.Client.DynamicInterface()
+		if err != nil {
+			return nil, err
+		}
+		u.dynamic = d
+	}
+
+	return u.dynamic.Resource(kubeSchema.GroupVersionResource{
+		Group:    r.Group,
+		Version:  r.Version,
+		Resource: r.Plural,
+	}), nil
+}
+
+func (u *Updater) kubeClient() (kubernetes.Interface, error) {
+	if u.kube == nil {
+		k, err := u.options.Client.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+		u.kube = k
+	}
+	return u.kube, nil
+}
+
+func isBuiltIn(r schema.KubeResource) bool {
+	return rt.DefaultProvider().GetAdapter(r).IsBuiltIn()
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
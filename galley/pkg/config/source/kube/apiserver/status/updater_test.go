@@ -0,0 +1,253 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	. "github.com/onsi/gomega"
+	extclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
+	kubeSchema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"istio.io/istio/galley/pkg/config/analysis/diag"
+	"istio.io/istio/galley/pkg/config/analysis/msg"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
+	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/schema"
+	"istio.io/istio/galley/pkg/config/source/kube"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
+)
+
+type fakeInterfaces struct {
+	dynamic dynamic.Interface
+	kube    kubernetes.Interface
+}
+
+var _ kube.Interfaces = &fakeInterfaces{}
+
+func (f *fakeInterfaces) DynamicInterface() (dynamic.Interface, error) {
+	return f.dynamic, nil
+}
+
+func (f *fakeInterfaces) APIExtensionsClientset() (extclientset.Interface, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *fakeInterfaces) KubeClient() (kubernetes.Interface, error) {
+	return f.kube, nil
+}
+
+func virtualServiceResource() schema.KubeResource {
+	return metadata.MustGet().KubeSource().Resources().MustFind("networking.istio.io", "VirtualService")
+}
+
+func newVirtualService(ns, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "networking.istio.io/v1alpha3",
+			"kind":       "VirtualService",
+			"metadata": map[string]interface{}{
+				"name":            name,
+				"namespace":       ns,
+				"resourceVersion": "v1",
+			},
+			"spec": map[string]interface{}{},
+		},
+	}
+}
+
+func newEntry(ns, name string) *resource.Entry {
+	r := virtualServiceResource()
+	return &resource.Entry{
+		Metadata: resource.Metadata{
+			Name: resource.NewName(ns, name),
+		},
+		Origin: &rt.Origin{
+			Collection: r.Collection.Name,
+			Kind:       r.Kind,
+			Name:       resource.NewName(ns, name),
+			Version:    "v1",
+		},
+	}
+}
+
+// mergePatchReactor applies JSON merge patches, which the fake dynamic client does not support.
+func mergePatchReactor(tracker k8stesting.ObjectTracker) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, k8sRuntime.Object, error) {
+		a := action.(k8stesting.PatchAction)
+		if a.GetPatchType() != types.MergePatchType {
+			return false, nil, nil
+		}
+		obj, err := tracker.Get(a.GetResource(), a.GetNamespace(), a.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		old, err := json.Marshal(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		patched, err := jsonpatch.MergePatch(old, a.GetPatch())
+		if err != nil {
+			return true, nil, err
+		}
+		u := &unstructured.Unstructured{}
+		if err = u.UnmarshalJSON(patched); err != nil {
+			return true, nil, err
+		}
+		if err = tracker.Update(a.GetResource(), u, a.GetNamespace()); err != nil {
+			return true, nil, err
+		}
+		return true, u, nil
+	}
+}
+
+func setup(objs ...k8sRuntime.Object) (*Updater, dynamic.ResourceInterface, *kubefake.Clientset) {
+	u, ri, k, _ := setupWithDynamic(objs...)
+	return u, ri, k
+}
+
+func setupWithDynamic(objs ...k8sRuntime.Object) (*Updater, dynamic.ResourceInterface, *kubefake.Clientset,
+	*dynamicfake.FakeDynamicClient) {
+	// The tracker of the fake dynamic client is not accessible, so use a separate one to support merge patches.
+	scheme := k8sRuntime.NewScheme()
+	tracker := k8stesting.NewObjectTracker(scheme, serializer.NewCodecFactory(scheme).UniversalDecoder())
+	for _, obj := range objs {
+		if err := tracker.Add(obj); err != nil {
+			panic(err)
+		}
+	}
+	d := dynamicfake.NewSimpleDynamicClient(scheme)
+	d.PrependReactor("*", "*", k8stesting.ObjectReaction(tracker))
+	d.PrependReactor("patch", "*", mergePatchReactor(tracker))
+	k := kubefake.NewSimpleClientset()
+
+	u := NewUpdater(Options{
+		Client:      &fakeInterfaces{dynamic: d, kube: k},
+		Resources:   metadata.MustGet().KubeSource().Resources(),
+		WriteStatus: true,
+		WriteEvents: true,
+	})
+
+	r := virtualServiceResource()
+	ri := d.Resource(kubeSchema.GroupVersionResource{
+		Group:    r.Group,
+		Version:  r.Version,
+		Resource: r.Plural,
+	}).Namespace("ns")
+
+	return u, ri, k, d
+}
+
+func validationMessages(g *GomegaWithT, ri dynamic.ResourceInterface, name string) []interface{} {
+	obj, err := ri.Get(name, metav1.GetOptions{})
+	g.Expect(err).To(BeNil())
+	vms, _, err := unstructured.NestedSlice(obj.Object, "status", "validationMessages")
+	g.Expect(err).To(BeNil())
+	return vms
+}
+
+func eventCount(g *GomegaWithT, k *kubefake.Clientset) int {
+	events, err := k.CoreV1().Events("ns").List(metav1.ListOptions{})
+	g.Expect(err).To(BeNil())
+	return len(events.Items)
+}
+
+func TestUpdater_WritesStatusAndEvents(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u, ri, k := setup(newVirtualService("ns", "vs1"), newVirtualService("ns", "vs2"))
+
+	m := msg.ReferencedResourceNotFound(newEntry("ns", "vs1"), "gateway", "gw")
+	u.Update(diag.Messages{m})
+
+	g.Expect(validationMessages(g, ri, "vs1")).To(ConsistOf(map[string]interface{}{
+		"code":    "IST0101",
+		"level":   "Error",
+		"message": m.StatusString(),
+	}))
+	g.Expect(validationMessages(g, ri, "vs2")).To(BeEmpty())
+	g.Expect(eventCount(g, k)).To(Equal(1))
+
+	// Reporting the same messages again should not record new events.
+	u.Update(diag.Messages{m})
+	g.Expect(eventCount(g, k)).To(Equal(1))
+}
+
+func TestUpdater_ClearsResolvedMessages(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u, ri, _ := setup(newVirtualService("ns", "vs1"))
+
+	u.Update(diag.Messages{msg.ReferencedResourceNotFound(newEntry("ns", "vs1"), "gateway", "gw")})
+	g.Expect(validationMessages(g, ri, "vs1")).To(HaveLen(1))
+
+	u.Update(diag.Messages{})
+	g.Expect(validationMessages(g, ri, "vs1")).To(BeEmpty())
+	g.Expect(u.last).To(BeEmpty())
+}
+
+func TestUpdater_PatchesOnlyStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u, _, _, d := setupWithDynamic(newVirtualService("ns", "vs1"))
+
+	u.Update(diag.Messages{msg.ReferencedResourceNotFound(newEntry("ns", "vs1"), "gateway", "gw")})
+
+	var patches []map[string]interface{}
+	for _, a := range d.Actions() {
+		g.Expect(a.GetVerb()).NotTo(Equal("update"))
+		if p, ok := a.(k8stesting.PatchAction); ok {
+			g.Expect(p.GetPatchType()).To(Equal(types.MergePatchType))
+			patch := map[string]interface{}{}
+			g.Expect(json.Unmarshal(p.GetPatch(), &patch)).To(Succeed())
+			patches = append(patches, patch)
+		}
+	}
+	g.Expect(patches).To(HaveLen(1))
+	g.Expect(patches[0]).To(HaveLen(1))
+	g.Expect(patches[0]).To(HaveKey("status"))
+}
+
+func TestUpdater_IgnoresMissingResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u, _, _ := setup()
+
+	u.Update(diag.Messages{msg.ReferencedResourceNotFound(newEntry("ns", "vs1"), "gateway", "gw")})
+	g.Expect(u.last).To(HaveLen(1))
+}
+
+func TestUpdater_IgnoresMessagesWithoutKubeOrigin(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	u, _, k := setup()
+
+	u.Update(diag.Messages{msg.InternalError(nil, "oops")})
+	g.Expect(u.last).To(BeEmpty())
+	g.Expect(eventCount(g, k)).To(Equal(0))
+}
@@ -19,6 +19,7 @@ import (
 	"crypto/sha1"
 	"fmt"
 	"sync"
+	"unicode"
 
 	"github.com/ghodss/yaml"
 	kubeJson "k8s.io/apimachinery/pkg/runtime/serializer/json"
@@ -183,8 +184,8 @@ func (s *KubeSource) RemoveContent(name string) {
 
 func parseContent(r schema.KubeResources, name, yamlText string) []kubeResource {
 	var resources []kubeResource
-	for i, chunk := range kubeyaml.Split([]byte(yamlText)) {
-		chunk = bytes.TrimSpace(chunk)
+	for i, part := range kubeyaml.SplitParts([]byte(yamlText)) {
+		chunk := bytes.TrimSpace(part.Content)
 
 		r, err := parseChunk(r, chunk)
 		if err != nil {
@@ -193,6 +194,16 @@ func parseContent(r schema.KubeResources, name, yamlText string) []kubeResource
 			continue
 		}
 
+		if o, ok := r.entry.Origin.(*rt.Origin); ok {
+			// Skip the leading blank lines that were trimmed from the chunk.
+			leading := part.Content[:len(part.Content)-len(bytes.TrimLeftFunc(part.Content, unicode.IsSpace))]
+			o.Position = &resource.Position{
+				Filename: name,
+				Document: i,
+				Line:     part.Line + bytes.Count(leading, []byte("\n")),
+			}
+		}
+
 		resources = append(resources, r)
 	}
 	return resources
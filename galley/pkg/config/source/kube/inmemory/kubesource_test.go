@@ -21,6 +21,7 @@ import (
 
 	"istio.io/istio/galley/pkg/config/event"
 	"istio.io/istio/galley/pkg/config/resource"
+	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/config/testing/basicmeta"
 	"istio.io/istio/galley/pkg/config/testing/data"
 	"istio.io/istio/galley/pkg/config/testing/data/builtin"
@@ -52,6 +53,23 @@ func TestKubeSource_ApplyContent(t *testing.T) {
 	g.Expect(acc.Events()[1].Entry.Metadata.Name).To(Equal(data.EntryN1I1V1.Metadata.Name))
 }
 
+func TestKubeSource_ApplyContent_Positions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s, _ := setupKubeSource()
+	s.Start()
+	defer s.Stop()
+
+	err := s.ApplyContent("foo.yaml", kubeyaml.JoinString(data.YamlN1I1V1, data.YamlN2I2V1))
+	g.Expect(err).To(BeNil())
+
+	actual := s.Get(data.Collection1).AllSorted()
+	g.Expect(actual).To(HaveLen(2))
+
+	g.Expect(actual[0].Origin.(*rt.Origin).Position).To(Equal(&resource.Position{Filename: "foo.yaml", Document: 0, Line: 2}))
+	g.Expect(actual[1].Origin.(*rt.Origin).Position).To(Equal(&resource.Position{Filename: "foo.yaml", Document: 1, Line: 11}))
+}
+
 func TestKubeSource_ApplyContent_BeforeStart(t *testing.T) {
 	g := NewGomegaWithT(t)
 
@@ -27,11 +27,19 @@ type Origin struct {
 	Kind       string
 	Name       resource.Name
 	Version    resource.Version
+
+	// Position of the resource in its source file, if it was read from a file.
+	Position *resource.Position
 }
 
-var _ resource.Origin = &Origin{}
+var _ resource.PositionedOrigin = &Origin{}
 
 // FriendlyName implements resource.Origin
 func (o *Origin) FriendlyName() string {
 	return fmt.Sprintf("%s/%s", o.Kind, o.Name.String())
 }
+
+// SourcePosition implements resource.PositionedOrigin
+func (o *Origin) SourcePosition() *resource.Position {
+	return o.Position
+}
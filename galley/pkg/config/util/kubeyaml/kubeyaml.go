@@ -35,6 +35,30 @@ func Split(yamlText []byte) [][]byte {
 	return result
 }
 
+// Part is a single document of a multipart yaml document.
+type Part struct {
+	// Content of the document.
+	Content []byte
+
+	// Line is the 1-based line number in the original yaml text at which the document starts.
+	Line int
+}
+
+// SplitParts splits the given yaml doc if it's multipart document, keeping track of where each part starts.
+func SplitParts(yamlText []byte) []Part {
+	parts := bytes.Split(yamlText, []byte(yamlSeparator))
+	var result []Part
+	line := 1
+	for _, p := range parts {
+		if len(p) != 0 {
+			result = append(result, Part{Content: p, Line: line})
+		}
+		// Account for the lines in the part, plus the line of the separator itself.
+		line += bytes.Count(p, []byte("\n")) + 1
+	}
+	return result
+}
+
 // SplitString splits the given yaml doc if it's multipart document.
 func SplitString(yamlText string) []string {
 	parts := strings.Split(yamlText, yamlSeparator)
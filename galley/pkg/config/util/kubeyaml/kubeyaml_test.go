@@ -177,3 +177,23 @@ func TestJoinString(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitParts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	actual := SplitParts([]byte(`
+yaml: foo
+---
+---
+bar: boo
+baz: bee
+---
+qux: quux
+`))
+
+	g.Expect(actual).To(Equal([]Part{
+		{Content: []byte("\nyaml: foo\n"), Line: 1},
+		{Content: []byte("bar: boo\nbaz: bee\n"), Line: 5},
+		{Content: []byte("qux: quux\n"), Line: 8},
+	}))
+}
@@ -26,6 +26,10 @@ const (
 
 	// SyntheticServiceEntry is the group used for the SynetheticServiceEntry collection.
 	SyntheticServiceEntry = "syntheticServiceEntry"
+
+	// LocalAnalysis is the group analyzed by local and in-cluster analysis. Galley only builds it when in-cluster
+	// analysis is enabled, and keeps it out of the MCP cache.
+	LocalAnalysis = "localAnalysis"
 )
 
 var _ snapshot.GroupIndexFn = IndexFunction
@@ -32,6 +32,8 @@ import (
 	"istio.io/pkg/log"
 	"istio.io/pkg/version"
 
+	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/local"
 	"istio.io/istio/galley/pkg/config/event"
 	"istio.io/istio/galley/pkg/config/processing"
 	"istio.io/istio/galley/pkg/config/processing/snapshotter"
@@ -39,6 +41,7 @@ import (
 	"istio.io/istio/galley/pkg/config/schema"
 	"istio.io/istio/galley/pkg/config/source/kube"
 	"istio.io/istio/galley/pkg/config/source/kube/apiserver"
+	"istio.io/istio/galley/pkg/config/source/kube/apiserver/status"
 	"istio.io/istio/galley/pkg/config/source/kube/rt"
 	"istio.io/istio/galley/pkg/runtime/groups"
 	"istio.io/istio/galley/pkg/server/process"
@@ -68,6 +71,7 @@ type Processing2 struct {
 	mcpSource     *source.Server
 	reporter      monitoring.Reporter
 	callOut       *callout
+	statusUpdater *snapshotter.AsyncStatusUpdater
 	listenerMutex sync.Mutex
 	listener      net.Listener
 	stopCh        chan struct{}
@@ -95,6 +99,9 @@ func (p *Processing2) Start() (err error) {
 	}
 
 	m := metadata.MustGet()
+	// The localAnalysis snapshot holds every namespace, pod and service, it is only built for the analyzers and
+	// never served to MCP clients.
+	served := m.ExcludeSnapshots(groups.LocalAnalysis)
 
 	kubeResources := p.disableExcludedKubeResources(m)
 
@@ -103,8 +110,18 @@ func (p *Processing2) Start() (err error) {
 	}
 
 	var distributor snapshotter.Distributor = snapshotter.NewMCPDistributor(p.mcpCache)
+	processed := served
 
-	if p.runtime, err = processorInitialize(m, p.args.DomainSuffix, event.CombineSources(mesh, src), distributor); err != nil {
+	if p.args.EnableConfigAnalysis {
+		if distributor, err = p.createAnalyzingDistributor(kubeResources, distributor); err != nil {
+			return
+		}
+		if p.isAnalysisEnabled() {
+			processed = m
+		}
+	}
+
+	if p.runtime, err = processorInitialize(processed, p.args.DomainSuffix, event.CombineSources(mesh, src), distributor); err != nil {
 		return
 	}
 
@@ -133,7 +150,7 @@ func (p *Processing2) Start() (err error) {
 	options := &source.Options{
 		Watcher:            p.mcpCache,
 		Reporter:           p.reporter,
-		CollectionsOptions: source.CollectionOptionsFromSlice(m.AllCollectionsInSnapshots()),
+		CollectionsOptions: source.CollectionOptionsFromSlice(served.AllCollectionsInSnapshots()),
 		ConnRateLimiter:    mcprate.NewRateLimiter(time.Second, 100), // TODO(Nino-K): https://github.com/istio/istio/issues/12074
 	}
 
@@ -217,6 +234,8 @@ func (p *Processing2) disableExcludedKubeResources(m *schema.Metadata) schema.Ku
 	// Behave in the same way as existing logic:
 	// - Builtin types are excluded by default.
 	// - If ServiceDiscovery is enabled, any built-in type should be readded.
+	// - If config analysis is enabled, any built-in type should be readded as well, as the analyzers need the
+	//   Services, Pods and Namespaces.
 
 	var result schema.KubeResources
 	for _, r := range m.KubeSource().Resources() {
@@ -225,12 +244,12 @@ func (p *Processing2) disableExcludedKubeResources(m *schema.Metadata) schema.Ku
 			// Found a matching exclude directive for this KubeResource. Disable the resource.
 			r.Disabled = true
 
-			// Check and see if this is needed for Service Discovery. If needed, we will need to re-enable.
-			if p.args.EnableServiceDiscovery {
-				// IsBuiltIn is a proxy for types needed for service discovery
+			// Check and see if this is needed for Service Discovery or analysis. If needed, we will need to re-enable.
+			if p.args.EnableServiceDiscovery || p.isAnalysisEnabled() {
+				// IsBuiltIn is a proxy for types needed for service discovery and analysis
 				a := rt.DefaultProvider().GetAdapter(r)
 				if a.IsBuiltIn() {
-					// This is needed for service discovery. Re-enable.
+					// This is needed for service discovery or analysis. Re-enable.
 					r.Disabled = false
 				}
 			}
@@ -305,6 +324,38 @@ func (p *Processing2) createSource(resources schema.KubeResources) (src event.So
 	return
 }
 
+func (p *Processing2) createAnalyzingDistributor(resources schema.KubeResources,
+	d snapshotter.Distributor) (snapshotter.Distributor, error) {
+
+	if !p.isAnalysisEnabled() {
+		scope.Warnf("Config analysis is only supported with a Kubernetes config source, disabling analysis")
+		return d, nil
+	}
+
+	k, err := p.getKubeInterfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	// Status is written in the background, as writing it can take a while with many resources.
+	p.statusUpdater = snapshotter.NewAsyncStatusUpdater(status.NewUpdater(status.Options{
+		Client:      k,
+		Resources:   resources,
+		WriteStatus: true,
+		WriteEvents: true,
+	}))
+	p.statusUpdater.Start()
+	a := local.NewSuppressingAnalyzer(analyzers.All(), nil)
+	// The localAnalysis snapshot holds every collection the analyzers take as input. The analyzing distributor keeps
+	// it from the MCP cache.
+	return snapshotter.NewAnalyzingDistributor(p.statusUpdater, a, d, groups.LocalAnalysis), nil
+}
+
+// isAnalysisEnabled returns whether in-cluster config analysis is performed.
+func (p *Processing2) isAnalysisEnabled() bool {
+	return p.args.EnableConfigAnalysis && p.args.ConfigPath == ""
+}
+
 func (p *Processing2) isKindExcluded(kind string) bool {
 	for _, excludedKind := range p.args.ExcludedResourceKinds {
 		if kind == excludedKind {
@@ -332,6 +383,11 @@ func (p *Processing2) Stop() {
 		p.runtime = nil
 	}
 
+	if p.statusUpdater != nil {
+		p.statusUpdater.Stop()
+		p.statusUpdater = nil
+	}
+
 	p.listenerMutex.Lock()
 	if p.listener != nil {
 		_ = p.listener.Close()
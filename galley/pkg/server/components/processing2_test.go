@@ -21,6 +21,7 @@ import (
 	"os"
 	"path"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	k8sRuntime "k8s.io/apimachinery/pkg/runtime"
@@ -30,8 +31,11 @@ import (
 	"istio.io/istio/galley/pkg/config/meshcfg"
 	"istio.io/istio/galley/pkg/config/processing"
 	"istio.io/istio/galley/pkg/config/processing/snapshotter"
+	"istio.io/istio/galley/pkg/config/processor"
+	"istio.io/istio/galley/pkg/config/processor/metadata"
 	"istio.io/istio/galley/pkg/config/schema"
 	"istio.io/istio/galley/pkg/config/source/kube"
+	"istio.io/istio/galley/pkg/runtime/groups"
 	"istio.io/istio/galley/pkg/server/settings"
 	"istio.io/istio/galley/pkg/source/kube/client"
 	"istio.io/istio/galley/pkg/testing/mock"
@@ -136,3 +140,96 @@ func TestProcessing2_Basic(t *testing.T) {
 
 	g.Expect(p.Address()).To(BeNil())
 }
+
+func TestProcessing2_DisableExcludedKubeResources(t *testing.T) {
+	cases := []struct {
+		name             string
+		serviceDiscovery bool
+		analysis         bool
+		configPath       string
+		servicesEnabled  bool
+	}{
+		{name: "default", servicesEnabled: false},
+		{name: "service discovery", serviceDiscovery: true, servicesEnabled: true},
+		{name: "analysis", analysis: true, servicesEnabled: true},
+		{name: "analysis with config path", analysis: true, configPath: "aaa", servicesEnabled: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			args := settings.DefaultArgs()
+			args.EnableServiceDiscovery = c.serviceDiscovery
+			args.EnableConfigAnalysis = c.analysis
+			args.ConfigPath = c.configPath
+
+			p := NewProcessing2(args)
+			resources := p.disableExcludedKubeResources(metadata.MustGet())
+
+			r, found := resources.Find("", "Service")
+			g.Expect(found).To(BeTrue())
+			g.Expect(r.Disabled).To(Equal(!c.servicesEnabled))
+		})
+	}
+}
+
+func TestProcessing2_LocalAnalysisNotServed(t *testing.T) {
+	cases := []struct {
+		name       string
+		analysis   bool
+		configPath bool
+	}{
+		{name: "analysis disabled"},
+		{name: "analysis with config path", analysis: true, configPath: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			resetPatchTable()
+			defer resetPatchTable()
+
+			mk := mock.NewKube()
+			mk.AddResponse(fake.NewSimpleDynamicClient(k8sRuntime.NewScheme()), nil)
+			newKubeFromConfigFile = func(string) (client.Interfaces, error) { return mk, nil }
+			mcpMetricReporter = func(s string) monitoring.Reporter {
+				return mcptestmon.NewInMemoryStatsContext()
+			}
+			checkResourceTypesPresence = func(_ kube.Interfaces, _ schema.KubeResources) error { return nil }
+			meshcfgNewFS = func(path string) (event.Source, error) {
+				m := meshcfg.NewInmemory()
+				m.Set(meshcfg.Default())
+				return m, nil
+			}
+			var snapshots []string
+			processorInitialize = func(m *schema.Metadata, domainSuffix string, source event.Source,
+				distributor snapshotter.Distributor) (*processing.Runtime, error) {
+				for _, s := range m.Snapshots() {
+					snapshots = append(snapshots, s.Name)
+				}
+				return processor.Initialize(m, domainSuffix, source, distributor)
+			}
+
+			args := settings.DefaultArgs()
+			args.APIAddress = "tcp://0.0.0.0:0"
+			args.Insecure = true
+			args.EnableConfigAnalysis = c.analysis
+			if c.configPath {
+				tmpDir, err := ioutil.TempDir(os.TempDir(), "processing2")
+				g.Expect(err).To(BeNil())
+				defer func() { _ = os.RemoveAll(tmpDir) }()
+				args.ConfigPath = tmpDir
+			}
+
+			p := NewProcessing2(args)
+			g.Expect(p.Start()).To(BeNil())
+			defer p.Stop()
+
+			g.Expect(snapshots).NotTo(BeEmpty())
+			g.Expect(snapshots).NotTo(ContainElement(groups.LocalAnalysis))
+			g.Eventually(p.mcpCache.GetGroups, 10*time.Second).Should(ContainElement(groups.Default))
+			g.Consistently(p.mcpCache.GetGroups, 100*time.Millisecond).ShouldNot(ContainElement(groups.LocalAnalysis))
+		})
+	}
+}
@@ -108,6 +108,10 @@ type Args struct { // nolint:maligned
 	// Enable service discovery / endpoint processing.
 	EnableServiceDiscovery bool
 
+	// Enable continuous analysis of the config in the cluster. Analysis results are written back to the status
+	// of the offending resources, and recorded as events.
+	EnableConfigAnalysis bool
+
 	// DisableResourceReadyCheck disables the CRD readiness check. This
 	// allows Galley to start when not all supported CRD are
 	// registered with the kube-apiserver.
@@ -195,6 +199,7 @@ func (a *Args) String() string {
 	_, _ = fmt.Fprintf(buf, "ConfigFilePath: %s\n", a.ConfigPath)
 	_, _ = fmt.Fprintf(buf, "MeshConfigFile: %s\n", a.MeshConfigFile)
 	_, _ = fmt.Fprintf(buf, "DomainSuffix: %s\n", a.DomainSuffix)
+	_, _ = fmt.Fprintf(buf, "EnableConfigAnalysis: %v\n", a.EnableConfigAnalysis)
 	_, _ = fmt.Fprintf(buf, "DisableResourceReadyCheck: %v\n", a.DisableResourceReadyCheck)
 	_, _ = fmt.Fprintf(buf, "ExcludedResourceKinds: %v\n", a.ExcludedResourceKinds)
 	_, _ = fmt.Fprintf(buf, "SinkAddress: %v\n", a.SinkAddress)
@@ -15,10 +15,16 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
 
 	"istio.io/istio/galley/pkg/config/analysis/analyzers"
+	"istio.io/istio/galley/pkg/config/analysis/diag"
 	"istio.io/istio/galley/pkg/config/analysis/local"
 	"istio.io/istio/galley/pkg/config/processor/metadata"
 	"istio.io/istio/galley/pkg/source/kube/client"
@@ -28,28 +34,80 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	logOutput  = "log"
+	yamlOutput = "yaml"
+
+	// stdinArg is the file argument that stands for the standard input, which is called stdinName in messages.
+	stdinArg  = "-"
+	stdinName = "stdin"
+)
+
 var (
-	useKube bool
+	useKube          bool
+	recursive        bool
+	msgOutputFormat  string
+	failureThreshold string
+	analyzerNames    []string
+	suppress         []string
+
+	msgOutputFormats = []string{logOutput, jsonOutput, yamlOutput}
+
+	yamlFileExtensions = []string{".yaml", ".yml"}
 )
 
+// AnalyzerFoundIssuesError indicates that at least one analysis message met the failure threshold.
+type AnalyzerFoundIssuesError struct{}
+
+// AnalyzerFoundIssuesExitCode is the exit code used when analysis messages met the failure threshold. It differs from
+// the generic failure exit code, so that callers can tell found issues apart from errors running the analysis itself.
+const AnalyzerFoundIssuesExitCode = 79
+
+func (AnalyzerFoundIssuesError) Error() string {
+	return fmt.Sprintf("Analyzers found issues at or above the failure threshold (%s)", failureThreshold)
+}
+
 // Analyze command
 // Once we're ready to move this functionality out of the "experimental" subtree, we should merge
 // with `istioctl validate`. https://github.com/istio/istio/issues/16777
 func Analyze() *cobra.Command {
 	analysisCmd := &cobra.Command{
-		Use:   "analyze <file|globpattern>...",
+		Use:   "analyze <file|globpattern|directory|->...",
 		Short: "Analyze Istio configuration and print validation messages",
 		Example: `
 # Analyze yaml files
 istioctl experimental analyze a.yaml b.yaml
 
+# Analyze yaml files read from the standard input
+helm template install/kubernetes/helm/istio | istioctl experimental analyze -
+
+# Analyze all yaml files in a directory tree
+istioctl experimental analyze -R manifests/
+
 # Analyze the current live cluster
 istioctl experimental analyze -k
 
 # Analyze the current live cluster, simulating the effect of applying additional yaml files
 istioctl experimental analyze -k a.yaml b.yaml
+
+# Analyze yaml files, printing the results as JSON and only failing on errors
+istioctl experimental analyze -o json --failure-threshold Error a.yaml b.yaml
+
+# Analyze yaml files with a subset of the analyzers
+istioctl experimental analyze --analyzers virtualservice.DestinationHostAnalyzer a.yaml
+
+# Analyze yaml files, suppressing IST0102 for all resources and IST0101 for virtual services in the default namespace
+istioctl experimental analyze -S IST0102 -S "IST0101=VirtualService/default/*" a.yaml
+
+# Messages can also be suppressed for an individual resource, by setting the
+# galley.istio.io/analyze-suppress annotation to a comma separated list of codes
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			threshold, err := diag.ParseLevel(failureThreshold)
+			if err != nil {
+				return err
+			}
+
 			// These scopes are pretty verbose at the default log level and significantly clutter terminal output,
 			// so we adjust them here to avoid that.
 			loggingOptions.SetOutputLevel("processing", log.ErrorLevel)
@@ -58,13 +116,29 @@ istioctl experimental analyze -k a.yaml b.yaml
 				return err
 			}
 
-			files, err := gatherFiles(args)
+			files, err := gatherFiles(args, recursive)
 			if err != nil {
 				return err
 			}
 			cancel := make(chan struct{})
 
-			sa := local.NewSourceAnalyzer(metadata.MustGet(), analyzers.All())
+			selected := analyzers.All()
+			if len(analyzerNames) > 0 {
+				if selected, err = selected.Select(analyzerNames); err != nil {
+					return err
+				}
+			}
+
+			var suppressions []local.AnalysisSuppression
+			for _, s := range suppress {
+				suppression, err := local.ParseSuppression(s)
+				if err != nil {
+					return err
+				}
+				suppressions = append(suppressions, suppression)
+			}
+
+			sa := local.NewSourceAnalyzer(metadata.MustGet(), local.NewSuppressingAnalyzer(selected, suppressions))
 
 			// If we're using kube, use that as a base source.
 			if useKube {
@@ -76,10 +150,24 @@ istioctl experimental analyze -k a.yaml b.yaml
 				sa.AddRunningKubeSource(k)
 			}
 
-			// If files are provided, treat them (collectively) as a source.
-			if len(files) > 0 {
-				err := sa.AddFileKubeSource(files)
-				if err != nil {
+			// If files are provided, treat them (collectively) as a source. The standard input, if requested, is added
+			// on top of them.
+			var paths []string
+			var readers []local.ReaderSource
+			for _, f := range files {
+				if f == stdinArg {
+					readers = append(readers, local.ReaderSource{Name: stdinName, Reader: os.Stdin})
+					continue
+				}
+				paths = append(paths, f)
+			}
+			if len(paths) > 0 {
+				if err := sa.AddFileKubeSource(paths); err != nil {
+					return err
+				}
+			}
+			if len(readers) > 0 {
+				if err := sa.AddReaderKubeSource(readers); err != nil {
 					return err
 				}
 			}
@@ -89,8 +177,14 @@ istioctl experimental analyze -k a.yaml b.yaml
 				return err
 			}
 
+			if err := printMessages(cmd, messages); err != nil {
+				return err
+			}
+
 			for _, m := range messages {
-				fmt.Printf("%v\n", m.String())
+				if m.Level.IsWorseThanOrEqualTo(threshold) {
+					return AnalyzerFoundIssuesError{}
+				}
 			}
 
 			return nil
@@ -99,18 +193,118 @@ istioctl experimental analyze -k a.yaml b.yaml
 
 	analysisCmd.PersistentFlags().BoolVarP(&useKube, "use-kube", "k", false,
 		"Use live kubernetes cluster for analysis")
+	analysisCmd.PersistentFlags().BoolVarP(&recursive, "recursive", "R", false,
+		"Process directory arguments recursively. Only files with a yaml extension are analyzed.")
+	analysisCmd.PersistentFlags().StringSliceVar(&analyzerNames, "analyzers", nil,
+		fmt.Sprintf("The analyzers to run. If not specified, all analyzers are run. Valid values: %v", analyzerNameList()))
+	analysisCmd.PersistentFlags().StringArrayVarP(&suppress, "suppress", "S", nil,
+		"Suppress messages of the given code, either for all resources (e.g. \"IST0102\") or for resources "+
+			"matching a glob pattern (e.g. \"IST0101=VirtualService/default/*\"). Can be repeated.")
+	analysisCmd.PersistentFlags().StringVarP(&msgOutputFormat, "output", "o", logOutput,
+		fmt.Sprintf("Output format: one of %v", msgOutputFormats))
+	analysisCmd.PersistentFlags().StringVar(&failureThreshold, "failure-threshold", string(diag.Warning),
+		fmt.Sprintf("The severity level of analysis at which to set a non-zero exit code. Valid values: %v",
+			diag.GetAllLevelStrings()))
 
 	return analysisCmd
 }
 
-func gatherFiles(args []string) ([]string, error) {
+func analyzerNameList() []string {
+	var names []string
+	for _, a := range analyzers.All().Analyzers() {
+		names = append(names, a.Name())
+	}
+	return names
+}
+
+func printMessages(cmd *cobra.Command, messages diag.Messages) error {
+	w := cmd.OutOrStdout()
+
+	switch msgOutputFormat {
+	case logOutput:
+		for _, m := range messages {
+			fmt.Fprintln(w, m.String())
+		}
+	case jsonOutput:
+		out, err := json.MarshalIndent(messages, "", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(out))
+	case yamlOutput:
+		out, err := yaml.Marshal(messages)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, string(out))
+	default:
+		return fmt.Errorf("output format %q not supported, must be one of %s", msgOutputFormat,
+			strings.Join(msgOutputFormats, "|"))
+	}
+	return nil
+}
+
+// gatherFiles expands the glob patterns and directories in args into the list of files to analyze. Directories
+// contribute the yaml files directly within them, or within their whole tree if recursive is set. The stdinArg is
+// passed through as-is.
+func gatherFiles(args []string, recursive bool) ([]string, error) {
 	var result []string
 	for _, a := range args {
+		if a == stdinArg {
+			result = append(result, a)
+			continue
+		}
+
 		paths, err := filepath.Glob(a)
 		if err != nil {
 			return nil, err
 		}
-		result = append(result, paths...)
+		for _, p := range paths {
+			fi, err := os.Stat(p)
+			if err != nil {
+				return nil, err
+			}
+			if !fi.IsDir() {
+				result = append(result, p)
+				continue
+			}
+
+			files, err := gatherFilesInDirectory(p, recursive)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, files...)
+		}
 	}
 	return result, nil
 }
+
+func gatherFilesInDirectory(dir string, recursive bool) ([]string, error) {
+	var result []string
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isYamlFile(path) {
+			result = append(result, path)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func isYamlFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range yamlFileExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	cases := []testCase{
+		{ // case 0
+			args: strings.Split("experimental analyze testdata/analyze/missing-gateway.yaml", " "),
+			expectedRegexp: regexp.MustCompile(
+				`Error \[IST0101\]\(VirtualService/default/productpage testdata/analyze/missing-gateway.yaml:1\)`),
+			wantException: true,
+		},
+		{ // case 1
+			args: strings.Split("experimental analyze -o json testdata/analyze/missing-gateway.yaml", " "),
+			expectedRegexp: regexp.MustCompile(
+				`"code": "IST0101",\s+"level": "Error",(.|\n)*"origin": "VirtualService/default/productpage",` +
+					`(.|\n)*"position": \{\s+"filename": "testdata/analyze/missing-gateway.yaml",`),
+			wantException: true,
+		},
+		{ // case 2
+			args:           strings.Split("experimental analyze -o yaml testdata/analyze/missing-gateway.yaml", " "),
+			expectedRegexp: regexp.MustCompile(`- code: IST0101\n\s+level: Error\n`),
+			wantException:  true,
+		},
+		{ // case 3
+			args:           strings.Split("experimental analyze -o xml testdata/analyze/missing-gateway.yaml", " "),
+			expectedRegexp: regexp.MustCompile(`Error: output format "xml" not supported`),
+			wantException:  true,
+		},
+		{ // case 4
+			args:           strings.Split("experimental analyze --failure-threshold bogus testdata/analyze/missing-gateway.yaml", " "),
+			expectedRegexp: regexp.MustCompile(`Error: unknown level "bogus"`),
+			wantException:  true,
+		},
+		{ // case 5
+			args:           strings.Split("experimental analyze -S IST0101 testdata/analyze/missing-gateway.yaml", " "),
+			expectedOutput: "",
+			wantException:  false,
+		},
+		{ // case 6
+			args: strings.Split("experimental analyze -S IST0101=VirtualService/default/* "+
+				"testdata/analyze/missing-gateway.yaml", " "),
+			expectedOutput: "",
+			wantException:  false,
+		},
+		{ // case 7
+			args: strings.Split("experimental analyze --analyzers virtualservice.DestinationHostAnalyzer "+
+				"testdata/analyze/missing-gateway.yaml", " "),
+			expectedOutput: "",
+			wantException:  false,
+		},
+		{ // case 8
+			args:           strings.Split("experimental analyze --analyzers bogus testdata/analyze/missing-gateway.yaml", " "),
+			expectedRegexp: regexp.MustCompile(`Error: unknown analyzer "bogus"`),
+			wantException:  true,
+		},
+		{ // case 9
+			args: strings.Split("experimental analyze testdata/analyze/dir", " "),
+			expectedOutput: "Error [IST0101](VirtualService/default/top testdata/analyze/dir/top.yaml:1) " +
+				"Referenced gateway not found: \"bogus-gateway\"\n" +
+				"Error: Analyzers found issues at or above the failure threshold (Warn)\n",
+			wantException: true,
+		},
+		{ // case 10
+			args: strings.Split("experimental analyze -R testdata/analyze/dir", " "),
+			expectedRegexp: regexp.MustCompile(
+				`Error \[IST0101\]\(VirtualService/default/nested testdata/analyze/dir/nested/nested.yml:1\)`),
+			wantException: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyOutput(t, c)
+		})
+	}
+}
+
+func TestAnalyzeStdin(t *testing.T) {
+	f, err := os.Open("testdata/analyze/missing-gateway.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() // nolint: errcheck
+
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	verifyOutput(t, testCase{
+		args:           strings.Split("experimental analyze -", " "),
+		expectedRegexp: regexp.MustCompile(`Error \[IST0101\]\(VirtualService/default/productpage stdin:1\)`),
+		wantException:  true,
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+func caCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ca",
+		Short: "Inspect the Citadel certificate authority",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.HelpFunc()(cmd, args)
+			if len(args) != 0 {
+				return fmt.Errorf("unknown subcommand %q", args[0])
+			}
+			return nil
+		},
+	}
+	cmd.AddCommand(rootRotationCmd())
+	return cmd
+}
+
+func rootRotationCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "root-rotation",
+		Short: "Show the progress of the Citadel root certificate rotation",
+		Long: `istioctl experimental ca root-rotation shows the phase of the self-signed root certificate rotation,
+and the root certificates trusted by Citadel, as published in the ` + ca.RootCertConfigMap + ` ConfigMap.
+The rotation is enabled by the Citadel --root-cert-rotation-threshold flag.
+`,
+		Example: `istioctl experimental ca root-rotation -i istio-system`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := interfaceFactory(kubeconfig)
+			if err != nil {
+				return err
+			}
+			configmap, err := client.CoreV1().ConfigMaps(istioNamespace).Get(ca.RootCertConfigMap, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to read ConfigMap %s/%s: %v", istioNamespace, ca.RootCertConfigMap, err)
+			}
+			data, ok := configmap.Data[ca.RootRotationStatusID]
+			if !ok {
+				return fmt.Errorf("no root rotation status in ConfigMap %s/%s", istioNamespace, ca.RootCertConfigMap)
+			}
+			status := &ca.RootRotationStatus{}
+			if err := json.Unmarshal([]byte(data), status); err != nil {
+				return fmt.Errorf("failed to parse the root rotation status: %v", err)
+			}
+			printRootRotationStatus(cmd.OutOrStdout(), status)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func printRootRotationStatus(w io.Writer, status *ca.RootRotationStatus) {
+	if status.PhaseStartTime.IsZero() {
+		fmt.Fprintf(w, "Phase: %s\n", status.Phase)
+	} else {
+		fmt.Fprintf(w, "Phase: %s (since %s)\n", status.Phase, formatRotationTime(status.PhaseStartTime))
+	}
+	fmt.Fprintf(w, "Next phase: %s (not before %s)\n", status.NextPhase, formatRotationTime(status.NextPhaseTime))
+	fmt.Fprintf(w, "Signing root: %s (expires %s)\n",
+		status.SigningRoot.SerialNumber, formatRotationTime(status.SigningRoot.NotAfter))
+	fmt.Fprintf(w, "Trusted roots:\n")
+	for _, root := range status.TrustedRoots {
+		fmt.Fprintf(w, "  %s (expires %s)\n", root.SerialNumber, formatRotationTime(root.NotAfter))
+	}
+}
+
+func formatRotationTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+func TestRootRotation(t *testing.T) {
+	rootCertConfigMap := func(status string) []runtime.Object {
+		return []runtime.Object{
+			&coreV1.ConfigMap{
+				ObjectMeta: metaV1.ObjectMeta{
+					Name:      ca.RootCertConfigMap,
+					Namespace: "istio-system",
+				},
+				Data: map[string]string{ca.RootRotationStatusID: status},
+			},
+		}
+	}
+	cases := []testcase{
+		{
+			description:       "ConfigMap does not exist",
+			args:              strings.Split("experimental ca root-rotation", " "),
+			expectedException: true,
+			expectedOutput: "Error: failed to read ConfigMap istio-system/istio-ca-root-cert: " +
+				"configmaps \"istio-ca-root-cert\" not found\n",
+		},
+		{
+			description:       "invalid status",
+			args:              strings.Split("experimental ca root-rotation", " "),
+			expectedException: true,
+			k8sConfigs:        rootCertConfigMap("{"),
+			expectedOutput:    "Error: failed to parse the root rotation status: unexpected end of JSON input\n",
+		},
+		{
+			description: "idle",
+			args:        strings.Split("experimental ca root-rotation", " "),
+			k8sConfigs: rootCertConfigMap(`{"phase":"Idle","phaseStartTime":"0001-01-01T00:00:00Z",` +
+				`"nextPhase":"TrustBundle","nextPhaseTime":"2028-10-01T00:00:00Z",` +
+				`"signingRoot":{"serialNumber":"0A1B","notAfter":"2029-10-01T00:00:00Z"},` +
+				`"trustedRoots":[{"serialNumber":"0A1B","notAfter":"2029-10-01T00:00:00Z"}]}`),
+			expectedOutput: "Phase: Idle\n" +
+				"Next phase: TrustBundle (not before 2028-10-01T00:00:00Z)\n" +
+				"Signing root: 0A1B (expires 2029-10-01T00:00:00Z)\n" +
+				"Trusted roots:\n" +
+				"  0A1B (expires 2029-10-01T00:00:00Z)\n",
+		},
+		{
+			description: "switched",
+			args:        strings.Split("experimental ca root-rotation", " "),
+			k8sConfigs: rootCertConfigMap(`{"phase":"Switched","phaseStartTime":"2028-12-30T00:00:00Z",` +
+				`"nextPhase":"Idle","nextPhaseTime":"2029-03-30T00:00:00Z",` +
+				`"signingRoot":{"serialNumber":"0C2D","notAfter":"2038-10-01T00:00:00Z"},` +
+				`"trustedRoots":[{"serialNumber":"0C2D","notAfter":"2038-10-01T00:00:00Z"},` +
+				`{"serialNumber":"0A1B","notAfter":"2029-10-01T00:00:00Z"}]}`),
+			expectedOutput: "Phase: Switched (since 2028-12-30T00:00:00Z)\n" +
+				"Next phase: Idle (not before 2029-03-30T00:00:00Z)\n" +
+				"Signing root: 0C2D (expires 2038-10-01T00:00:00Z)\n" +
+				"Trusted roots:\n" +
+				"  0C2D (expires 2038-10-01T00:00:00Z)\n" +
+				"  0A1B (expires 2029-10-01T00:00:00Z)\n",
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, c.description), func(t *testing.T) {
+			verifyAddToMeshOutput(t, c)
+		})
+	}
+}
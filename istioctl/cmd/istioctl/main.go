@@ -26,6 +26,9 @@ import (
 func main() {
 	rootCmd := cmd.GetRootCmd(os.Args[1:])
 	if err := rootCmd.Execute(); err != nil {
+		if _, ok := err.(cmd.AnalyzerFoundIssuesError); ok {
+			os.Exit(cmd.AnalyzerFoundIssuesExitCode)
+		}
 		os.Exit(1)
 	}
 }
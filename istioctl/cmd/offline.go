@@ -0,0 +1,132 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/istioctl/pkg/offline"
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/istioctl/pkg/writer/pilot"
+	istiocmd "istio.io/istio/pilot/cmd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/plugin"
+)
+
+var (
+	genFilenames []string
+	genProxyType string
+	genIP        string
+	genLabels    []string
+	genMetadata  []string
+	genPlugins   []string
+)
+
+func generateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate <pod-name[.namespace]> -f <file>...",
+		Short: "Generates the Envoy configuration Pilot would send to a proxy, from configuration files",
+		Long: `Loads Istio configuration, Kubernetes services and pods from files, and prints the listeners, clusters,
+routes and endpoints Pilot would generate for a proxy with the given name, namespace, labels and metadata,
+without connecting to a cluster. The proxy is an instance of the services selecting its labels.
+Other Kubernetes kinds in the files are ignored.`,
+		Example: `  # Show the configuration a reviews v1 pod would receive.
+  istioctl x proxy-config generate reviews-v1.default -f samples/bookinfo/platform/kube/bookinfo.yaml \
+    -f samples/bookinfo/networking/virtual-service-all-v1.yaml -l app=reviews,version=v1
+
+  # Print the full configuration an ingress gateway would receive, with a custom mesh config.
+  istioctl x proxy-config generate istio-ingressgateway.istio-system --type router \
+    -l istio=ingressgateway -f gateway.yaml --meshConfigFile mesh.yaml -o json
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("generate requires pod name")
+			}
+			if len(genFilenames) == 0 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("generate requires at least one --filename")
+			}
+			if !model.IsApplicationNodeType(model.NodeType(genProxyType)) {
+				return fmt.Errorf("invalid proxy type %q: valid types are %s and %s", genProxyType, model.SidecarProxy, model.Router)
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			podName, ns := handlers.InferPodInfo(args[0], handlers.HandleNamespace(namespace, defaultNamespace))
+
+			resources := &offline.Resources{}
+			for _, filename := range genFilenames {
+				b, err := ioutil.ReadFile(filename)
+				if err != nil {
+					return err
+				}
+				if err := resources.Parse(b, ns); err != nil {
+					return fmt.Errorf("%s: %v", filename, err)
+				}
+			}
+
+			var meshConfig *meshconfig.MeshConfig
+			if meshConfigFile != "" {
+				var err error
+				if meshConfig, err = istiocmd.ReadMeshConfig(meshConfigFile); err != nil {
+					return err
+				}
+			}
+
+			config, err := offline.Generate(resources, meshConfig, genPlugins, &offline.Proxy{
+				Type:      model.NodeType(genProxyType),
+				Name:      podName,
+				Namespace: ns,
+				IP:        genIP,
+				Labels:    convertToMap(genLabels),
+				Metadata:  convertToMap(genMetadata),
+			})
+			if err != nil {
+				return err
+			}
+
+			ow := pilot.OfflineConfigWriter{Writer: c.OutOrStdout()}
+			switch outputFormat {
+			case summaryOutput:
+				return ow.PrintSummary(config)
+			case jsonOutput:
+				return ow.PrintJSON(config)
+			default:
+				return fmt.Errorf("output format %q not supported", outputFormat)
+			}
+		},
+	}
+
+	cmd.PersistentFlags().StringSliceVarP(&genFilenames, "filename", "f", nil,
+		"Files with the Istio configuration, services and pods to load (can be repeated)")
+	cmd.PersistentFlags().StringVar(&genProxyType, "type", string(model.SidecarProxy), "Type of the proxy: sidecar or router")
+	cmd.PersistentFlags().StringVar(&genIP, "ip", "10.0.0.1", "IP address of the proxy")
+	cmd.PersistentFlags().StringSliceVarP(&genLabels, "labels", "l", nil,
+		"Labels of the proxy workload; e.g. -l app=reviews,version=v1")
+	cmd.PersistentFlags().StringSliceVar(&genMetadata, "meta", nil,
+		"Node metadata sent by the proxy, without the ISTIO_META_ prefix; e.g. --meta HTTP10=1")
+	cmd.PersistentFlags().StringVar(&meshConfigFile, "meshConfigFile", "",
+		"Mesh configuration filename; the default mesh configuration is used if not set")
+	cmd.PersistentFlags().StringSliceVar(&genPlugins, "plugins",
+		[]string{plugin.Authn, plugin.Authz, plugin.Health, plugin.Mixer}, "Pilot plugins used to generate the configuration")
+
+	return cmd
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func TestProxyConfigGenerate(t *testing.T) {
+	cases := []testCase{
+		{ // case 0
+			configs:        []model.Config{},
+			args:           strings.Split("x proxy-config generate -f testdata/offline/reviews.yaml", " "),
+			expectedRegexp: regexp.MustCompile("Error: generate requires pod name\n"),
+			wantException:  true,
+		},
+		{ // case 1
+			configs:        []model.Config{},
+			args:           strings.Split("x proxy-config generate productpage-v1.default", " "),
+			expectedRegexp: regexp.MustCompile("Error: generate requires at least one --filename\n"),
+			wantException:  true,
+		},
+		{ // case 2
+			configs:        []model.Config{},
+			args:           strings.Split("x pc generate productpage-v1.default -f testdata/offline/reviews.yaml --type client", " "),
+			expectedRegexp: regexp.MustCompile(`Error: invalid proxy type "client"`),
+			wantException:  true,
+		},
+		{ // case 3
+			configs: []model.Config{},
+			args:    strings.Split("x pc generate productpage-v1.default -f testdata/offline/reviews.yaml -l app=productpage", " "),
+			expectedRegexp: regexp.MustCompile(`(?s)CDS\s+outbound\|9080\|v2\|reviews.default.svc.cluster.local\s+EDS.*` +
+				`RDS\s+9080\s+.*` +
+				`EDS\s+outbound\|9080\|v2\|reviews.default.svc.cluster.local\s+1 endpoints`),
+		},
+		{ // case 4
+			configs: []model.Config{},
+			args: strings.Split("x pc generate reviews-v1.default -f testdata/offline/reviews.yaml -l app=reviews,version=v1 "+
+				"--ip 10.0.0.3", " "),
+			expectedRegexp: regexp.MustCompile(`(?s)LDS\s+10.0.0.3_9080\s+10.0.0.3:9080.*` +
+				`EDS\s+outbound\|9080\|\|reviews.default.svc.cluster.local\s+2 endpoints`),
+		},
+		{ // case 5
+			configs:        []model.Config{},
+			args:           strings.Split("x pc generate productpage-v1.default -f testdata/offline/reviews.yaml -o json", " "),
+			expectedRegexp: regexp.MustCompile(`"clusterName": "outbound\|9080\|v2\|reviews.default.svc.cluster.local"`),
+		},
+		{ // case 6
+			configs:        []model.Config{},
+			args:           strings.Split("x pc generate productpage-v1.default -f testdata/offline/missing.yaml", " "),
+			expectedRegexp: regexp.MustCompile("Error: open testdata/offline/missing.yaml: no such file or directory"),
+			wantException:  true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyOutput(t, c)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"istio.io/istio/istioctl/pkg/util/handlers"
+	"istio.io/istio/istioctl/pkg/writer/pilot"
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+)
+
+var (
+	simAuthority, simPath, simMethod string
+	simHeaders                       []string
+)
+
+// experimentalProxyConfig holds the proxy-config commands that are not stable yet.
+func experimentalProxyConfig() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:     "proxy-config",
+		Short:   "Experimental commands to inspect the proxy configuration generated by Pilot",
+		Aliases: []string{"pc"},
+	}
+
+	configCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", summaryOutput, "Output format: one of json|short")
+	configCmd.AddCommand(simulateCmd())
+	configCmd.AddCommand(generateCmd())
+
+	return configCmd
+}
+
+func simulateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate <pod-name[.namespace]>",
+		Short: "Shows which route and clusters a request would hit on the Envoy in the specified pod",
+		Long: `Runs an HTTP request through the route configuration Pilot generates for the specified pod and reports
+the virtual host, route, originating virtual service and weighted clusters it matches, following the Envoy
+route matching order.`,
+		Example: `  # Show where a request from productpage to reviews on port 9080 is routed.
+  istioctl x proxy-config simulate productpage-v1-7bb5c7d6f9-2xk9j.default --port 9080 --authority reviews:9080
+
+  # Simulate a request with a path and headers, and print the matched Envoy route.
+  istioctl x proxy-config simulate productpage-v1-7bb5c7d6f9-2xk9j.default --port 9080 --authority reviews:9080 \
+    --path /reviews/0 --header end-user:jason -o json
+
+  # Simulate a request hitting the ingress gateway on a specific route configuration.
+  istioctl x proxy-config simulate istio-ingressgateway-6d7b4bbc5d-9f8gx.istio-system --port 80 \
+    --authority bookinfo.example.com --route http.80
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("simulate requires pod name")
+			}
+			if port == 0 || simAuthority == "" {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("simulate requires --port and --authority")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			kubeClient, err := clientExecFactory(kubeconfig, configContext)
+			if err != nil {
+				return err
+			}
+			podName, ns := handlers.InferPodInfo(args[0], handlers.HandleNamespace(namespace, defaultNamespace))
+			query := url.Values{
+				"proxyID":   []string{fmt.Sprintf("%s.%s", podName, ns)},
+				"port":      []string{strconv.Itoa(port)},
+				"authority": []string{simAuthority},
+				"path":      []string{simPath},
+				"method":    []string{simMethod},
+				"header":    simHeaders,
+			}
+			if routeName != "" {
+				query.Set("route", routeName)
+			}
+			results, err := kubeClient.AllPilotsDiscoveryDo(istioNamespace, "GET", "/debug/route_simulate?"+query.Encode(), nil)
+			if err != nil {
+				return err
+			}
+
+			// Only the Pilot the proxy is connected to is able to simulate the request.
+			var sim *v2.RouteSimulation
+			for _, result := range results {
+				candidate := &v2.RouteSimulation{}
+				if err := json.Unmarshal(result, candidate); err == nil && candidate.ProxyID != "" {
+					sim = candidate
+					break
+				}
+			}
+			if sim == nil {
+				return fmt.Errorf("checked %d pilot instances and found no route simulation for %s.%s, check proxy status",
+					len(results), podName, ns)
+			}
+
+			rsw := pilot.RouteSimulationWriter{Writer: c.OutOrStdout()}
+			switch outputFormat {
+			case summaryOutput:
+				return rsw.PrintSummary(*sim)
+			case jsonOutput:
+				return rsw.PrintJSON(*sim)
+			default:
+				return fmt.Errorf("output format %q not supported", outputFormat)
+			}
+		},
+	}
+
+	cmd.PersistentFlags().IntVar(&port, "port", 0, "Port the request is sent to")
+	cmd.PersistentFlags().StringVar(&simAuthority, "authority", "", "Authority (Host header) of the request")
+	cmd.PersistentFlags().StringVar(&simPath, "path", "/", "Path of the request, including the query string")
+	cmd.PersistentFlags().StringVar(&simMethod, "method", "GET", "Method of the request")
+	cmd.PersistentFlags().StringArrayVar(&simHeaders, "header", nil, "Header of the request, as name:value (can be repeated)")
+	cmd.PersistentFlags().StringVar(&routeName, "route", "", "Name of the route configuration to use instead of inferring it from the port")
+
+	return cmd
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"istio.io/istio/istioctl/pkg/kubernetes"
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func TestProxyConfigSimulate(t *testing.T) {
+	clientExecFactory = mockExecClientSimulate
+
+	cases := []testCase{
+		{ // case 0
+			configs:        []model.Config{},
+			args:           strings.Split("x proxy-config simulate", " "),
+			expectedRegexp: regexp.MustCompile("Error: simulate requires pod name\n"),
+			wantException:  true,
+		},
+		{ // case 1
+			configs:        []model.Config{},
+			args:           strings.Split("x proxy-config simulate productpage-123456-7890", " "),
+			expectedRegexp: regexp.MustCompile("Error: simulate requires --port and --authority\n"),
+			wantException:  true,
+		},
+		{ // case 2
+			configs: []model.Config{},
+			args:    strings.Split("x pc simulate productpage-123456-7890 --port 9080 --authority reviews:9080", " "),
+			expectedRegexp: regexp.MustCompile(
+				`9080\s+reviews.default.svc.cluster.local:9080\s+default\s+reviews.default\s+outbound\|9080\|v1\|reviews.default.svc.cluster.local\s+80`),
+		},
+		{ // case 3
+			configs:        []model.Config{},
+			args:           strings.Split("x pc simulate productpage-123456-7890 --port 9080 --authority reviews:9080 -o json", " "),
+			expectedRegexp: regexp.MustCompile(`"virtual_service": "reviews.default"`),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			verifyOutput(t, c)
+		})
+	}
+}
+
+func TestProxyConfigSimulateNotConnected(t *testing.T) {
+	clientExecFactory = mockExecClientSimulateNotConnected
+
+	c := testCase{
+		configs:        []model.Config{},
+		args:           strings.Split("x pc simulate productpage-123456-7890 --port 9080 --authority reviews:9080", " "),
+		expectedRegexp: regexp.MustCompile("Error: checked 1 pilot instances and found no route simulation"),
+		wantException:  true,
+	}
+	verifyOutput(t, c)
+}
+
+func mockExecClientSimulate(_, _ string) (kubernetes.ExecClient, error) {
+	return &mockExecConfig{
+		results: map[string][]byte{
+			"istio-pilot-123456-7890": []byte(`{
+  "proxy": "productpage-123456-7890.default",
+  "request": {"authority": "reviews:9080", "path": "/", "method": "GET", "port": 9080},
+  "route_config": "9080",
+  "virtual_host": "reviews.default.svc.cluster.local:9080",
+  "route_name": "default",
+  "virtual_service": "reviews.default",
+  "clusters": [
+    {"name": "outbound|9080|v1|reviews.default.svc.cluster.local", "weight": 80},
+    {"name": "outbound|9080|v3|reviews.default.svc.cluster.local", "weight": 20}
+  ],
+  "route": {"name": "default"}
+}`),
+		},
+	}, nil
+}
+
+func mockExecClientSimulateNotConnected(_, _ string) (kubernetes.ExecClient, error) {
+	return &mockExecConfig{
+		results: map[string][]byte{
+			"istio-pilot-123456-7890": []byte(`Proxy not connected to this Pilot instance`),
+		},
+	}, nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package offline generates the configuration Pilot would send to a proxy from Istio and Kubernetes
+// resources read from files, without connecting to a cluster.
+package offline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/gogo/protobuf/types"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/pkg/log"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	networking "istio.io/istio/pilot/pkg/networking/core"
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	"istio.io/istio/pilot/pkg/serviceregistry/aggregate"
+	"istio.io/istio/pilot/pkg/serviceregistry/external"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+const (
+	// DomainSuffix is the Kubernetes domain suffix used for the services read from files.
+	DomainSuffix = "cluster.local"
+
+	// maximum size of a single YAML document
+	documentBufferSize = 512 * 1024
+
+	// syntheticClusterIPPrefix is the prefix of the cluster IPs given to the services read from files, in the
+	// default service CIDR of Kubernetes.
+	syntheticClusterIPPrefix = "10.96"
+)
+
+// Resources are the Istio configs, Kubernetes services and pods the proxy configuration is generated from.
+type Resources struct {
+	Configs  []model.Config
+	Services []*v1.Service
+	Pods     []*v1.Pod
+}
+
+// Parse reads a stream of YAML or JSON documents and appends the resources it contains. Resources without a
+// namespace are placed in the given namespace; kinds other than Istio configs, services and pods are ignored.
+func (r *Resources) Parse(input []byte, namespace string) error {
+	decoder := kubeyaml.NewYAMLOrJSONDecoder(bytes.NewReader(input), documentBufferSize)
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot parse document: %v", err)
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		meta := metav1.TypeMeta{}
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return fmt.Errorf("cannot parse document: %v", err)
+		}
+		switch meta.Kind {
+		case "Service":
+			svc := &v1.Service{}
+			if err := json.Unmarshal(raw, svc); err != nil {
+				return fmt.Errorf("cannot parse service: %v", err)
+			}
+			if svc.Namespace == "" {
+				svc.Namespace = namespace
+			}
+			setServiceDefaults(svc, len(r.Services))
+			r.Services = append(r.Services, svc)
+		case "Pod":
+			pod := &v1.Pod{}
+			if err := json.Unmarshal(raw, pod); err != nil {
+				return fmt.Errorf("cannot parse pod: %v", err)
+			}
+			if pod.Namespace == "" {
+				pod.Namespace = namespace
+			}
+			r.Pods = append(r.Pods, pod)
+		default:
+			configs, others, err := crd.ParseInputs(string(raw))
+			if err != nil {
+				return err
+			}
+			for _, o := range others {
+				log.Debugf("ignoring unsupported kind %s", o.Kind)
+			}
+			for _, c := range configs {
+				if c.Namespace == "" {
+					c.Namespace = namespace
+				}
+				// Short hosts are resolved in the Kubernetes domain, as the configs read from the cluster.
+				if c.Domain == "" {
+					c.Domain = DomainSuffix
+				}
+				r.Configs = append(r.Configs, c)
+			}
+		}
+	}
+}
+
+// setServiceDefaults applies the defaults the Kubernetes API server sets on creation, which the manifests
+// usually omit: the protocol and target port of the ports, and a cluster IP for services which are neither
+// headless nor external names. The index of the service makes its cluster IP unique.
+func setServiceDefaults(svc *v1.Service, index int) {
+	for i := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[i]
+		if port.Protocol == "" {
+			port.Protocol = v1.ProtocolTCP
+		}
+		if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal == 0 {
+			port.TargetPort = intstr.FromInt(int(port.Port))
+		}
+	}
+	if svc.Spec.Type == "" {
+		svc.Spec.Type = v1.ServiceTypeClusterIP
+	}
+	if svc.Spec.ClusterIP == "" && svc.Spec.Type != v1.ServiceTypeExternalName {
+		svc.Spec.ClusterIP = fmt.Sprintf("%s.%d.%d", syntheticClusterIPPrefix, (index+1)/256, (index+1)%256)
+	}
+}
+
+// Proxy describes the hypothetical proxy the configuration is generated for.
+type Proxy struct {
+	Type      model.NodeType
+	Name      string
+	Namespace string
+	IP        string
+	Labels    map[string]string
+	// Metadata is sent by the proxy in the node metadata, without the ISTIO_META_ prefix.
+	Metadata map[string]string
+}
+
+// Generate loads the resources into in-memory config and service registries and returns the LDS, CDS, RDS and
+// EDS Pilot generates with the given plugins for the proxy. The proxy is registered as a pod, so it becomes an
+// instance of the services selecting its labels.
+func Generate(r *Resources, meshConfig *meshconfig.MeshConfig, plugins []string, proxy *Proxy) (*v2.OfflineConfig, error) {
+	if meshConfig == nil {
+		m := mesh.DefaultMeshConfig()
+		meshConfig = &m
+	}
+
+	store := memory.Make(schemas.Istio)
+	for _, c := range r.Configs {
+		if _, err := store.Create(c); err != nil {
+			return nil, fmt.Errorf("cannot add %s %s/%s: %v", c.Type, c.Namespace, c.Name, err)
+		}
+	}
+	configController := memory.NewController(store)
+	istioConfigStore := model.MakeIstioStore(configController)
+
+	sd := v2.NewMemServiceDiscovery(map[host.Name]*model.Service{}, 0)
+	sd.WantGetProxyServiceInstances = addKubernetesResources(sd, r, proxy)
+
+	serviceEntryStore := external.NewServiceDiscovery(configController, istioConfigStore)
+	serviceControllers := aggregate.NewController()
+	serviceControllers.AddRegistry(aggregate.Registry{
+		Name:             serviceregistry.MockRegistry,
+		ServiceDiscovery: sd,
+		Controller:       &v2.MemServiceController{},
+	})
+	serviceControllers.AddRegistry(aggregate.Registry{
+		Name:             "ServiceEntries",
+		ServiceDiscovery: serviceEntryStore,
+		Controller:       serviceEntryStore,
+	})
+
+	env := &model.Environment{
+		Mesh:             meshConfig,
+		MeshNetworks:     &meshconfig.MeshNetworks{},
+		IstioConfigStore: istioConfigStore,
+		ServiceDiscovery: serviceControllers,
+		PushContext:      model.NewPushContext(),
+	}
+	server := v2.NewDiscoveryServer(env, networking.NewConfigGenerator(plugins), serviceControllers, nil, configController)
+	if server == nil {
+		return nil, fmt.Errorf("cannot create the discovery server")
+	}
+
+	node, err := proxyNode(proxy)
+	if err != nil {
+		return nil, err
+	}
+	return server.GenerateOfflineConfig(node)
+}
+
+// addKubernetesResources adds the services and the instances of the pods selected by them to the registry, the
+// same way the Kubernetes registry converts them, and returns the instances of the proxy.
+func addKubernetesResources(sd *v2.MemServiceDiscovery, r *Resources, proxy *Proxy) []*model.ServiceInstance {
+	pods := append([]*v1.Pod{}, r.Pods...)
+	if proxy.IP != "" {
+		pods = append(pods, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: proxy.Name, Namespace: proxy.Namespace, Labels: proxy.Labels},
+			Status:     v1.PodStatus{PodIP: proxy.IP},
+		})
+	}
+
+	proxyInstances := make([]*model.ServiceInstance, 0)
+	for _, svc := range r.Services {
+		istioService := kube.ConvertService(*svc, DomainSuffix, "")
+		sd.AddService(istioService.Hostname, istioService)
+		if len(svc.Spec.Selector) == 0 {
+			continue
+		}
+
+		for _, pod := range pods {
+			if pod.Namespace != svc.Namespace || pod.Status.PodIP == "" ||
+				!labels.Instance(svc.Spec.Selector).SubsetOf(pod.Labels) {
+				continue
+			}
+			for i := range svc.Spec.Ports {
+				port := &svc.Spec.Ports[i]
+				servicePort, _ := istioService.Ports.GetByPort(int(port.Port))
+				// Pods declared in files may omit their containers; send the traffic of named target ports to the
+				// service port then.
+				targetPort, err := controller.FindPort(pod, port)
+				if err != nil {
+					targetPort = int(port.Port)
+				}
+				instance := &model.ServiceInstance{
+					Endpoint: model.NetworkEndpoint{
+						Address:     pod.Status.PodIP,
+						Port:        targetPort,
+						ServicePort: servicePort,
+					},
+					Labels:         pod.Labels,
+					ServiceAccount: kube.SecureNamingSAN(pod),
+				}
+				sd.AddInstance(istioService.Hostname, instance)
+				if pod.Status.PodIP == proxy.IP {
+					proxyInstances = append(proxyInstances, instance)
+				}
+			}
+		}
+	}
+
+	for _, pod := range pods {
+		if pod.Status.PodIP != "" {
+			sd.AddWorkload(pod.Status.PodIP, pod.Labels)
+		}
+	}
+	return proxyInstances
+}
+
+// proxyNode returns the Envoy node the proxy would send in its first discovery request.
+func proxyNode(proxy *Proxy) (*core.Node, error) {
+	fields := map[string]*types.Value{}
+	for k, v := range proxy.Metadata {
+		fields[k] = &types.Value{Kind: &types.Value_StringValue{StringValue: v}}
+	}
+	if len(proxy.Labels) > 0 {
+		l, err := json.Marshal(proxy.Labels)
+		if err != nil {
+			return nil, err
+		}
+		fields[model.NodeMetadataLabels] = &types.Value{Kind: &types.Value_StringValue{StringValue: string(l)}}
+	}
+	if _, f := fields[model.NodeMetadataConfigNamespace]; !f {
+		fields[model.NodeMetadataConfigNamespace] = &types.Value{Kind: &types.Value_StringValue{StringValue: proxy.Namespace}}
+	}
+
+	return &core.Node{
+		Id: fmt.Sprintf("%s~%s~%s.%s~%s.svc.%s", proxy.Type, proxy.IP, proxy.Name, proxy.Namespace,
+			proxy.Namespace, DomainSuffix),
+		Metadata: &types.Struct{Fields: fields},
+	}, nil
+}
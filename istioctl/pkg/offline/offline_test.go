@@ -0,0 +1,173 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+const resources = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: ratings
+  namespace: bookinfo
+spec:
+  ports:
+  - port: 9080
+    name: http
+  selector:
+    app: ratings
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ratings-v1
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: ratings-v1-f745cf57b-vkbf2
+  labels:
+    app: ratings
+status:
+  podIP: 10.0.0.2
+---
+apiVersion: networking.istio.io/v1alpha3
+kind: DestinationRule
+metadata:
+  name: ratings
+spec:
+  host: ratings
+  trafficPolicy:
+    tls:
+      mode: ISTIO_MUTUAL
+`
+
+func TestParse(t *testing.T) {
+	r := &Resources{}
+	if err := r.Parse([]byte(resources), "default"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Services) != 1 || r.Services[0].Name != "ratings" || r.Services[0].Namespace != "bookinfo" {
+		t.Errorf("Parse() services => got %v, want ratings.bookinfo", r.Services)
+	}
+	if len(r.Pods) != 1 || r.Pods[0].Namespace != "default" || r.Pods[0].Status.PodIP != "10.0.0.2" {
+		t.Errorf("Parse() pods => got %v, want ratings-v1-f745cf57b-vkbf2.default", r.Pods)
+	}
+	if len(r.Configs) != 1 || r.Configs[0].Type != "destination-rule" || r.Configs[0].Namespace != "default" {
+		t.Errorf("Parse() configs => got %v, want destination rule ratings.default", r.Configs)
+	}
+
+	if r.Configs[0].Domain != DomainSuffix {
+		t.Errorf("Parse() config domain => got %q, want %q", r.Configs[0].Domain, DomainSuffix)
+	}
+
+	if err := r.Parse([]byte("kind: VirtualService\napiVersion: networking.istio.io/v1alpha3\nmetadata:\n  name: bad\n"),
+		"default"); err == nil {
+		t.Errorf("Parse() expected an error for an invalid virtual service")
+	}
+}
+
+func TestSetServiceDefaults(t *testing.T) {
+	cases := []struct {
+		name       string
+		spec       v1.ServiceSpec
+		clusterIP  string
+		targetPort intstr.IntOrString
+	}{
+		{
+			name:       "cluster IP",
+			spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 9080}}},
+			clusterIP:  "10.96.0.1",
+			targetPort: intstr.FromInt(9080),
+		},
+		{
+			name:       "named target port",
+			spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 9080, TargetPort: intstr.FromString("http")}}},
+			clusterIP:  "10.96.0.1",
+			targetPort: intstr.FromString("http"),
+		},
+		{
+			name: "headless",
+			spec: v1.ServiceSpec{ClusterIP: v1.ClusterIPNone,
+				Ports: []v1.ServicePort{{Port: 9080, TargetPort: intstr.FromInt(8080)}}},
+			clusterIP:  v1.ClusterIPNone,
+			targetPort: intstr.FromInt(8080),
+		},
+		{
+			name: "external name",
+			spec: v1.ServiceSpec{Type: v1.ServiceTypeExternalName, ExternalName: "example.com",
+				Ports: []v1.ServicePort{{Port: 80}}},
+			clusterIP:  "",
+			targetPort: intstr.FromInt(80),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			svc := &v1.Service{Spec: c.spec}
+			setServiceDefaults(svc, 0)
+			if svc.Spec.ClusterIP != c.clusterIP {
+				t.Errorf("setServiceDefaults() cluster IP => got %q, want %q", svc.Spec.ClusterIP, c.clusterIP)
+			}
+			port := svc.Spec.Ports[0]
+			if port.TargetPort != c.targetPort || port.Protocol != v1.ProtocolTCP {
+				t.Errorf("setServiceDefaults() port => got %v, want target port %v and protocol TCP", port, c.targetPort.String())
+			}
+		})
+	}
+
+	// Each service gets its own cluster IP.
+	svc := &v1.Service{}
+	setServiceDefaults(svc, 300)
+	if svc.Spec.ClusterIP != "10.96.1.45" {
+		t.Errorf("setServiceDefaults() cluster IP => got %q, want 10.96.1.45", svc.Spec.ClusterIP)
+	}
+}
+
+func TestProxyNode(t *testing.T) {
+	node, err := proxyNode(&Proxy{
+		Type:      model.SidecarProxy,
+		Name:      "reviews-v1",
+		Namespace: "bookinfo",
+		IP:        "10.0.0.1",
+		Labels:    map[string]string{"app": "reviews"},
+		Metadata:  map[string]string{model.NodeMetadataHTTP10: "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy, err := model.ParseServiceNodeWithMetadata(node.Id, model.ParseMetadata(node.Metadata))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxy.ID != "reviews-v1.bookinfo" || proxy.IPAddresses[0] != "10.0.0.1" || proxy.Type != model.SidecarProxy {
+		t.Errorf("proxyNode() => got proxy %+v", proxy)
+	}
+	if model.GetProxyConfigNamespace(proxy) != "bookinfo" {
+		t.Errorf("proxyNode() => got config namespace %s, want bookinfo", model.GetProxyConfigNamespace(proxy))
+	}
+	if len(proxy.WorkloadLabels) != 1 || proxy.WorkloadLabels[0]["app"] != "reviews" {
+		t.Errorf("proxyNode() => got labels %v, want app=reviews", proxy.WorkloadLabels)
+	}
+	if proxy.Metadata[model.NodeMetadataHTTP10] != "1" {
+		t.Errorf("proxyNode() => got metadata %v, want %s=1", proxy.Metadata, model.NodeMetadataHTTP10)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+)
+
+// OfflineConfigWriter enables printing of the configuration generated for a proxy without a cluster
+type OfflineConfigWriter struct {
+	Writer io.Writer
+}
+
+type offlineConfigJSON struct {
+	Listeners []json.RawMessage `json:"listeners"`
+	Clusters  []json.RawMessage `json:"clusters"`
+	Routes    []json.RawMessage `json:"routes"`
+	Endpoints []json.RawMessage `json:"endpoints"`
+}
+
+// PrintSummary outputs one line per generated listener, cluster, route configuration and cluster load assignment
+func (o *OfflineConfigWriter) PrintSummary(config *v2.OfflineConfig) error {
+	w := new(tabwriter.Writer).Init(o.Writer, 0, 8, 5, ' ', 0)
+	fmt.Fprintln(w, "XDS\tNAME\tDETAILS")
+	for _, l := range config.Listeners {
+		address := l.Address.GetSocketAddress()
+		fmt.Fprintf(w, "LDS\t%s\t%s:%d\n", l.Name, address.GetAddress(), address.GetPortValue())
+	}
+	for _, c := range config.Clusters {
+		fmt.Fprintf(w, "CDS\t%s\t%s\n", c.Name, c.GetType())
+	}
+	for _, r := range config.Routes {
+		fmt.Fprintf(w, "RDS\t%s\t%d virtual hosts\n", r.Name, len(r.VirtualHosts))
+	}
+	for _, e := range config.Endpoints {
+		endpoints := 0
+		for _, locality := range e.Endpoints {
+			endpoints += len(locality.LbEndpoints)
+		}
+		fmt.Fprintf(w, "EDS\t%s\t%d endpoints\n", e.ClusterName, endpoints)
+	}
+	return w.Flush()
+}
+
+// PrintJSON outputs the complete generated configuration
+func (o *OfflineConfigWriter) PrintJSON(config *v2.OfflineConfig) error {
+	out := offlineConfigJSON{
+		Listeners: []json.RawMessage{},
+		Clusters:  []json.RawMessage{},
+		Routes:    []json.RawMessage{},
+		Endpoints: []json.RawMessage{},
+	}
+	var err error
+	for _, l := range config.Listeners {
+		if out.Listeners, err = appendJSON(out.Listeners, l); err != nil {
+			return err
+		}
+	}
+	for _, c := range config.Clusters {
+		if out.Clusters, err = appendJSON(out.Clusters, c); err != nil {
+			return err
+		}
+	}
+	for _, r := range config.Routes {
+		if out.Routes, err = appendJSON(out.Routes, r); err != nil {
+			return err
+		}
+	}
+	for _, e := range config.Endpoints {
+		if out.Endpoints, err = appendJSON(out.Endpoints, e); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(o.Writer, string(b))
+	return nil
+}
+
+func appendJSON(out []json.RawMessage, msg proto.Message) ([]json.RawMessage, error) {
+	jsonm := &jsonpb.Marshaler{}
+	s, err := jsonm.MarshalToString(msg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal %T: %v", msg, err)
+	}
+	return append(out, json.RawMessage(s)), nil
+}
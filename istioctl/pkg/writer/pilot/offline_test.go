@@ -0,0 +1,84 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/stretchr/testify/assert"
+
+	networking "istio.io/istio/pilot/pkg/networking/util"
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/tests/util"
+)
+
+const reviewsCluster = "outbound|9080||reviews.default.svc.cluster.local"
+
+func offlineConfig() *v2.OfflineConfig {
+	return &v2.OfflineConfig{
+		Listeners: []*xdsapi.Listener{{Name: "0.0.0.0_9080", Address: networking.BuildAddress("0.0.0.0", 9080)}},
+		Clusters: []*xdsapi.Cluster{{
+			Name:                 reviewsCluster,
+			ClusterDiscoveryType: &xdsapi.Cluster_Type{Type: xdsapi.Cluster_EDS},
+		}},
+		Routes: []*xdsapi.RouteConfiguration{{
+			Name:         "9080",
+			VirtualHosts: []*route.VirtualHost{{Name: "reviews.default.svc.cluster.local:9080"}, {Name: "allow_any"}},
+		}},
+		Endpoints: []*xdsapi.ClusterLoadAssignment{{
+			ClusterName: reviewsCluster,
+			Endpoints: []*endpoint.LocalityLbEndpoints{{
+				LbEndpoints: []*endpoint.LbEndpoint{{}, {}},
+			}},
+		}},
+	}
+}
+
+func TestOfflineConfigWriter_PrintSummary(t *testing.T) {
+	got := &bytes.Buffer{}
+	ow := OfflineConfigWriter{Writer: got}
+	assert.NoError(t, ow.PrintSummary(offlineConfig()))
+	want, _ := ioutil.ReadFile("testdata/offlineConfig.txt")
+	if err := util.Compare(got.Bytes(), want); err != nil {
+		t.Errorf(err.Error())
+	}
+}
+
+func TestOfflineConfigWriter_PrintJSON(t *testing.T) {
+	got := &bytes.Buffer{}
+	ow := OfflineConfigWriter{Writer: got}
+	assert.NoError(t, ow.PrintJSON(offlineConfig()))
+
+	out := map[string][]map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(got.Bytes(), &out))
+	for xds, name := range map[string]string{
+		"listeners": "0.0.0.0_9080",
+		"clusters":  reviewsCluster,
+		"routes":    "9080",
+	} {
+		if len(out[xds]) != 1 || out[xds][0]["name"] != name {
+			t.Errorf("PrintJSON() %s => got %v, want %s", xds, out[xds], name)
+		}
+	}
+	if len(out["endpoints"]) != 1 || out["endpoints"][0]["clusterName"] != reviewsCluster {
+		t.Errorf("PrintJSON() endpoints => got %v, want %s", out["endpoints"], reviewsCluster)
+	}
+}
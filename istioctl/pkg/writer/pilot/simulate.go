@@ -0,0 +1,71 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+)
+
+// RouteSimulationWriter enables printing of a Pilot route simulation
+type RouteSimulationWriter struct {
+	Writer io.Writer
+}
+
+// PrintSummary outputs the route a simulated request matched, with one line per destination cluster
+func (r *RouteSimulationWriter) PrintSummary(sim v2.RouteSimulation) error {
+	w := new(tabwriter.Writer).Init(r.Writer, 0, 8, 5, ' ', 0)
+	fmt.Fprintln(w, "ROUTE CONFIG\tVIRTUAL HOST\tROUTE\tVIRTUAL SERVICE\tDESTINATION\tWEIGHT")
+	prefix := fmt.Sprintf("%s\t%s\t%s\t%s", orNone(sim.RouteConfig), orNone(sim.VirtualHost),
+		orNone(sim.RouteName), orNone(sim.VirtualService))
+	switch {
+	case sim.VirtualHost == "":
+		fmt.Fprintf(w, "%s\tno matching virtual host\t-\n", prefix)
+	case len(sim.Route) == 0:
+		fmt.Fprintf(w, "%s\tno matching route\t-\n", prefix)
+	case len(sim.Clusters) > 0:
+		for _, c := range sim.Clusters {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", prefix, c.Name, c.Weight)
+		}
+	case sim.Redirect != "":
+		fmt.Fprintf(w, "%s\tredirect to %s\t-\n", prefix, sim.Redirect)
+	case sim.DirectResponse != 0:
+		fmt.Fprintf(w, "%s\tdirect response %d\t-\n", prefix, sim.DirectResponse)
+	default:
+		fmt.Fprintf(w, "%s\t-\t-\n", prefix)
+	}
+	return w.Flush()
+}
+
+// PrintJSON outputs the full route simulation, including the matched route
+func (r *RouteSimulationWriter) PrintJSON(sim v2.RouteSimulation) error {
+	out, err := json.MarshalIndent(sim, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(r.Writer, string(out))
+	return nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
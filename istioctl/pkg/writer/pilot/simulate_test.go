@@ -0,0 +1,71 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pilot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/tests/util"
+)
+
+func TestRouteSimulationWriter_PrintSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		input v2.RouteSimulation
+		want  string
+	}{
+		{
+			name: "prints weighted clusters of the matched route",
+			input: v2.RouteSimulation{
+				ProxyID:        "productpage-v1-7bb5c7d6f9-2xk9j.default",
+				RouteConfig:    "9080",
+				VirtualHost:    "reviews.default.svc.cluster.local:9080",
+				RouteName:      "default",
+				VirtualService: "reviews.default",
+				Clusters: []v2.SimulatedCluster{
+					{Name: "outbound|9080|v1|reviews.default.svc.cluster.local", Weight: 80},
+					{Name: "outbound|9080|v3|reviews.default.svc.cluster.local", Weight: 20},
+				},
+				Route: json.RawMessage(`{"name":"default"}`),
+			},
+			want: "testdata/routeSimulation.txt",
+		},
+		{
+			name: "prints requests without a virtual host",
+			input: v2.RouteSimulation{
+				ProxyID:     "istio-ingressgateway-6d7b4bbc5d-9f8gx.istio-system",
+				RouteConfig: "http.80",
+			},
+			want: "testdata/routeSimulationNoMatch.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &bytes.Buffer{}
+			rsw := RouteSimulationWriter{Writer: got}
+			assert.NoError(t, rsw.PrintSummary(tt.input))
+			want, _ := ioutil.ReadFile(tt.want)
+			if err := util.Compare(got.Bytes(), want); err != nil {
+				t.Errorf(err.Error())
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@
 package bootstrap
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -86,6 +87,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
@@ -108,11 +110,22 @@ const (
 	// URL types supported by the config store
 	// example fs:///tmp/configroot
 	fsScheme = "fs"
+
+	defaultFileResyncInterval = time.Minute
 )
 
 var (
+	// FileWatchDebounce dictates how long config file changes are batched before the file system is walked for config
+	FileWatchDebounce = 100 * time.Millisecond
+
+	// FileResyncInterval dictates how often the file system is walked for config, in case a change was not
+	// reported by the file watcher
+	FileResyncInterval = defaultFileResyncInterval
+
 	// FilepathWalkInterval dictates how often the file system is walked for config
-	FilepathWalkInterval = 100 * time.Millisecond
+	//
+	// Deprecated: use FileResyncInterval. If it is set, it overrides FileResyncInterval.
+	FilepathWalkInterval = defaultFileResyncInterval
 
 	// PilotCertDir is the default location for mTLS certificates used by pilot
 	// Visible for tests - at runtime can be set by PILOT_CERT_DIR environment variable.
@@ -209,6 +222,7 @@ type Server struct {
 
 	mesh             *meshconfig.MeshConfig
 	meshNetworks     *meshconfig.MeshNetworks
+	crl              []byte
 	configController model.ConfigStoreCache
 
 	kubeClient       kubernetes.Interface
@@ -259,6 +273,9 @@ func NewServer(args PilotArgs) (*Server, error) {
 	if err := s.initMeshNetworks(&args); err != nil {
 		return nil, fmt.Errorf("mesh networks: %v", err)
 	}
+	if err := s.initCertificateRevocationList(); err != nil {
+		return nil, fmt.Errorf("certificate revocation list: %v", err)
+	}
 	if err := s.initConfigController(&args); err != nil {
 		return nil, fmt.Errorf("config controller: %v", err)
 	}
@@ -406,7 +423,7 @@ func (s *Server) initMesh(args *PilotArgs) error {
 				s.mesh = meshConfig
 				if s.EnvoyXdsServer != nil {
 					s.EnvoyXdsServer.Env.Mesh = meshConfig
-					s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true})
+					s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true, Reason: []model.TriggerReason{model.GlobalUpdate}})
 				}
 			}
 		})
@@ -477,7 +494,42 @@ func (s *Server) initMeshNetworks(args *PilotArgs) error { //nolint: unparam
 			}
 			if s.EnvoyXdsServer != nil {
 				s.EnvoyXdsServer.Env.MeshNetworks = meshNetworks
-				s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true})
+				s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true, Reason: []model.TriggerReason{model.GlobalUpdate}})
+			}
+		}
+	})
+
+	return nil
+}
+
+// initCertificateRevocationList loads the certificate revocation list from the PILOT_CRL_FILE file
+// and adds a watcher for changes in this file.
+func (s *Server) initCertificateRevocationList() error {
+	crlFile := features.CertificateRevocationListFile.Get()
+	if crlFile == "" {
+		return nil
+	}
+
+	crl, err := ioutil.ReadFile(crlFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the certificate revocation list from %q: %v", crlFile, err)
+	}
+	log.Infof("certificate revocation list loaded from %q", crlFile)
+	s.crl = crl
+
+	// Watch the certificate revocation list for changes and reload if it got modified
+	s.addFileWatcher(crlFile, func() {
+		crl, err := ioutil.ReadFile(crlFile)
+		if err != nil {
+			log.Warnf("failed to read the certificate revocation list from %q: %v", crlFile, err)
+			return
+		}
+		if !bytes.Equal(crl, s.crl) {
+			log.Infof("certificate revocation list file %q updated", crlFile)
+			s.crl = crl
+			if s.EnvoyXdsServer != nil {
+				s.EnvoyXdsServer.Env.CertificateRevocationList = crl
+				s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true, Reason: []model.TriggerReason{model.GlobalUpdate}})
 			}
 		}
 	})
@@ -525,7 +577,7 @@ func (s *Server) initMCPConfigController(args *PilotArgs) error {
 	options := coredatamodel.Options{
 		DomainSuffix: args.Config.ControllerOptions.DomainSuffix,
 		ClearDiscoveryServerCache: func() {
-			s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true})
+			s.EnvoyXdsServer.ConfigUpdate(&model.PushRequest{Full: true, Reason: []model.TriggerReason{model.ConfigUpdate}})
 		},
 	}
 
@@ -774,10 +826,19 @@ func (s *Server) makeKubeConfigController(args *PilotArgs) (model.ConfigStoreCac
 
 func (s *Server) makeFileMonitor(fileDir string, configController model.ConfigStore) error {
 	fileSnapshot := configmonitor.NewFileSnapshot(fileDir, schemas.Istio)
-	fileMonitor := configmonitor.NewMonitor("file-monitor", configController, FilepathWalkInterval, fileSnapshot.ReadConfigFiles)
+	resyncInterval := FileResyncInterval
+	if FilepathWalkInterval != defaultFileResyncInterval {
+		resyncInterval = FilepathWalkInterval
+	}
+	fileMonitor := configmonitor.NewMonitor("file-monitor", configController, resyncInterval, fileSnapshot.ReadConfigFiles)
 
 	// Defer starting the file monitor until after the service is created.
 	s.addStartFunc(func(stop <-chan struct{}) error {
+		// Watch before the initial read, so that no change is missed.
+		if err := fileSnapshot.Watch(stop, FileWatchDebounce, fileMonitor.ScheduleProcessEvent); err != nil {
+			log.Warnf("Failed to watch config files in %s, changes are only picked up every %v: %v",
+				fileDir, resyncInterval, err)
+		}
 		fileMonitor.Start(stop)
 		return nil
 	})
@@ -790,6 +851,18 @@ func (s *Server) createK8sServiceControllers(serviceControllers *aggregate.Contr
 	clusterID := string(serviceregistry.KubernetesRegistry)
 	log.Infof("Primary Cluster name: %s", clusterID)
 	args.Config.ControllerOptions.ClusterID = clusterID
+	if features.EnableEndpointSliceController.Get() {
+		restConfig, cfgErr := kubelib.BuildClientConfig(s.getKubeCfgFile(args), "")
+		if cfgErr != nil {
+			return multierror.Prefix(cfgErr, "failed to connect to Kubernetes API.")
+		}
+		dynamicClient, dynErr := dynamic.NewForConfig(restConfig)
+		if dynErr != nil {
+			return multierror.Prefix(dynErr, "failed to create dynamic Kubernetes client.")
+		}
+		args.Config.ControllerOptions.EndpointMode = controller2.EndpointSliceOnly
+		args.Config.ControllerOptions.DynamicClient = dynamicClient
+	}
 	kubectl := controller2.NewController(s.kubeClient, args.Config.ControllerOptions)
 	s.kubeRegistry = kubectl
 	serviceControllers.AddRegistry(
@@ -892,11 +965,12 @@ func (s *Server) initMemoryRegistry(serviceControllers *aggregate.Controller) {
 
 func (s *Server) initDiscoveryService(args *PilotArgs) error {
 	environment := &model.Environment{
-		Mesh:             s.mesh,
-		MeshNetworks:     s.meshNetworks,
-		IstioConfigStore: s.istioConfigStore,
-		ServiceDiscovery: s.ServiceController,
-		PushContext:      model.NewPushContext(),
+		Mesh:                      s.mesh,
+		MeshNetworks:              s.meshNetworks,
+		IstioConfigStore:          s.istioConfigStore,
+		ServiceDiscovery:          s.ServiceController,
+		PushContext:               model.NewPushContext(),
+		CertificateRevocationList: s.crl,
 	}
 
 	// Set up discovery service
@@ -15,10 +15,12 @@
 package monitor
 
 import (
+	"crypto/sha256"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
 
 	"istio.io/istio/pilot/pkg/config/kube/crd"
 	"istio.io/istio/pilot/pkg/model"
@@ -40,6 +42,20 @@ var (
 type FileSnapshot struct {
 	root             string
 	configTypeFilter map[string]bool
+
+	mutex sync.Mutex
+	// files holds the configs last read from each file under the root directory, keyed by path.
+	files map[string]*fileConfigs
+	// changed holds the paths reported by the file watcher since the last ReadConfigFiles.
+	changed map[string]bool
+}
+
+// fileConfigs are the configs read from a file, along with the hash of the content they were read from.
+type fileConfigs struct {
+	sum     [sha256.Size]byte
+	configs []*model.Config
+	// err is the error of the last attempt to read the file. configs are those of the last successful attempt.
+	err error
 }
 
 // NewFileSnapshot returns a snapshotter.
@@ -48,6 +64,8 @@ func NewFileSnapshot(root string, descriptor schema.Set) *FileSnapshot {
 	snapshot := &FileSnapshot{
 		root:             root,
 		configTypeFilter: make(map[string]bool),
+		files:            make(map[string]*fileConfigs),
+		changed:          make(map[string]bool),
 	}
 
 	types := descriptor.Types()
@@ -66,42 +84,100 @@ func NewFileSnapshot(root string, descriptor schema.Set) *FileSnapshot {
 
 // ReadConfigFiles parses files in the root directory and returns a sorted slice of
 // eligible model.Config. This can be used as a configFunc when creating a Monitor.
+//
+// Files are only parsed again when their content changed, or when the file watcher reported
+// a change to them. If a file can't be read or parsed, the error is logged and the configs
+// previously read from that file are kept, until the file is fixed or removed.
 func (f *FileSnapshot) ReadConfigFiles() ([]*model.Config, error) {
-	var result []*model.Config
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
 
+	changed := f.changed
+	f.changed = make(map[string]bool)
+
+	found := make(map[string]bool, len(f.files))
 	err := filepath.Walk(f.root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		} else if !supportedExtensions[filepath.Ext(path)] || (info.Mode()&os.ModeType) != 0 {
 			return nil
 		}
+		found[path] = true
+
+		entry, ok := f.files[path]
+		if !ok {
+			entry = &fileConfigs{}
+			f.files[path] = entry
+		}
+
 		data, err := ioutil.ReadFile(path)
 		if err != nil {
-			log.Warnf("Failed to read %s: %v", path, err)
-			return err
+			log.Warnf("Failed to read %s, keeping the %d configs previously read from it: %v", path, len(entry.configs), err)
+			entry.sum, entry.err = [sha256.Size]byte{}, err
+			return nil
+		}
+		sum := sha256.Sum256(data)
+		if ok && entry.sum == sum && !changed[path] {
+			return nil
 		}
+		entry.sum = sum
+
 		configs, err := parseInputs(data)
 		if err != nil {
-			log.Warnf("Failed to parse %s: %v", path, err)
-			return err
+			log.Warnf("Failed to parse %s, keeping the %d configs previously read from it: %v", path, len(entry.configs), err)
+			entry.err = err
+			return nil
 		}
+		entry.configs, entry.err = configs, nil
+		return nil
+	})
+	if err != nil {
+		log.Warnf("failure during filepath.Walk: %v", err)
+		return nil, err
+	}
 
+	var result []*model.Config
+	for path, entry := range f.files {
+		if !found[path] {
+			delete(f.files, path)
+			continue
+		}
 		// Filter any unsupported types before appending to the result.
-		for _, cfg := range configs {
+		for _, cfg := range entry.configs {
 			if !f.configTypeFilter[cfg.Type] {
 				continue
 			}
-			result = append(result, cfg)
+			// Return a copy, as the caller may modify it, e.g. the Monitor sets the resource version.
+			c := *cfg
+			result = append(result, &c)
 		}
-		return nil
-	})
-	if err != nil {
-		log.Warnf("failure during filepath.Walk: %v", err)
 	}
 
 	// Sort by the config IDs.
 	sort.Sort(byKey(result))
-	return result, err
+	return result, nil
+}
+
+// Errors returns the files that failed to be read or parsed by the last ReadConfigFiles, keyed by path.
+func (f *FileSnapshot) Errors() map[string]error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	errs := make(map[string]error)
+	for path, entry := range f.files {
+		if entry.err != nil {
+			errs[path] = entry.err
+		}
+	}
+	return errs
+}
+
+// markChanged records that the file watcher reported a change to the path, so that it is parsed again by the next
+// ReadConfigFiles even if its content looks unchanged.
+func (f *FileSnapshot) markChanged(path string) {
+	f.mutex.Lock()
+	f.changed[path] = true
+	f.mutex.Unlock()
 }
 
 // parseInputs is identical to crd.ParseInputs, except that it returns an array of config pointers.
@@ -18,7 +18,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/onsi/gomega"
 
@@ -126,6 +128,131 @@ func TestFileSnapshotSorting(t *testing.T) {
 	g.Expect(configs[1].Spec).To(gomega.BeAssignableToTypeOf(&networking.VirtualService{}))
 }
 
+func TestFileSnapshotKeepsConfigOnParseError(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ts := &testState{
+		ConfigFiles: map[string][]byte{
+			"gateway.yml":         []byte(gatewayYAML),
+			"virtual_service.yml": []byte(virtualServiceYAML),
+		},
+	}
+
+	ts.testSetup(t)
+	defer ts.testTeardown(t)
+
+	fileWatcher := monitor.NewFileSnapshot(ts.rootPath, nil)
+	configs, err := fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(2))
+
+	// An invalid file keeps the configs previously read from it, and is reported.
+	gatewayPath := filepath.Join(ts.rootPath, "gateway.yml")
+	g.Expect(ioutil.WriteFile(gatewayPath, []byte("kind: Gateway\n  bad: yaml\n"), 0600)).To(gomega.Succeed())
+	configs, err = fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(2))
+	g.Expect(configs[0].Spec).To(gomega.BeAssignableToTypeOf(&networking.Gateway{}))
+	g.Expect(fileWatcher.Errors()).To(gomega.HaveKey(gatewayPath))
+
+	// Fixing the file clears the error.
+	g.Expect(ioutil.WriteFile(gatewayPath, []byte(gatewayYAML), 0600)).To(gomega.Succeed())
+	configs, err = fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(2))
+	g.Expect(fileWatcher.Errors()).To(gomega.BeEmpty())
+
+	// Removing a file drops its configs.
+	g.Expect(os.Remove(gatewayPath)).To(gomega.Succeed())
+	configs, err = fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(1))
+	g.Expect(configs[0].Spec).To(gomega.BeAssignableToTypeOf(&networking.VirtualService{}))
+}
+
+func TestFileSnapshotDetectsContentChanges(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ts := &testState{
+		ConfigFiles: map[string][]byte{"gateway.yml": []byte(gatewayYAML)},
+	}
+
+	ts.testSetup(t)
+	defer ts.testTeardown(t)
+
+	gatewayPath := filepath.Join(ts.rootPath, "gateway.yml")
+	info, err := os.Stat(gatewayPath)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	fileWatcher := monitor.NewFileSnapshot(ts.rootPath, nil)
+	configs, err := fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(1))
+	g.Expect(configs[0].Spec.(*networking.Gateway).Servers[0].Hosts).To(gomega.Equal([]string{"*.example.com"}))
+
+	// A change that keeps the size and the modification time of the file is still picked up.
+	changed := strings.Replace(gatewayYAML, "*.example.com", "*.example.org", 1)
+	g.Expect(ioutil.WriteFile(gatewayPath, []byte(changed), 0600)).To(gomega.Succeed())
+	g.Expect(os.Chtimes(gatewayPath, info.ModTime(), info.ModTime())).To(gomega.Succeed())
+	configs, err = fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(1))
+	g.Expect(configs[0].Spec.(*networking.Gateway).Servers[0].Hosts).To(gomega.Equal([]string{"*.example.org"}))
+}
+
+func TestFileSnapshotReturnsCopies(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ts := &testState{
+		ConfigFiles: map[string][]byte{"gateway.yml": []byte(gatewayYAML)},
+	}
+
+	ts.testSetup(t)
+	defer ts.testTeardown(t)
+
+	fileWatcher := monitor.NewFileSnapshot(ts.rootPath, nil)
+	configs, err := fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(1))
+
+	// Changing a returned config, as the Monitor does, does not change the cached one.
+	configs[0].ResourceVersion = "changed"
+	configs, err = fileWatcher.ReadConfigFiles()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(configs).To(gomega.HaveLen(1))
+	g.Expect(configs[0].ResourceVersion).To(gomega.BeEmpty())
+}
+
+func TestFileSnapshotWatch(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	ts := &testState{
+		ConfigFiles: map[string][]byte{"gateway.yml": []byte(gatewayYAML)},
+	}
+
+	ts.testSetup(t)
+	defer ts.testTeardown(t)
+
+	notified := make(chan struct{}, 10)
+	stop := make(chan struct{})
+	defer close(stop)
+	fileWatcher := monitor.NewFileSnapshot(ts.rootPath, nil)
+	err := fileWatcher.Watch(stop, 50*time.Millisecond, func() { notified <- struct{}{} })
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// A burst of changes results in a single notification.
+	subdir := filepath.Join(ts.rootPath, "networking")
+	g.Expect(os.Mkdir(subdir, 0700)).To(gomega.Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(ts.rootPath, "virtual_service.yml"), []byte(virtualServiceYAML), 0600)).
+		To(gomega.Succeed())
+	g.Eventually(notified).Should(gomega.Receive())
+	g.Consistently(notified, 200*time.Millisecond).ShouldNot(gomega.Receive())
+
+	// Files in new subdirectories are watched too.
+	g.Expect(ioutil.WriteFile(filepath.Join(subdir, "gateway.yml"), []byte(gatewayYAML), 0600)).To(gomega.Succeed())
+	g.Eventually(notified).Should(gomega.Receive())
+}
+
 type testState struct {
 	ConfigFiles map[string][]byte
 	rootPath    string
@@ -0,0 +1,111 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"istio.io/pkg/log"
+)
+
+// Watch watches the root directory and its subdirectories for changes and calls notify once the
+// files stopped changing for the debounce duration, so that a burst of writes results in a single
+// notification. Watching stops when the stop channel is closed.
+func (f *FileSnapshot) Watch(stop <-chan struct{}, debounce time.Duration, notify func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := addWatches(watcher, f.root); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	go f.watchEvents(watcher, stop, debounce, notify)
+	return nil
+}
+
+// addWatches watches the directory and all its subdirectories, as fsnotify is not recursive.
+func addWatches(watcher *fsnotify.Watcher, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+func (f *FileSnapshot) watchEvents(watcher *fsnotify.Watcher, stop <-chan struct{}, debounce time.Duration, notify func()) {
+	var timer *time.Timer
+	var timerC <-chan time.Time
+
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := watcher.Close(); err != nil {
+			log.Warnf("Failed to close the config file watcher: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			log.Debugf("Config file event: %v", event)
+			f.markChanged(filepath.Clean(event.Name))
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addWatches(watcher, event.Name); err != nil {
+						log.Warnf("Failed to watch %s: %v", event.Name, err)
+					}
+				}
+			}
+
+			// Restart the debounce period.
+			if timer == nil {
+				timer = time.NewTimer(debounce)
+				timerC = timer.C
+			} else {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(debounce)
+			}
+		case <-timerC:
+			timer, timerC = nil, nil
+			notify()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("Config file watcher error: %v", err)
+		case <-stop:
+			return
+		}
+	}
+}
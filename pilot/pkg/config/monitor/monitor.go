@@ -33,42 +33,68 @@ type Monitor struct {
 	checkDuration   time.Duration
 	configs         []*model.Config
 	getSnapshotFunc func() ([]*model.Config, error)
+	// updateCh triggers a check outside of the polling interval, see ScheduleProcessEvent.
+	updateCh chan struct{}
 }
 
 // NewMonitor creates a Monitor and will delegate to a passed in controller.
 // The controller holds a reference to the actual store.
-// Any func that returns a []*model.Config can be used with the Monitor
+// Any func that returns a []*model.Config can be used with the Monitor.
+// If checkInterval is 0, the Monitor does not poll and only checks for changes
+// when ScheduleProcessEvent is called.
 func NewMonitor(name string, delegateStore model.ConfigStore, checkInterval time.Duration, getSnapshotFunc func() ([]*model.Config, error)) *Monitor {
 	monitor := &Monitor{
 		name:            name,
 		store:           delegateStore,
 		getSnapshotFunc: getSnapshotFunc,
 		checkDuration:   checkInterval,
+		updateCh:        make(chan struct{}, 1),
 	}
 	return monitor
 }
 
 // Start starts a new Monitor. Immediately checks the Monitor getSnapshotFunc
 // and updates the controller. It then kicks off an asynchronous event loop that
-// periodically polls the getSnapshotFunc for changes until a close event is sent.
+// periodically polls the getSnapshotFunc for changes, or checks them when
+// scheduled, until a close event is sent.
 func (m *Monitor) Start(stop <-chan struct{}) {
 	m.checkAndUpdate()
-	tick := time.NewTicker(m.checkDuration)
+
+	var tickC <-chan time.Time
+	var tick *time.Ticker
+	if m.checkDuration > 0 {
+		tick = time.NewTicker(m.checkDuration)
+		tickC = tick.C
+	}
 
 	// Run the close loop asynchronously.
 	go func() {
 		for {
 			select {
 			case <-stop:
-				tick.Stop()
+				if tick != nil {
+					tick.Stop()
+				}
 				return
-			case <-tick.C:
+			case <-tickC:
+				m.checkAndUpdate()
+			case <-m.updateCh:
 				m.checkAndUpdate()
 			}
 		}
 	}()
 }
 
+// ScheduleProcessEvent schedules a check of the getSnapshotFunc for changes. Checks
+// scheduled while one is already pending are merged. It can be used as the notify
+// func of FileSnapshot.Watch.
+func (m *Monitor) ScheduleProcessEvent() {
+	select {
+	case m.updateCh <- struct{}{}:
+	default:
+	}
+}
+
 func (m *Monitor) checkAndUpdate() {
 	newConfigs, err := m.getSnapshotFunc()
 	//If an error exists then log it and return to running the check and update
@@ -187,3 +187,38 @@ func TestMonitorForError(t *testing.T) {
 		return nil
 	}).Should(gomega.Succeed())
 }
+
+func TestMonitorScheduleProcessEvent(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	store := memory.Make(schema.Set{schemas.Gateway})
+
+	configs := make(chan []*model.Config, 1)
+	configs <- createConfigSet
+	current := []*model.Config{}
+	someConfigFunc := func() ([]*model.Config, error) {
+		select {
+		case current = <-configs:
+		default:
+		}
+		return current, nil
+	}
+
+	// Without a check interval, the monitor only checks for changes when scheduled.
+	mon := monitor.NewMonitor("", store, 0, someConfigFunc)
+	stop := make(chan struct{})
+	defer close(stop)
+	mon.Start(stop)
+
+	g.Expect(store.List("gateway", "")).To(gomega.HaveLen(1))
+
+	configs <- []*model.Config{}
+	g.Consistently(func() ([]model.Config, error) {
+		return store.List("gateway", "")
+	}, 3*checkInterval).Should(gomega.HaveLen(1))
+
+	mon.ScheduleProcessEvent()
+	g.Eventually(func() ([]model.Config, error) {
+		return store.List("gateway", "")
+	}).Should(gomega.HaveLen(0))
+}
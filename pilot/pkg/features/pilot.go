@@ -52,6 +52,16 @@ var (
 	// For larger clusters it can increase memory use and GC - useful for small tests.
 	DebugConfigs = env.RegisterBoolVar("PILOT_DEBUG_ADSZ_CONFIG", false, "").Get()
 
+	// PushHistorySize controls the number of pushes kept per proxy for /debug/push_history.
+	// Defaults to 0, disabling the history. Enabling it keeps a copy of the listeners, clusters
+	// and routes last pushed to each proxy, to compute the differences between pushes.
+	PushHistorySize = env.RegisterIntVar(
+		"PILOT_PUSH_HISTORY_SIZE",
+		0,
+		"The number of pushes kept per proxy, with their trigger and the changes of their listeners, clusters and routes, "+
+			"for /debug/push_history. Disabled if 0.",
+	).Get()
+
 	DebounceAfter = env.RegisterDurationVar(
 		"PILOT_DEBOUNCE_AFTER",
 		100*time.Millisecond,
@@ -185,12 +195,58 @@ var (
 			"Gateways with same selectors in different namespaces will not be applicable.",
 	)
 
+	EnableEndpointSliceController = env.RegisterBoolVar(
+		"PILOT_USE_ENDPOINT_SLICE",
+		false,
+		"If enabled, Pilot will use EndpointSlices as the source of endpoints for Kubernetes services. "+
+			"The EndpointSlice API (discovery.k8s.io/v1alpha1) must be enabled in the cluster.",
+	)
+
 	RespectDNSTTL = env.RegisterBoolVar(
 		"PILOT_RESPECT_DNS_TTL",
 		true,
 		"If enabled, DNS based clusters will respect the TTL of the DNS, rather than polling at a fixed rate. "+
 			"This option is only provided for backward compatibility purposes and will be removed in the near future.",
 	)
+
+	// RateLimitService is the Envoy rate limit service used by the ratelimit plugin. Rate limiting is
+	// disabled if not set.
+	RateLimitService = env.RegisterStringVar(
+		"PILOT_RATE_LIMIT_SERVICE",
+		"",
+		"The host:port of the Envoy rate limit service (RLS) in the mesh, e.g. ratelimit.istio-system.svc.cluster.local:8081. "+
+			"If set and the ratelimit plugin is enabled, the rate limit filter is added to the outbound and gateway HTTP listeners.",
+	)
+
+	RateLimitDomain = env.RegisterStringVar(
+		"PILOT_RATE_LIMIT_DOMAIN",
+		"istio",
+		"The domain of the descriptors sent to the rate limit service.",
+	)
+
+	RateLimitFailureModeDeny = env.RegisterBoolVar(
+		"PILOT_RATE_LIMIT_FAILURE_MODE_DENY",
+		false,
+		"If enabled, requests are denied when the rate limit service can't be reached.",
+	)
+
+	// ExtAuthzProviders are the external authorization services used by the extauthz plugin.
+	ExtAuthzProviders = env.RegisterStringVar(
+		"PILOT_EXT_AUTHZ_PROVIDERS",
+		"",
+		"A JSON list of external authorization providers, e.g. "+
+			`[{"name": "opa", "selector": {"app": "productpage"}, "grpc": "opa.opa.svc.cluster.local:9191", "timeout": "1s"}]. `+
+			"If set and the extauthz plugin is enabled, the ext_authz filter calling the first provider selecting a workload "+
+			"is added to the inbound HTTP listeners of its sidecar, or to the HTTP listeners of a gateway.",
+	)
+
+	CertificateRevocationListFile = env.RegisterStringVar(
+		"PILOT_CRL_FILE",
+		"",
+		"The path of a PEM-encoded certificate revocation list, e.g. the crl.pem key of the istio-ca-crl ConfigMap "+
+			"published by Citadel. If set, the list is added to the validation context of the Istio mutual TLS "+
+			"clusters and inbound listeners, and pushed again whenever the file changes.",
+	)
 )
 
 var (
@@ -56,6 +56,10 @@ type Environment struct {
 	// routable L3 network. A single routable L3 network can have one or more
 	// service registries.
 	MeshNetworks *meshconfig.MeshNetworks
+
+	// CertificateRevocationList (loaded from a file) is the PEM-encoded certificate revocation list
+	// checked by the proxies for Istio mutual TLS, or empty.
+	CertificateRevocationList []byte
 }
 
 // Proxy contains information about an specific instance of a proxy (envoy sidecar, gateway,
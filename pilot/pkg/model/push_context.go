@@ -164,8 +164,33 @@ type PushRequest struct {
 	// Start represents the time a push was started. This represents the time of adding to the PushQueue.
 	// Note that this does not include time spent debouncing.
 	Start time.Time
+
+	// Reason lists the kinds of changes that triggered the push, without duplicates.
+	// This is used for debugging, e.g. in the push history of the proxies.
+	Reason []TriggerReason
+
+	// ConfigsUpdated keeps track of the configs that changed since the last push.
+	// Key is the config key, see model.Key.
+	// This is used for debugging, e.g. in the push history of the proxies.
+	ConfigsUpdated map[string]struct{}
 }
 
+// TriggerReason describes a kind of change that triggers a push
+type TriggerReason string
+
+const (
+	// EndpointUpdate is a change of the endpoints of a service.
+	EndpointUpdate TriggerReason = "endpoint"
+	// ServiceUpdate is a change of a service or of a service instance.
+	ServiceUpdate TriggerReason = "service"
+	// ConfigUpdate is a change of an Istio config, listed in ConfigsUpdated when known.
+	ConfigUpdate TriggerReason = "config"
+	// GlobalUpdate is a change of the mesh config or mesh networks.
+	GlobalUpdate TriggerReason = "global"
+	// UnknownTrigger is any other change, e.g. a JWT public key rotation.
+	UnknownTrigger TriggerReason = "unknown"
+)
+
 // Merge two update requests together
 func (first *PushRequest) Merge(other *PushRequest) *PushRequest {
 	if first == nil {
@@ -186,6 +211,25 @@ func (first *PushRequest) Merge(other *PushRequest) *PushRequest {
 		Push: other.Push,
 	}
 
+	// Merge the reasons, keeping the order of the first occurrences
+	for _, reasons := range [][]TriggerReason{first.Reason, other.Reason} {
+		for _, reason := range reasons {
+			if !containsReason(merged.Reason, reason) {
+				merged.Reason = append(merged.Reason, reason)
+			}
+		}
+	}
+
+	if len(first.ConfigsUpdated) > 0 || len(other.ConfigsUpdated) > 0 {
+		merged.ConfigsUpdated = make(map[string]struct{}, len(first.ConfigsUpdated)+len(other.ConfigsUpdated))
+		for key := range first.ConfigsUpdated {
+			merged.ConfigsUpdated[key] = struct{}{}
+		}
+		for key := range other.ConfigsUpdated {
+			merged.ConfigsUpdated[key] = struct{}{}
+		}
+	}
+
 	// Only merge EdsUpdates when incremental eds push needed.
 	if !merged.Full {
 		merged.EdsUpdates = make(map[string]struct{})
@@ -222,6 +266,15 @@ func (first *PushRequest) Merge(other *PushRequest) *PushRequest {
 	return merged
 }
 
+func containsReason(reasons []TriggerReason, reason TriggerReason) bool {
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
 // ProxyPushStatus represents an event captured during config push to proxies.
 // It may contain additional message and the affected proxy.
 type ProxyPushStatus struct {
@@ -98,6 +98,16 @@ func TestMergeUpdateRequest(t *testing.T) {
 			&PushRequest{Full: false, TargetNamespaces: map[string]struct{}{"ns2": {}}, EdsUpdates: map[string]struct{}{"svc-2": {}}},
 			PushRequest{Full: false, TargetNamespaces: map[string]struct{}{"ns1": {}, "ns2": {}}, EdsUpdates: map[string]struct{}{"svc-1": {}, "svc-2": {}}},
 		},
+		{
+			"reasons and configs merge",
+			&PushRequest{Full: true, Reason: []TriggerReason{ConfigUpdate, EndpointUpdate}, ConfigsUpdated: map[string]struct{}{"vs-1": {}}},
+			&PushRequest{Full: true, Reason: []TriggerReason{ServiceUpdate, ConfigUpdate}, ConfigsUpdated: map[string]struct{}{"vs-2": {}}},
+			PushRequest{
+				Full:           true,
+				Reason:         []TriggerReason{ConfigUpdate, EndpointUpdate, ServiceUpdate},
+				ConfigsUpdated: map[string]struct{}{"vs-1": {}, "vs-2": {}},
+			},
+		},
 	}
 
 	for _, tt := range cases {
@@ -1259,3 +1259,50 @@ func TestPassthroughClustersBuildUponProxyIpVersions(t *testing.T) {
 		g.Expect(validation(clusters)).To(Equal(inAndOut.features))
 	}
 }
+
+func TestApplyUpstreamTLSSettingsWithCertificateRevocationList(t *testing.T) {
+	crl := []byte("crl")
+	istioMutual := &networking.TLSSettings{
+		Mode:              networking.TLSSettings_ISTIO_MUTUAL,
+		ClientCertificate: "/etc/certs/cert-chain.pem",
+		PrivateKey:        "/etc/certs/key.pem",
+		CaCertificates:    "/etc/certs/root-cert.pem",
+	}
+	mutual := &networking.TLSSettings{
+		Mode:              networking.TLSSettings_MUTUAL,
+		ClientCertificate: "/cert.pem",
+		PrivateKey:        "/key.pem",
+		CaCertificates:    "/ca.pem",
+	}
+
+	cases := []struct {
+		name       string
+		sdsUdsPath string
+		tls        *networking.TLSSettings
+		crl        []byte
+	}{
+		{"istio mutual with file mount", "", istioMutual, crl},
+		{"istio mutual with SDS", "unix:/var/run/sds/uds_path", istioMutual, crl},
+		{"mutual", "", mutual, nil},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			env := &model.Environment{
+				Mesh:                      &meshconfig.MeshConfig{SdsUdsPath: tt.sdsUdsPath},
+				CertificateRevocationList: crl,
+			}
+			cluster := &apiv2.Cluster{Name: "outbound|8080||foo.example.org"}
+			applyUpstreamTLSSettings(env, cluster, tt.tls, map[string]string{})
+
+			tlsContext := cluster.TlsContext.CommonTlsContext
+			validationContext := tlsContext.GetValidationContext()
+			if tt.sdsUdsPath != "" {
+				validationContext = tlsContext.GetCombinedValidationContext().DefaultValidationContext
+			}
+			g.Expect(validationContext).NotTo(BeNil())
+			g.Expect(validationContext.Crl.GetInlineBytes()).To(Equal(tt.crl))
+		})
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extauthz configures Envoy to call an external authorization service, without a Mixer round-trip.
+//
+// The authorization services are set with PILOT_EXT_AUTHZ_PROVIDERS, a JSON list of providers, e.g.
+//
+//   [{"name": "opa", "selector": {"app": "productpage"}, "grpc": "opa.opa.svc.cluster.local:9191", "timeout": "1s"},
+//    {"name": "in-house", "http": "authz.security.svc.cluster.local:8000", "pathPrefix": "/check", "failureModeAllow": true}]
+//
+// The first provider whose selector matches the labels of a workload is used: the plugin adds the envoy.ext_authz
+// HTTP filter, calling the provider through its outbound cluster, to the inbound HTTP listeners of its sidecar,
+// or to the HTTP listeners of a gateway. A provider without selector applies to all the workloads.
+package extauthz
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	extauthzfilter "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
+	http_conn "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/plugin"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/labels"
+	istiolog "istio.io/pkg/log"
+)
+
+const (
+	// filterName is the name of the Envoy HTTP external authorization filter.
+	filterName = "envoy.ext_authz"
+
+	// defaultTimeout is the timeout of the authorization requests of the providers without timeout.
+	defaultTimeout = 200 * time.Millisecond
+)
+
+var (
+	extAuthzLog = istiolog.RegisterScope("extauthz", "external authorization debugging", 0)
+)
+
+// Provider is an external authorization service, called over gRPC (envoy.service.auth.v2.Authorization) or HTTP.
+type Provider struct {
+	// Name identifies the provider in the logs.
+	Name string `json:"name"`
+
+	// Selector is the labels of the workloads using the provider. Matches all the workloads if empty.
+	Selector map[string]string `json:"selector,omitempty"`
+
+	// GRPC is the host:port of a gRPC authorization service. Exactly one of GRPC and HTTP must be set.
+	GRPC string `json:"grpc,omitempty"`
+
+	// HTTP is the host:port of an HTTP authorization service.
+	HTTP string `json:"http,omitempty"`
+
+	// PathPrefix is prepended to the path of the requests sent to the HTTP authorization service.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+
+	// Timeout of the authorization requests, e.g. 500ms. Defaults to 200ms.
+	Timeout string `json:"timeout,omitempty"`
+
+	// FailureModeAllow lets requests through when the authorization service can't be reached or fails.
+	// Requests are denied by default.
+	FailureModeAllow bool `json:"failureModeAllow,omitempty"`
+
+	// cluster is the outbound cluster of the authorization service.
+	cluster string
+	timeout time.Duration
+}
+
+// Plugin implements external authorization
+type Plugin struct {
+	providers []*Provider
+}
+
+// NewPlugin returns an instance of the external authorization plugin, configured with PILOT_EXT_AUTHZ_PROVIDERS
+func NewPlugin() plugin.Plugin {
+	value := features.ExtAuthzProviders.Get()
+	if value == "" {
+		return Plugin{}
+	}
+	providers, err := ParseProviders(value)
+	if err != nil {
+		extAuthzLog.Errorf("Disabling external authorization, invalid PILOT_EXT_AUTHZ_PROVIDERS: %v", err)
+		return Plugin{}
+	}
+	return Plugin{providers: providers}
+}
+
+// ParseProviders parses and validates a JSON list of providers.
+func ParseProviders(value string) ([]*Provider, error) {
+	var providers []*Provider
+	if err := json.Unmarshal([]byte(value), &providers); err != nil {
+		return nil, err
+	}
+	for i, p := range providers {
+		if p == nil {
+			return nil, fmt.Errorf("provider %d is null", i)
+		}
+		if err := p.init(); err != nil {
+			return nil, fmt.Errorf("provider %d (%s): %v", i, p.Name, err)
+		}
+	}
+	return providers, nil
+}
+
+// init validates the provider and computes its cluster and timeout.
+func (p *Provider) init() error {
+	address := p.GRPC
+	switch {
+	case p.GRPC != "" && p.HTTP != "":
+		return fmt.Errorf("only one of grpc and http can be set")
+	case p.HTTP != "":
+		address = p.HTTP
+	case p.GRPC == "":
+		return fmt.Errorf("one of grpc and http must be set")
+	}
+	if p.PathPrefix != "" && p.HTTP == "" {
+		return fmt.Errorf("pathPrefix requires an http service")
+	}
+
+	hostname, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid service %q: %v", address, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("invalid service port %q: %v", portStr, err)
+	}
+	p.cluster = model.BuildSubsetKey(model.TrafficDirectionOutbound, "", host.Name(hostname), port)
+
+	p.timeout = defaultTimeout
+	if p.Timeout != "" {
+		if p.timeout, err = time.ParseDuration(p.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout %q: %v", p.Timeout, err)
+		}
+		if p.timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q: must be positive", p.Timeout)
+		}
+	}
+
+	return labels.Instance(p.Selector).Validate()
+}
+
+// providerFor returns the first provider selecting the workload of the proxy, or nil.
+func (p Plugin) providerFor(node *model.Proxy) *Provider {
+	for _, provider := range p.providers {
+		if node.WorkloadLabels.IsSupersetOf(provider.Selector) {
+			return provider
+		}
+	}
+	return nil
+}
+
+// buildFilter returns the external authorization filter calling the provider through its outbound cluster.
+func buildFilter(provider *Provider, node *model.Proxy) *http_conn.HttpFilter {
+	config := &extauthzfilter.ExtAuthz{
+		FailureModeAllow: provider.FailureModeAllow,
+	}
+	if provider.GRPC != "" {
+		config.Services = &extauthzfilter.ExtAuthz_GrpcService{
+			GrpcService: &core.GrpcService{
+				TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
+						ClusterName: provider.cluster,
+					},
+				},
+				Timeout: types.DurationProto(provider.timeout),
+			},
+		}
+	} else {
+		timeout := provider.timeout
+		config.Services = &extauthzfilter.ExtAuthz_HttpService{
+			HttpService: &extauthzfilter.HttpService{
+				ServerUri: &core.HttpUri{
+					Uri:              "http://" + provider.HTTP,
+					HttpUpstreamType: &core.HttpUri_Cluster{Cluster: provider.cluster},
+					Timeout:          &timeout,
+				},
+				PathPrefix: provider.PathPrefix,
+			},
+		}
+	}
+
+	out := &http_conn.HttpFilter{
+		Name: filterName,
+	}
+	if util.IsXDSMarshalingToAnyEnabled(node) {
+		out.ConfigType = &http_conn.HttpFilter_TypedConfig{TypedConfig: util.MessageToAny(config)}
+	} else {
+		out.ConfigType = &http_conn.HttpFilter_Config{Config: util.MessageToStruct(config)}
+	}
+	return out
+}
+
+// addFilter adds the filter of the provider selecting the proxy, if any, to the HTTP filter chains.
+func (p Plugin) addFilter(in *plugin.InputParams, mutable *plugin.MutableObjects) {
+	provider := p.providerFor(in.Node)
+	if provider == nil {
+		return
+	}
+	plugin.AppendHTTPFilter(in, mutable, buildFilter(provider, in.Node))
+	extAuthzLog.Debugf("added the %s external authorization filter to %s", provider.Name, in.Node.ID)
+}
+
+// OnOutboundListener is called whenever a new outbound listener is added to the LDS output for a given service
+// Can be used to add additional filters on the outbound path
+func (p Plugin) OnOutboundListener(in *plugin.InputParams, mutable *plugin.MutableObjects) error {
+	// Only gateways authorize the requests of their outbound listeners.
+	if in.Node.Type != model.Router {
+		return nil
+	}
+	p.addFilter(in, mutable)
+	return nil
+}
+
+// OnInboundListener is called whenever a new listener is added to the LDS output for a given service
+// Can be used to add additional filters on the inbound path
+func (p Plugin) OnInboundListener(in *plugin.InputParams, mutable *plugin.MutableObjects) error {
+	if in.Node.Type != model.SidecarProxy {
+		return nil
+	}
+	p.addFilter(in, mutable)
+	return nil
+}
+
+// OnVirtualListener implements the Plugin interface method.
+func (Plugin) OnVirtualListener(in *plugin.InputParams, mutable *plugin.MutableObjects) error {
+	return nil
+}
+
+// OnInboundCluster implements the Plugin interface method.
+func (Plugin) OnInboundCluster(in *plugin.InputParams, cluster *xdsapi.Cluster) {
+}
+
+// OnOutboundRouteConfiguration implements the Plugin interface method.
+func (Plugin) OnOutboundRouteConfiguration(in *plugin.InputParams, route *xdsapi.RouteConfiguration) {
+}
+
+// OnInboundRouteConfiguration implements the Plugin interface method.
+func (Plugin) OnInboundRouteConfiguration(in *plugin.InputParams, route *xdsapi.RouteConfiguration) {
+}
+
+// OnOutboundCluster implements the Plugin interface method.
+func (Plugin) OnOutboundCluster(in *plugin.InputParams, cluster *xdsapi.Cluster) {
+}
+
+// OnInboundFilterChains is called whenever a plugin needs to setup the filter chains, including relevant filter chain configuration.
+func (Plugin) OnInboundFilterChains(in *plugin.InputParams) []plugin.FilterChain {
+	return nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extauthz
+
+import (
+	"testing"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	extauthzfilter "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/ext_authz/v2"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/plugin"
+	"istio.io/istio/pkg/config/labels"
+)
+
+func mustParse(t *testing.T, value string) Plugin {
+	t.Helper()
+	providers, err := ParseProviders(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Plugin{providers: providers}
+}
+
+func TestParseProviders(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"grpc", `[{"name": "opa", "grpc": "opa.opa.svc.cluster.local:9191", "timeout": "1s"}]`, true},
+		{"http", `[{"name": "authz", "http": "authz:8000", "pathPrefix": "/check", "selector": {"app": "a"}}]`, true},
+		{"empty", `[]`, true},
+		{"invalid json", `[{"name": }]`, false},
+		{"null provider", `[null]`, false},
+		{"no service", `[{"name": "none"}]`, false},
+		{"both services", `[{"name": "both", "grpc": "opa:9191", "http": "authz:8000"}]`, false},
+		{"missing port", `[{"name": "opa", "grpc": "opa"}]`, false},
+		{"invalid port", `[{"name": "opa", "grpc": "opa:grpc"}]`, false},
+		{"invalid timeout", `[{"name": "opa", "grpc": "opa:9191", "timeout": "1"}]`, false},
+		{"negative timeout", `[{"name": "opa", "grpc": "opa:9191", "timeout": "-1s"}]`, false},
+		{"path prefix with grpc", `[{"name": "opa", "grpc": "opa:9191", "pathPrefix": "/check"}]`, false},
+		{"invalid selector", `[{"name": "opa", "grpc": "opa:9191", "selector": {"app": "a b"}}]`, false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseProviders(tt.value)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("got error %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestOnListener(t *testing.T) {
+	p := mustParse(t, `[
+		{"name": "productpage", "selector": {"app": "productpage"}, "grpc": "opa:9191"},
+		{"name": "ingress", "selector": {"istio": "ingressgateway"}, "http": "authz:8000"}
+	]`)
+	sidecar := &model.Proxy{Type: model.SidecarProxy, WorkloadLabels: labels.Collection{{"app": "productpage", "version": "v1"}}}
+	gateway := &model.Proxy{Type: model.Router, WorkloadLabels: labels.Collection{{"istio": "ingressgateway"}}}
+
+	cases := []struct {
+		name     string
+		plugin   Plugin
+		inbound  bool
+		in       *plugin.InputParams
+		chains   []plugin.ListenerProtocol
+		expected []bool
+	}{
+		{
+			name:     "disabled without providers",
+			plugin:   Plugin{},
+			inbound:  true,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolHTTP, Node: sidecar},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolHTTP},
+			expected: []bool{false},
+		},
+		{
+			name:     "sidecar inbound HTTP listener",
+			plugin:   p,
+			inbound:  true,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolHTTP, Node: sidecar},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolHTTP},
+			expected: []bool{true},
+		},
+		{
+			name:     "sidecar inbound TCP listener",
+			plugin:   p,
+			inbound:  true,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolTCP, Node: sidecar},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolTCP},
+			expected: []bool{false},
+		},
+		{
+			name:     "sidecar inbound auto listener",
+			plugin:   p,
+			inbound:  true,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolAuto, Node: sidecar},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolTCP, plugin.ListenerProtocolHTTP},
+			expected: []bool{false, true},
+		},
+		{
+			name:    "sidecar not selected",
+			plugin:  p,
+			inbound: true,
+			in: &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolHTTP, Node: &model.Proxy{
+				Type: model.SidecarProxy, WorkloadLabels: labels.Collection{{"app": "reviews"}}}},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolHTTP},
+			expected: []bool{false},
+		},
+		{
+			name:     "sidecar outbound HTTP listener",
+			plugin:   p,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolHTTP, Node: sidecar},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolHTTP},
+			expected: []bool{false},
+		},
+		{
+			name:     "gateway terminating TLS",
+			plugin:   p,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolTCP, Node: gateway},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolHTTP, plugin.ListenerProtocolTCP},
+			expected: []bool{true, false},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mutable := &plugin.MutableObjects{Listener: &xdsapi.Listener{}}
+			for _, p := range tt.chains {
+				mutable.FilterChains = append(mutable.FilterChains, plugin.FilterChain{ListenerProtocol: p})
+			}
+			var err error
+			if tt.inbound {
+				err = tt.plugin.OnInboundListener(tt.in, mutable)
+			} else {
+				err = tt.plugin.OnOutboundListener(tt.in, mutable)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.expected {
+				got := len(mutable.FilterChains[i].HTTP) == 1 && mutable.FilterChains[i].HTTP[0].Name == "envoy.ext_authz"
+				if got != want {
+					t.Errorf("filter chain %d: got ext_authz filter %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildFilter(t *testing.T) {
+	p := mustParse(t, `[
+		{"name": "opa", "grpc": "opa.opa.svc.cluster.local:9191", "timeout": "1s"},
+		{"name": "authz", "http": "authz.security.svc.cluster.local:8000", "pathPrefix": "/check", "failureModeAllow": true}
+	]`)
+
+	grpc := &extauthzfilter.ExtAuthz{}
+	if err := types.UnmarshalAny(buildFilter(p.providers[0], &model.Proxy{}).GetTypedConfig(), grpc); err != nil {
+		t.Fatal(err)
+	}
+	if grpc.FailureModeAllow {
+		t.Errorf("got failure mode allow for the gRPC provider, want fail closed")
+	}
+	service := grpc.GetGrpcService()
+	if cluster := service.GetEnvoyGrpc().GetClusterName(); cluster != "outbound|9191||opa.opa.svc.cluster.local" {
+		t.Errorf("got gRPC cluster %q", cluster)
+	}
+	if timeout, _ := types.DurationFromProto(service.GetTimeout()); timeout != time.Second {
+		t.Errorf("got gRPC timeout %v, want 1s", timeout)
+	}
+
+	http := &extauthzfilter.ExtAuthz{}
+	if err := types.UnmarshalAny(buildFilter(p.providers[1], &model.Proxy{}).GetTypedConfig(), http); err != nil {
+		t.Fatal(err)
+	}
+	if !http.FailureModeAllow {
+		t.Errorf("got failure mode deny for the HTTP provider, want fail open")
+	}
+	uri := http.GetHttpService().GetServerUri()
+	if cluster := uri.GetCluster(); cluster != "outbound|8000||authz.security.svc.cluster.local" {
+		t.Errorf("got HTTP cluster %q", cluster)
+	}
+	if uri.GetTimeout() == nil || *uri.GetTimeout() != defaultTimeout {
+		t.Errorf("got HTTP timeout %v, want %v", uri.GetTimeout(), defaultTimeout)
+	}
+	if prefix := http.GetHttpService().GetPathPrefix(); prefix != "/check" {
+		t.Errorf("got path prefix %q, want /check", prefix)
+	}
+}
@@ -48,6 +48,10 @@ const (
 	Health = "health"
 	// Mixer is the name of the mixer plugin passed through the command line
 	Mixer = "mixer"
+	// RateLimit is the name of the rate limit plugin passed through the command line
+	RateLimit = "ratelimit"
+	// ExtAuthz is the name of the external authorization plugin passed through the command line
+	ExtAuthz = "extauthz"
 )
 
 // ModelProtocolToListenerProtocol converts from a config.Protocol to its corresponding plugin.ListenerProtocol
@@ -146,6 +150,28 @@ type MutableObjects struct {
 	FilterChains []FilterChain
 }
 
+// AppendHTTPFilter appends the filter to the HTTP filter chains of the listener described by the input parameters.
+func AppendHTTPFilter(in *InputParams, mutable *MutableObjects, filter *http_conn.HttpFilter) {
+	switch in.ListenerProtocol {
+	case ListenerProtocolHTTP:
+		for cnum := range mutable.FilterChains {
+			mutable.FilterChains[cnum].HTTP = append(mutable.FilterChains[cnum].HTTP, filter)
+		}
+	case ListenerProtocolTCP, ListenerProtocolAuto:
+		// For gateways, due to TLS termination, a listener marked as TCP could very well
+		// be using a HTTP connection manager. So check the filterChain.listenerProtocol
+		// to decide whether the filter applies.
+		if in.ListenerProtocol == ListenerProtocolTCP && in.Node.Type != model.Router {
+			return
+		}
+		for cnum := range mutable.FilterChains {
+			if mutable.FilterChains[cnum].ListenerProtocol == ListenerProtocolHTTP {
+				mutable.FilterChains[cnum].HTTP = append(mutable.FilterChains[cnum].HTTP, filter)
+			}
+		}
+	}
+}
+
 // Plugin is called during the construction of a xdsapi.Listener which may alter the Listener in any
 // way. Examples include AuthenticationPlugin that sets up mTLS authentication on the inbound Listener
 // and outbound Cluster, the mixer plugin that sets up policy checks on the inbound listener, etc.
@@ -0,0 +1,243 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit configures Envoy global rate limiting, without a Mixer round-trip.
+//
+// When a rate limit service (RLS) is set with PILOT_RATE_LIMIT_SERVICE, the plugin adds the envoy.rate_limit
+// HTTP filter, calling the RLS through its outbound cluster, to the outbound HTTP listeners of sidecars and to
+// the HTTP listeners of gateways. The descriptors sent to the RLS are built from the rate limit actions of the
+// routes, which are set with the ratelimit.istio.io/actions annotation of a VirtualService: a JSON array of
+// Envoy route RateLimit objects applied to all the HTTP routes generated from the VirtualService, e.g.
+//
+//   metadata:
+//     annotations:
+//       ratelimit.istio.io/actions: |
+//         [{"actions": [{"request_headers": {"header_name": "x-user", "descriptor_key": "user"}}]}]
+//
+// The RLS cluster must be visible to the proxies, see the Sidecar egress hosts.
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	ratelimitfilter "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rate_limit/v2"
+	http_conn "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	ratelimitconfig "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v2"
+	"github.com/gogo/protobuf/jsonpb"
+
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/plugin"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/pkg/config/schemas"
+	istiolog "istio.io/pkg/log"
+)
+
+const (
+	// ActionsAnnotation is the VirtualService annotation holding the rate limit actions of its routes.
+	ActionsAnnotation = "ratelimit.istio.io/actions"
+
+	// filterName is the name of the Envoy HTTP rate limit filter.
+	filterName = "envoy.rate_limit"
+)
+
+var (
+	rateLimitLog = istiolog.RegisterScope("ratelimit", "rate limit debugging", 0)
+)
+
+// Plugin implements Envoy global rate limiting
+type Plugin struct {
+	// service is the host:port of the rate limit service. The plugin is disabled if empty.
+	service         string
+	domain          string
+	failureModeDeny bool
+}
+
+// NewPlugin returns an instance of the rate limit plugin, configured with the PILOT_RATE_LIMIT_* variables
+func NewPlugin() plugin.Plugin {
+	return Plugin{
+		service:         features.RateLimitService.Get(),
+		domain:          features.RateLimitDomain.Get(),
+		failureModeDeny: features.RateLimitFailureModeDeny.Get(),
+	}
+}
+
+// buildFilter returns the rate limit filter calling the rate limit service through its outbound cluster.
+func (p Plugin) buildFilter(node *model.Proxy) (*http_conn.HttpFilter, error) {
+	hostname, portStr, err := net.SplitHostPort(p.service)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate limit service %q: %v", p.service, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate limit service port %q: %v", portStr, err)
+	}
+
+	config := &ratelimitfilter.RateLimit{
+		Domain:          p.domain,
+		FailureModeDeny: p.failureModeDeny,
+		RateLimitService: &ratelimitconfig.RateLimitServiceConfig{
+			GrpcService: &core.GrpcService{
+				TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &core.GrpcService_EnvoyGrpc{
+						ClusterName: model.BuildSubsetKey(model.TrafficDirectionOutbound, "", host.Name(hostname), port),
+					},
+				},
+			},
+		},
+	}
+
+	out := &http_conn.HttpFilter{
+		Name: filterName,
+	}
+	if util.IsXDSMarshalingToAnyEnabled(node) {
+		out.ConfigType = &http_conn.HttpFilter_TypedConfig{TypedConfig: util.MessageToAny(config)}
+	} else {
+		out.ConfigType = &http_conn.HttpFilter_Config{Config: util.MessageToStruct(config)}
+	}
+	return out, nil
+}
+
+// OnOutboundListener is called whenever a new outbound listener is added to the LDS output for a given service
+// Can be used to add additional filters on the outbound path
+func (p Plugin) OnOutboundListener(in *plugin.InputParams, mutable *plugin.MutableObjects) error {
+	if p.service == "" {
+		return nil
+	}
+
+	filter, err := p.buildFilter(in.Node)
+	if err != nil {
+		return err
+	}
+	plugin.AppendHTTPFilter(in, mutable, filter)
+	return nil
+}
+
+// OnOutboundRouteConfiguration adds the rate limit actions of the VirtualServices to the routes generated from them.
+func (p Plugin) OnOutboundRouteConfiguration(in *plugin.InputParams, routeConfiguration *xdsapi.RouteConfiguration) {
+	if p.service == "" {
+		return
+	}
+
+	// Rate limits by VirtualService config path, parsed once per route configuration.
+	rateLimits := make(map[string][]*route.RateLimit)
+	for _, virtualHost := range routeConfiguration.VirtualHosts {
+		for _, r := range virtualHost.Routes {
+			action, ok := r.Action.(*route.Route_Route)
+			if !ok || action.Route == nil {
+				continue
+			}
+			configPath := routeConfigPath(r)
+			if configPath == "" {
+				continue
+			}
+			limits, ok := rateLimits[configPath]
+			if !ok {
+				limits = virtualServiceRateLimits(in.Env, configPath)
+				rateLimits[configPath] = limits
+			}
+			action.Route.RateLimits = append(action.Route.RateLimits, limits...)
+		}
+	}
+}
+
+// routeConfigPath returns the path of the config the route was generated from, set by util.BuildConfigInfoMetadata.
+func routeConfigPath(r *route.Route) string {
+	if r.Metadata == nil {
+		return ""
+	}
+	istio, ok := r.Metadata.FilterMetadata[util.IstioMetadataKey]
+	if !ok || istio == nil {
+		return ""
+	}
+	return istio.Fields["config"].GetStringValue()
+}
+
+// virtualServiceRateLimits returns the rate limits in the annotation of the VirtualService with the given
+// config path, i.e. /apis/<group>/<version>/namespaces/<namespace>/virtual-service/<name>.
+func virtualServiceRateLimits(env *model.Environment, configPath string) []*route.RateLimit {
+	parts := strings.Split(configPath, "/")
+	if len(parts) != 8 || parts[6] != schemas.VirtualService.Type {
+		return nil
+	}
+	namespace, name := parts[5], parts[7]
+
+	vs := env.IstioConfigStore.Get(schemas.VirtualService.Type, name, namespace)
+	if vs == nil || vs.Annotations[ActionsAnnotation] == "" {
+		return nil
+	}
+
+	limits, err := parseRateLimits(vs.Annotations[ActionsAnnotation])
+	if err != nil {
+		rateLimitLog.Warnf("Ignoring the %s annotation of virtual service %s/%s: %v", ActionsAnnotation, namespace, name, err)
+		return nil
+	}
+	return limits
+}
+
+// parseRateLimits parses a JSON array of Envoy route RateLimit objects.
+func parseRateLimits(value string) ([]*route.RateLimit, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, err
+	}
+
+	limits := make([]*route.RateLimit, 0, len(raw))
+	for _, r := range raw {
+		limit := &route.RateLimit{}
+		if err := jsonpb.UnmarshalString(string(r), limit); err != nil {
+			return nil, err
+		}
+		if err := limit.Validate(); err != nil {
+			return nil, err
+		}
+		limits = append(limits, limit)
+	}
+	return limits, nil
+}
+
+// OnInboundListener implements the Plugin interface method.
+func (Plugin) OnInboundListener(in *plugin.InputParams, mutable *plugin.MutableObjects) error {
+	return nil
+}
+
+// OnVirtualListener implements the Plugin interface method.
+func (Plugin) OnVirtualListener(in *plugin.InputParams, mutable *plugin.MutableObjects) error {
+	return nil
+}
+
+// OnInboundCluster implements the Plugin interface method.
+func (Plugin) OnInboundCluster(in *plugin.InputParams, cluster *xdsapi.Cluster) {
+}
+
+// OnInboundRouteConfiguration implements the Plugin interface method.
+func (Plugin) OnInboundRouteConfiguration(in *plugin.InputParams, route *xdsapi.RouteConfiguration) {
+}
+
+// OnOutboundCluster implements the Plugin interface method.
+func (Plugin) OnOutboundCluster(in *plugin.InputParams, cluster *xdsapi.Cluster) {
+}
+
+// OnInboundFilterChains is called whenever a plugin needs to setup the filter chains, including relevant filter chain configuration.
+func (Plugin) OnInboundFilterChains(in *plugin.InputParams) []plugin.FilterChain {
+	return nil
+}
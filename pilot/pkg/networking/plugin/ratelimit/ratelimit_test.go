@@ -0,0 +1,194 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"reflect"
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	ratelimitfilter "github.com/envoyproxy/go-control-plane/envoy/config/filter/http/rate_limit/v2"
+	"github.com/gogo/protobuf/types"
+
+	networking "istio.io/api/networking/v1alpha3"
+
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/plugin"
+	"istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pkg/config/schemas"
+)
+
+var testPlugin = Plugin{
+	service:         "ratelimit.istio-system.svc.cluster.local:8081",
+	domain:          "istio",
+	failureModeDeny: true,
+}
+
+func TestOnOutboundListener(t *testing.T) {
+	cases := []struct {
+		name     string
+		plugin   Plugin
+		in       *plugin.InputParams
+		chains   []plugin.ListenerProtocol
+		expected []bool
+	}{
+		{
+			name:     "disabled without rate limit service",
+			plugin:   Plugin{},
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolHTTP, Node: &model.Proxy{Type: model.SidecarProxy}},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolHTTP},
+			expected: []bool{false},
+		},
+		{
+			name:     "sidecar HTTP listener",
+			plugin:   testPlugin,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolHTTP, Node: &model.Proxy{Type: model.SidecarProxy}},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolHTTP},
+			expected: []bool{true},
+		},
+		{
+			name:     "sidecar TCP listener",
+			plugin:   testPlugin,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolTCP, Node: &model.Proxy{Type: model.SidecarProxy}},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolTCP},
+			expected: []bool{false},
+		},
+		{
+			name:     "sidecar auto listener",
+			plugin:   testPlugin,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolAuto, Node: &model.Proxy{Type: model.SidecarProxy}},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolTCP, plugin.ListenerProtocolHTTP},
+			expected: []bool{false, true},
+		},
+		{
+			name:     "gateway terminating TLS",
+			plugin:   testPlugin,
+			in:       &plugin.InputParams{ListenerProtocol: plugin.ListenerProtocolTCP, Node: &model.Proxy{Type: model.Router}},
+			chains:   []plugin.ListenerProtocol{plugin.ListenerProtocolHTTP, plugin.ListenerProtocolTCP},
+			expected: []bool{true, false},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mutable := &plugin.MutableObjects{Listener: &xdsapi.Listener{}}
+			for _, p := range tt.chains {
+				mutable.FilterChains = append(mutable.FilterChains, plugin.FilterChain{ListenerProtocol: p})
+			}
+			if err := tt.plugin.OnOutboundListener(tt.in, mutable); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.expected {
+				got := len(mutable.FilterChains[i].HTTP) == 1 && mutable.FilterChains[i].HTTP[0].Name == "envoy.rate_limit"
+				if got != want {
+					t.Errorf("filter chain %d: got rate limit filter %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildFilter(t *testing.T) {
+	filter, err := testPlugin.buildFilter(&model.Proxy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ratelimitfilter.RateLimit{}
+	if err := types.UnmarshalAny(filter.GetTypedConfig(), config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Domain != "istio" || !config.FailureModeDeny {
+		t.Errorf("got domain %q and failure mode deny %v, want istio and true", config.Domain, config.FailureModeDeny)
+	}
+	if cluster := config.RateLimitService.GetGrpcService().GetEnvoyGrpc().GetClusterName(); cluster !=
+		"outbound|8081||ratelimit.istio-system.svc.cluster.local" {
+		t.Errorf("got rate limit service cluster %q", cluster)
+	}
+
+	if _, err := (Plugin{service: "ratelimit"}).buildFilter(&model.Proxy{}); err == nil {
+		t.Errorf("expected an error for a rate limit service without port")
+	}
+}
+
+func TestOnOutboundRouteConfiguration(t *testing.T) {
+	store := memory.Make(schemas.Istio)
+	for name, annotations := range map[string]map[string]string{
+		"reviews": {ActionsAnnotation: `[{"actions": [{"request_headers": {"header_name": "x-user", "descriptor_key": "user"}}]}]`},
+		"ratings": {ActionsAnnotation: `[{"actions": [{"unknown": {}}]}]`},
+		"details": nil,
+	} {
+		if _, err := store.Create(model.Config{
+			ConfigMeta: model.ConfigMeta{
+				Type:        schemas.VirtualService.Type,
+				Group:       "networking.istio.io",
+				Version:     "v1alpha3",
+				Name:        name,
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+			Spec: &networking.VirtualService{
+				Hosts: []string{name},
+				Http: []*networking.HTTPRoute{{
+					Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: name}}},
+				}},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env := &model.Environment{IstioConfigStore: model.MakeIstioStore(store)}
+
+	routeFor := func(vs string) *route.Route {
+		return &route.Route{
+			Action: &route.Route_Route{Route: &route.RouteAction{}},
+			Metadata: util.BuildConfigInfoMetadata(model.ConfigMeta{
+				Group:     "networking.istio.io",
+				Version:   "v1alpha3",
+				Type:      schemas.VirtualService.Type,
+				Name:      vs,
+				Namespace: "default",
+			}),
+		}
+	}
+	rc := &xdsapi.RouteConfiguration{
+		VirtualHosts: []*route.VirtualHost{
+			{Routes: []*route.Route{routeFor("reviews"), routeFor("reviews")}},
+			{Routes: []*route.Route{routeFor("ratings"), routeFor("details")}},
+			{Routes: []*route.Route{{Action: &route.Route_Route{Route: &route.RouteAction{}}}}},
+		},
+	}
+
+	testPlugin.OnOutboundRouteConfiguration(&plugin.InputParams{Env: env}, rc)
+
+	want := []*route.RateLimit{{
+		Actions: []*route.RateLimit_Action{{
+			ActionSpecifier: &route.RateLimit_Action_RequestHeaders_{
+				RequestHeaders: &route.RateLimit_Action_RequestHeaders{HeaderName: "x-user", DescriptorKey: "user"},
+			},
+		}},
+	}}
+	for i, r := range rc.VirtualHosts[0].Routes {
+		if got := r.GetRoute().RateLimits; !reflect.DeepEqual(got, want) {
+			t.Errorf("reviews route %d: got rate limits %v, want %v", i, got, want)
+		}
+	}
+	for i, r := range append(rc.VirtualHosts[1].Routes, rc.VirtualHosts[2].Routes...) {
+		if got := r.GetRoute().RateLimits; len(got) != 0 {
+			t.Errorf("route %d: got rate limits %v, want none", i, got)
+		}
+	}
+}
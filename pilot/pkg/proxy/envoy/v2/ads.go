@@ -15,6 +15,7 @@
 package v2
 
 import (
+	"context"
 	"errors"
 	"io"
 	"reflect"
@@ -24,6 +25,7 @@ import (
 
 	adminapi "github.com/envoyproxy/go-control-plane/envoy/admin/v2alpha"
 	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 	"github.com/gogo/protobuf/types"
 	"google.golang.org/grpc"
@@ -110,6 +112,12 @@ type XdsConnection struct {
 	// Both ADS and EDS streams implement this interface
 	stream DiscoveryStream
 
+	// deltaStream is set instead of stream for connections using the incremental xDS protocol.
+	deltaStream DeltaDiscoveryStream
+
+	// deltaWatches holds the resources subscribed to by an incremental xDS client, keyed by type URL.
+	deltaWatches map[string]*deltaWatch
+
 	// Routes is the list of watched Routes.
 	Routes []string
 
@@ -121,6 +129,9 @@ type XdsConnection struct {
 	// added will be true if at least one discovery request was received, and the connection
 	// is added to the map of active.
 	added bool
+
+	// pushHistory keeps the last pushes for debugging, nil if disabled.
+	pushHistory *pushHistory
 }
 
 // configDump converts the connection internal state into an Envoy Admin API config dump proto
@@ -187,6 +198,10 @@ type XdsEvent struct {
 	// start represents the time a push was started.
 	start time.Time
 
+	// reason and configsUpdated describe what triggered the push, for the push history.
+	reason         []model.TriggerReason
+	configsUpdated map[string]struct{}
+
 	// function to call once a push is finished. This must be called or future changes may be blocked.
 	done func()
 }
@@ -210,6 +225,14 @@ func newXdsConnection(peerAddr string, stream DiscoveryStream) *XdsConnection {
 	}
 }
 
+// streamContext returns the context of the gRPC stream backing the connection.
+func (conn *XdsConnection) streamContext() context.Context {
+	if conn.deltaStream != nil {
+		return conn.deltaStream.Context()
+	}
+	return conn.stream.Context()
+}
+
 func receiveThread(con *XdsConnection, reqChannel chan *xdsapi.DiscoveryRequest, errP *error) {
 	defer close(reqChannel) // indicates close of the remote side.
 	for {
@@ -257,6 +280,7 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscove
 		return err
 	}
 	con := newXdsConnection(peerAddr, stream)
+	con.pushHistory = newPushHistory(s.PushHistorySize)
 
 	// Do not call: defer close(con.pushChannel) !
 	// the push channel will be garbage collected when the connection is no longer used.
@@ -280,7 +304,7 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscove
 				// Remote side closed connection.
 				return receiveError
 			}
-			err = s.initConnectionNode(discReq, con)
+			err = s.initConnectionNode(discReq.Node, con)
 			if err != nil {
 				return err
 			}
@@ -480,7 +504,7 @@ func (s *DiscoveryServer) StreamAggregatedResources(stream ads.AggregatedDiscove
 }
 
 // update the node associated with the connection, after receiving a a packet from envoy.
-func (s *DiscoveryServer) initConnectionNode(discReq *xdsapi.DiscoveryRequest, con *XdsConnection) error {
+func (s *DiscoveryServer) initConnectionNode(node *core.Node, con *XdsConnection) error {
 	con.mu.RLock() // may not be needed - once per connection, but locking for consistency.
 	if con.modelNode != nil {
 		con.mu.RUnlock()
@@ -488,10 +512,10 @@ func (s *DiscoveryServer) initConnectionNode(discReq *xdsapi.DiscoveryRequest, c
 	}
 	con.mu.RUnlock()
 
-	if discReq.Node == nil || discReq.Node.Id == "" {
+	if node == nil || node.Id == "" {
 		return errors.New("missing node id")
 	}
-	nt, err := model.ParseServiceNodeWithMetadata(discReq.Node.Id, model.ParseMetadata(discReq.Node.Metadata))
+	nt, err := model.ParseServiceNodeWithMetadata(node.Id, model.ParseMetadata(node.Metadata))
 	if err != nil {
 		return err
 	}
@@ -512,7 +536,7 @@ func (s *DiscoveryServer) initConnectionNode(discReq *xdsapi.DiscoveryRequest, c
 	// This is not preferable as only the connected Pilot is aware of this proxies location, but it
 	// can still help provide some client-side Envoy context when load balancing based on location.
 	if util.IsLocalityEmpty(nt.Locality) {
-		nt.Locality = discReq.Node.Locality
+		nt.Locality = node.Locality
 	}
 
 	if err := nt.SetWorkloadLabels(s.Env, false); err != nil {
@@ -527,18 +551,13 @@ func (s *DiscoveryServer) initConnectionNode(discReq *xdsapi.DiscoveryRequest, c
 	con.modelNode = nt
 	if con.ConID == "" {
 		// first request
-		con.ConID = connectionID(discReq.Node.Id)
+		con.ConID = connectionID(node.Id)
 	}
 	con.mu.Unlock()
 
 	return nil
 }
 
-// DeltaAggregatedResources is not implemented.
-func (s *DiscoveryServer) DeltaAggregatedResources(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
-	return status.Errorf(codes.Unimplemented, "not implemented")
-}
-
 // Compute and send the new configuration for a connection. This is blocking and may be slow
 // for large configs. The method will hold a lock on con.pushMutex.
 func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) error {
@@ -549,6 +568,9 @@ func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) e
 			adsLog.Debugf("Skipping EDS push to %v, no updates required", con.ConID)
 			return nil
 		}
+		con.pushHistory.begin(pushEv)
+		defer con.pushHistory.end()
+
 		// Push only EDS. This is indexed already - push immediately
 		// (may need a throttle)
 		if len(con.Clusters) > 0 {
@@ -559,28 +581,10 @@ func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) e
 		return nil
 	}
 
-	if err := con.modelNode.SetWorkloadLabels(s.Env, false); err != nil {
+	if err := s.updateProxy(con, pushEv.push); err != nil {
 		return err
 	}
 
-	if err := con.modelNode.SetServiceInstances(pushEv.push.Env); err != nil {
-		return err
-	}
-	if util.IsLocalityEmpty(con.modelNode.Locality) {
-		// Get the locality from the proxy's service instances.
-		// We expect all instances to have the same locality. So its enough to look at the first instance
-		if len(con.modelNode.ServiceInstances) > 0 {
-			con.modelNode.Locality = util.ConvertLocality(con.modelNode.ServiceInstances[0].GetLocality())
-		}
-	}
-
-	// Precompute the sidecar scope and merged gateways associated with this proxy.
-	// Saves compute cycles in networking code. Though this might be redundant sometimes, we still
-	// have to compute this because as part of a config change, a new Sidecar could become
-	// applicable to this proxy
-	con.modelNode.SetSidecarScope(pushEv.push)
-	con.modelNode.SetGatewaysForProxy(pushEv.push)
-
 	// This depends on SidecarScope updates, so it should be called after SetSidecarScope.
 	if !proxyNeedsPush(con, pushEv.targetNamespaces) {
 		adsLog.Debugf("Skipping push to %v, no updates required", con.ConID)
@@ -589,6 +593,9 @@ func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) e
 
 	adsLog.Infof("Pushing %v", con.ConID)
 
+	con.pushHistory.begin(pushEv)
+	defer con.pushHistory.end()
+
 	// check version, suppress if changed.
 	currentVersion := versionInfo()
 
@@ -625,6 +632,32 @@ func (s *DiscoveryServer) pushConnection(con *XdsConnection, pushEv *XdsEvent) e
 	return nil
 }
 
+// updateProxy refreshes the proxy attributes that may have changed since the last push, ahead of a full push.
+func (s *DiscoveryServer) updateProxy(con *XdsConnection, push *model.PushContext) error {
+	if err := con.modelNode.SetWorkloadLabels(s.Env, false); err != nil {
+		return err
+	}
+
+	if err := con.modelNode.SetServiceInstances(push.Env); err != nil {
+		return err
+	}
+	if util.IsLocalityEmpty(con.modelNode.Locality) {
+		// Get the locality from the proxy's service instances.
+		// We expect all instances to have the same locality. So its enough to look at the first instance
+		if len(con.modelNode.ServiceInstances) > 0 {
+			con.modelNode.Locality = util.ConvertLocality(con.modelNode.ServiceInstances[0].GetLocality())
+		}
+	}
+
+	// Precompute the sidecar scope and merged gateways associated with this proxy.
+	// Saves compute cycles in networking code. Though this might be redundant sometimes, we still
+	// have to compute this because as part of a config change, a new Sidecar could become
+	// applicable to this proxy
+	con.modelNode.SetSidecarScope(push)
+	con.modelNode.SetGatewaysForProxy(push)
+	return nil
+}
+
 func adsClientCount() int {
 	var n int
 	adsClientsMutex.RLock()
@@ -772,18 +805,7 @@ func (conn *XdsConnection) send(res *xdsapi.DiscoveryResponse) error {
 		err := conn.stream.Send(res)
 		done <- err
 		conn.mu.Lock()
-		if res.Nonce != "" {
-			switch res.TypeUrl {
-			case ClusterType:
-				conn.ClusterNonceSent = res.Nonce
-			case ListenerType:
-				conn.ListenerNonceSent = res.Nonce
-			case RouteType:
-				conn.RouteNonceSent = res.Nonce
-			case EndpointType:
-				conn.EndpointNonceSent = res.Nonce
-			}
-		}
+		conn.setNonceSent(res.TypeUrl, res.Nonce)
 		if res.TypeUrl == RouteType {
 			conn.RouteVersionInfoSent = res.VersionInfo
 		}
@@ -800,3 +822,20 @@ func (conn *XdsConnection) send(res *xdsapi.DiscoveryResponse) error {
 		return err
 	}
 }
+
+// setNonceSent records the last nonce sent for the given type, for debugging. Must be called with conn.mu held.
+func (conn *XdsConnection) setNonceSent(typeURL, nonce string) {
+	if nonce == "" {
+		return
+	}
+	switch typeURL {
+	case ClusterType:
+		conn.ClusterNonceSent = nonce
+	case ListenerType:
+		conn.ListenerNonceSent = nonce
+	case RouteType:
+		conn.RouteNonceSent = nonce
+	case EndpointType:
+		conn.EndpointNonceSent = nonce
+	}
+}
@@ -60,6 +60,7 @@ func (s *DiscoveryServer) pushCds(con *XdsConnection, push *model.PushContext, v
 		return err
 	}
 	cdsPushes.Increment()
+	con.pushHistory.recordClusters(rawClusters)
 
 	// The response can't be easily read due to 'any' marshaling.
 	adsLog.Infof("CDS: PUSH for node:%s clusters:%d services:%d version:%s",
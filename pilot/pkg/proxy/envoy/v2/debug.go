@@ -64,6 +64,8 @@ func (s *DiscoveryServer) InitDebug(mux *http.ServeMux, sctl *aggregate.Controll
 	mux.HandleFunc("/debug/authenticationz", s.authenticationz)
 	mux.HandleFunc("/debug/config_dump", s.ConfigDump)
 	mux.HandleFunc("/debug/push_status", s.PushStatusHandler)
+	mux.HandleFunc("/debug/route_simulate", s.routeSimulate)
+	mux.HandleFunc("/debug/push_history", s.pushHistoryz)
 }
 
 // SyncStatus is the synchronization status between Pilot and a given Envoy
@@ -0,0 +1,539 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/pkg/monitoring"
+)
+
+// DeltaDiscoveryStream is the stream used by the incremental variant of ADS.
+type DeltaDiscoveryStream interface {
+	Send(*xdsapi.DeltaDiscoveryResponse) error
+	Recv() (*xdsapi.DeltaDiscoveryRequest, error)
+	grpc.ServerStream
+}
+
+// deltaWatch tracks the resources of a single type an incremental xDS client subscribed to,
+// and the version of each resource the client is known to have.
+type deltaWatch struct {
+	// wildcard is set if the client subscribed to all resources of the type. Only CDS and LDS
+	// support wildcard subscriptions.
+	wildcard bool
+
+	// subscribed is the set of explicitly subscribed resource names.
+	subscribed map[string]struct{}
+
+	// versions holds the version of the resources last sent to (or reported by) the client.
+	versions map[string]string
+
+	// encodings caches the version computed for the last binary encoding of each resource.
+	encodings map[string]encodedVersion
+}
+
+// encodedVersion is the version of a resource along with the hash of its binary encoding.
+type encodedVersion struct {
+	sum     [sha256.Size]byte
+	version string
+}
+
+func newDeltaWatch() *deltaWatch {
+	return &deltaWatch{
+		subscribed: map[string]struct{}{},
+		versions:   map[string]string{},
+		encodings:  map[string]encodedVersion{},
+	}
+}
+
+func (w *deltaWatch) watches(name string) bool {
+	if w.wildcard {
+		return true
+	}
+	_, ok := w.subscribed[name]
+	return ok
+}
+
+// version returns the version of the resource. The binary encoding is already computed to send the
+// resource, so the version is only recomputed when it changed since the previous push. An encoding
+// that differs for the same content only costs a cache miss.
+func (w *deltaWatch) version(name string, msg proto.Message, res *types.Any) (string, error) {
+	sum := sha256.Sum256(res.Value)
+	if e, ok := w.encodings[name]; ok && e.sum == sum {
+		return e.version, nil
+	}
+	version, err := resourceVersion(msg)
+	if err != nil {
+		return "", err
+	}
+	w.encodings[name] = encodedVersion{sum: sum, version: version}
+	return version, nil
+}
+
+// subscribedNames returns the sorted list of explicitly subscribed resource names.
+func (w *deltaWatch) subscribedNames() []string {
+	names := make([]string, 0, len(w.subscribed))
+	for name := range w.subscribed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newDeltaXdsConnection(peerAddr string, stream DeltaDiscoveryStream) *XdsConnection {
+	return &XdsConnection{
+		pushChannel:   make(chan *XdsEvent),
+		updateChannel: make(chan *UpdateEvent, 1),
+		PeerAddr:      peerAddr,
+		Clusters:      []string{},
+		Connect:       time.Now(),
+		deltaStream:   stream,
+		deltaWatches:  map[string]*deltaWatch{},
+		LDSListeners:  []*xdsapi.Listener{},
+		RouteConfigs:  map[string]*xdsapi.RouteConfiguration{},
+	}
+}
+
+func deltaReceiveThread(con *XdsConnection, reqChannel chan *xdsapi.DeltaDiscoveryRequest, errP *error) {
+	defer close(reqChannel) // indicates close of the remote side.
+	for {
+		req, err := con.deltaStream.Recv()
+		if err != nil {
+			if status.Code(err) == codes.Canceled || err == io.EOF {
+				con.mu.RLock()
+				adsLog.Infof("ADS: %q %s terminated %v", con.PeerAddr, con.ConID, err)
+				con.mu.RUnlock()
+				return
+			}
+			*errP = err
+			adsLog.Errorf("ADS: %q %s terminated with error: %v", con.PeerAddr, con.ConID, err)
+			totalXDSInternalErrors.Increment()
+			return
+		}
+		select {
+		case reqChannel <- req:
+		case <-con.deltaStream.Context().Done():
+			adsLog.Errorf("ADS: %q %s terminated with stream closed", con.PeerAddr, con.ConID)
+			return
+		}
+	}
+}
+
+// DeltaAggregatedResources implements the incremental variant of the ADS interface. Pilot keeps
+// track of the resources each client subscribed to and of the versions it sent, and only sends
+// the resources that were added or changed, along with the names of the removed ones.
+func (s *DiscoveryServer) DeltaAggregatedResources(stream ads.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	peerInfo, ok := peer.FromContext(stream.Context())
+	peerAddr := "0.0.0.0"
+	if ok {
+		peerAddr = peerInfo.Addr.String()
+	}
+
+	// InitContext returns immediately if the context was already initialized.
+	err := s.globalPushContext().InitContext(s.Env)
+	if err != nil {
+		adsLog.Warnf("Error reading config %v", err)
+		return err
+	}
+	con := newDeltaXdsConnection(peerAddr, stream)
+	con.pushHistory = newPushHistory(s.PushHistorySize)
+
+	var receiveError error
+	reqChannel := make(chan *xdsapi.DeltaDiscoveryRequest, 1)
+	go deltaReceiveThread(con, reqChannel, &receiveError)
+
+	for {
+		select {
+		case req, ok := <-reqChannel:
+			if !ok {
+				// Remote side closed connection.
+				return receiveError
+			}
+			err = s.initConnectionNode(req.Node, con)
+			if err != nil {
+				return err
+			}
+
+			// Add the connection before handling the request, so the EDS registrations made by
+			// handleDeltaRequest are always undone by removeCon.
+			con.mu.Lock()
+			if !con.added {
+				con.added = true
+				con.mu.Unlock()
+				s.addCon(con.ConID, con)
+				defer s.removeCon(con.ConID, con)
+			} else {
+				con.mu.Unlock()
+			}
+
+			if err := s.handleDeltaRequest(con, req); err != nil {
+				return err
+			}
+		case updateEv := <-con.updateChannel:
+			if updateEv.workloadLabel && con.modelNode != nil {
+				_ = con.modelNode.SetWorkloadLabels(s.Env, true)
+			}
+		case pushEv := <-con.pushChannel:
+			err := s.pushDeltaConnection(con, pushEv)
+			pushEv.done()
+			if err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// handleDeltaRequest updates the subscriptions of the connection and responds to the request.
+func (s *DiscoveryServer) handleDeltaRequest(con *XdsConnection, req *xdsapi.DeltaDiscoveryRequest) error {
+	_, _, reject := xdsMetrics(req.TypeUrl)
+	if reject == nil {
+		adsLog.Warnf("ADS: Unknown watched resources %s", req.String())
+		return nil
+	}
+
+	w := con.deltaWatches[req.TypeUrl]
+	initial := w == nil
+	if req.ErrorDetail != nil {
+		adsLog.Warnf("ADS: delta NACK %v %s (%s) %v", con.PeerAddr, con.ConID, con.modelNode.ID, req.String())
+		errCode := codes.Code(req.ErrorDetail.Code)
+		incrementXDSRejects(reject, con.modelNode.ID, errCode.String())
+		if w != nil {
+			// The client kept its previous resources, so what it has is unknown. Forget the versions
+			// to send all the resources again on the next push.
+			w.versions = map[string]string{}
+		}
+		return nil
+	}
+
+	if w != nil && req.ResponseNonce != "" {
+		con.mu.Lock()
+		con.setNonceAcked(req.TypeUrl, req.ResponseNonce)
+		con.mu.Unlock()
+		if len(req.ResourceNamesSubscribe) == 0 && len(req.ResourceNamesUnsubscribe) == 0 {
+			adsLog.Debugf("ADS: delta ACK %s %s (%s) %s %s", con.PeerAddr, con.ConID, con.modelNode.ID,
+				req.TypeUrl, req.ResponseNonce)
+			return nil
+		}
+	}
+
+	if w == nil {
+		w = newDeltaWatch()
+		// An empty initial subscription is a wildcard subscription for the types that support it.
+		w.wildcard = len(req.ResourceNamesSubscribe) == 0 &&
+			(req.TypeUrl == ClusterType || req.TypeUrl == ListenerType)
+		for name, version := range req.InitialResourceVersions {
+			w.versions[name] = version
+		}
+		con.deltaWatches[req.TypeUrl] = w
+	}
+	for _, name := range req.ResourceNamesSubscribe {
+		w.subscribed[name] = struct{}{}
+	}
+	for _, name := range req.ResourceNamesUnsubscribe {
+		delete(w.subscribed, name)
+		delete(w.versions, name)
+		delete(w.encodings, name)
+	}
+
+	switch req.TypeUrl {
+	case ClusterType:
+		con.CDSWatch = true
+	case ListenerType:
+		con.LDSWatch = true
+	case RouteType:
+		con.Routes = w.subscribedNames()
+	case EndpointType:
+		for _, cn := range con.Clusters {
+			s.removeEdsCon(cn, con.ConID)
+		}
+		clusters := w.subscribedNames()
+		for _, cn := range clusters {
+			s.addEdsCon(cn, con.ConID, con)
+		}
+		con.Clusters = clusters
+	}
+
+	adsLog.Debugf("ADS: delta REQ %s %s %s subscribed:%d unsubscribed:%d", con.PeerAddr, con.ConID, req.TypeUrl,
+		len(req.ResourceNamesSubscribe), len(req.ResourceNamesUnsubscribe))
+	// Always respond to a subscription, even without changes, so the client is not left waiting.
+	// The initial request considers all the resources, so that the ones the client reported and
+	// that no longer exist are removed. Later requests only need the newly subscribed resources.
+	var names []string
+	if !initial {
+		names = append([]string{}, req.ResourceNamesSubscribe...)
+	}
+	return s.pushDelta(con, s.globalPushContext(), req.TypeUrl, nil, names, true)
+}
+
+// pushDeltaConnection is the equivalent of pushConnection for incremental xDS connections.
+func (s *DiscoveryServer) pushDeltaConnection(con *XdsConnection, pushEv *XdsEvent) error {
+	if pushEv.edsUpdatedServices != nil {
+		if !proxyNeedsPush(con, pushEv.targetNamespaces) {
+			adsLog.Debugf("Skipping EDS push to %v, no updates required", con.ConID)
+			return nil
+		}
+		con.pushHistory.begin(pushEv)
+		defer con.pushHistory.end()
+		return s.pushDelta(con, pushEv.push, EndpointType, pushEv.edsUpdatedServices, nil, false)
+	}
+
+	if err := s.updateProxy(con, pushEv.push); err != nil {
+		return err
+	}
+
+	// This depends on SidecarScope updates, so it should be called after SetSidecarScope.
+	if !proxyNeedsPush(con, pushEv.targetNamespaces) {
+		adsLog.Debugf("Skipping push to %v, no updates required", con.ConID)
+		return nil
+	}
+
+	adsLog.Infof("Pushing %v", con.ConID)
+
+	con.pushHistory.begin(pushEv)
+	defer con.pushHistory.end()
+
+	for _, typeURL := range []string{ClusterType, EndpointType, ListenerType, RouteType} {
+		if con.deltaWatches[typeURL] == nil {
+			continue
+		}
+		if err := s.pushDelta(con, pushEv.push, typeURL, nil, nil, false); err != nil {
+			switch typeURL {
+			case ClusterType:
+				proxiesConvergeDelayCdsErrors.Record(time.Since(pushEv.start).Seconds())
+			case EndpointType:
+				proxiesConvergeDelayEdsErrors.Record(time.Since(pushEv.start).Seconds())
+			case ListenerType:
+				proxiesConvergeDelayLdsErrors.Record(time.Since(pushEv.start).Seconds())
+			case RouteType:
+				proxiesConvergeDelayRdsErrors.Record(time.Since(pushEv.start).Seconds())
+			}
+			return err
+		}
+	}
+	proxiesConvergeDelay.Record(time.Since(pushEv.start).Seconds())
+	return nil
+}
+
+// pushDelta sends the resources of the given type that changed since the last response, along
+// with the names of the resources that no longer exist. If edsUpdatedServices is set, only the
+// endpoints of those services are considered, and if names is not nil, only the named resources
+// are; in both cases no resource is removed. Unless force is set, nothing is sent when there are
+// no changes.
+func (s *DiscoveryServer) pushDelta(con *XdsConnection, push *model.PushContext, typeURL string,
+	edsUpdatedServices map[string]struct{}, names []string, force bool) error {
+	w := con.deltaWatches[typeURL]
+	if w == nil {
+		return nil
+	}
+	partial := edsUpdatedServices != nil || names != nil
+
+	// Only the resources in scope of the push are encoded and versioned.
+	inScope := w.watches
+	if names != nil {
+		scope := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			scope[name] = struct{}{}
+		}
+		inScope = func(name string) bool {
+			_, ok := scope[name]
+			return ok && w.watches(name)
+		}
+	}
+	var resources []*xdsapi.Resource
+	if names == nil || len(names) > 0 {
+		resources = s.generateDeltaResources(con, push, w, typeURL, edsUpdatedServices, inScope)
+	}
+
+	response := &xdsapi.DeltaDiscoveryResponse{
+		TypeUrl:           typeURL,
+		SystemVersionInfo: versionInfo(),
+		Nonce:             nonce(),
+	}
+	current := make(map[string]string, len(resources))
+	for _, r := range resources {
+		current[r.Name] = r.Version
+		if w.versions[r.Name] != r.Version {
+			response.Resources = append(response.Resources, r)
+		}
+	}
+	if !partial {
+		for name := range w.versions {
+			if _, ok := current[name]; !ok {
+				response.RemovedResources = append(response.RemovedResources, name)
+			}
+		}
+		sort.Strings(response.RemovedResources)
+	}
+
+	if !force && len(response.Resources) == 0 && len(response.RemovedResources) == 0 {
+		adsLog.Debugf("ADS: delta no changes for node:%s %s", con.modelNode.ID, typeURL)
+		return nil
+	}
+
+	pushMetric, sendErrMetric, _ := xdsMetrics(typeURL)
+	if err := con.sendDelta(response); err != nil {
+		adsLog.Warnf("ADS: delta send failure %s %s: %v", con.ConID, typeURL, err)
+		recordSendError(sendErrMetric, err)
+		return err
+	}
+	pushMetric.Increment()
+	if !partial {
+		con.pushHistory.recordDelta(typeURL, resources)
+	}
+
+	for _, r := range response.Resources {
+		w.versions[r.Name] = r.Version
+	}
+	for _, name := range response.RemovedResources {
+		delete(w.versions, name)
+		delete(w.encodings, name)
+	}
+
+	adsLog.Infof("ADS: delta PUSH for node:%s %s updated:%d removed:%d", con.modelNode.ID, typeURL,
+		len(response.Resources), len(response.RemovedResources))
+	return nil
+}
+
+// generateDeltaResources builds the resources of the given type for the connection, and encodes
+// the ones in scope.
+func (s *DiscoveryServer) generateDeltaResources(con *XdsConnection, push *model.PushContext, w *deltaWatch,
+	typeURL string, edsUpdatedServices map[string]struct{}, inScope func(string) bool) []*xdsapi.Resource {
+	var resources []*xdsapi.Resource
+	add := func(name string, msg proto.Message) {
+		if !inScope(name) {
+			return
+		}
+		res, err := types.MarshalAny(msg)
+		if err != nil {
+			adsLog.Errorf("ADS: failed to marshal %s %s: %v", typeURL, name, err)
+			totalXDSInternalErrors.Increment()
+			return
+		}
+		version, err := w.version(name, msg, res)
+		if err != nil {
+			adsLog.Errorf("ADS: failed to compute the version of %s %s: %v", typeURL, name, err)
+			totalXDSInternalErrors.Increment()
+			return
+		}
+		resources = append(resources, &xdsapi.Resource{
+			Name:     name,
+			Version:  version,
+			Resource: res,
+		})
+	}
+
+	switch typeURL {
+	case ClusterType:
+		for _, c := range s.generateRawClusters(con.modelNode, push) {
+			add(c.Name, c)
+		}
+	case ListenerType:
+		for _, l := range s.generateRawListeners(con, push) {
+			add(l.Name, l)
+		}
+	case RouteType:
+		for _, r := range s.generateRawRoutes(con, push) {
+			add(r.Name, r)
+		}
+	case EndpointType:
+		loadAssignments, _, _ := s.generateEndpoints(push, con, edsUpdatedServices)
+		for _, l := range loadAssignments {
+			add(l.ClusterName, l)
+		}
+	}
+	return resources
+}
+
+// resourceVersion returns a version derived from the content of the resource, so that unchanged
+// resources are not sent again. The binary encoding of map fields, such as the ones in
+// types.Struct, varies between calls and the gogo generated marshalers can't make it
+// deterministic, so the JSON encoding is hashed instead: it sorts map keys, including the ones
+// of the messages packed in Any fields.
+func resourceVersion(msg proto.Message) (string, error) {
+	h := sha256.New()
+	if err := (&jsonpb.Marshaler{}).Marshal(h, msg); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// xdsMetrics returns the push, send error and reject metrics of the given type, or nils if the
+// type is not supported.
+func xdsMetrics(typeURL string) (monitoring.Metric, monitoring.Metric, monitoring.Metric) {
+	switch typeURL {
+	case ClusterType:
+		return cdsPushes, cdsSendErrPushes, cdsReject
+	case ListenerType:
+		return ldsPushes, ldsSendErrPushes, ldsReject
+	case RouteType:
+		return rdsPushes, rdsSendErrPushes, rdsReject
+	case EndpointType:
+		return edsPushes, edsSendErrPushes, edsReject
+	default:
+		return nil, nil, nil
+	}
+}
+
+// setNonceAcked records the last nonce acked for the given type, for debugging. Must be called with conn.mu held.
+func (conn *XdsConnection) setNonceAcked(typeURL, nonce string) {
+	switch typeURL {
+	case ClusterType:
+		conn.ClusterNonceAcked = nonce
+	case ListenerType:
+		conn.ListenerNonceAcked = nonce
+	case RouteType:
+		conn.RouteNonceAcked = nonce
+	case EndpointType:
+		conn.EndpointNonceAcked = nonce
+	}
+}
+
+// sendDelta is the incremental xDS equivalent of send.
+func (conn *XdsConnection) sendDelta(res *xdsapi.DeltaDiscoveryResponse) error {
+	done := make(chan error, 1)
+	t := time.NewTimer(SendTimeout)
+	go func() {
+		err := conn.deltaStream.Send(res)
+		done <- err
+		conn.mu.Lock()
+		conn.setNonceSent(res.TypeUrl, res.Nonce)
+		conn.mu.Unlock()
+	}()
+	select {
+	case <-t.C:
+		adsLog.Infof("Timeout writing %s", conn.ConID)
+		xdsResponseWriteTimeouts.Increment()
+		return errors.New("timeout sending")
+	case err := <-done:
+		t.Stop()
+		return err
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package v2_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	"google.golang.org/grpc"
+
+	"istio.io/istio/pilot/pkg/model"
+	v2 "istio.io/istio/pilot/pkg/proxy/envoy/v2"
+	"istio.io/istio/pkg/config/host"
+	"istio.io/istio/tests/util"
+)
+
+func connectDeltaADS(url string) (ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient, util.TearDownFunc, error) {
+	conn, err := grpc.Dial(url, grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		return nil, nil, fmt.Errorf("GRPC dial failed: %s", err)
+	}
+	xds := ads.NewAggregatedDiscoveryServiceClient(conn)
+	deltastr, err := xds.DeltaAggregatedResources(context.Background())
+	if err != nil {
+		return nil, nil, fmt.Errorf("delta stream resources failed: %s", err)
+	}
+
+	return deltastr, func() {
+		_ = deltastr.CloseSend()
+		_ = conn.Close()
+	}, nil
+}
+
+func deltaReceive(ads ads.AggregatedDiscoveryService_DeltaAggregatedResourcesClient,
+	to time.Duration) (*xdsapi.DeltaDiscoveryResponse, error) {
+	done := make(chan int, 1)
+	t := time.NewTimer(to)
+	defer func() {
+		done <- 1
+	}()
+	go func() {
+		select {
+		case <-t.C:
+			_ = ads.CloseSend() // will result in Recv closing as well, interrupting the blocking recv
+		case <-done:
+			_ = t.Stop()
+		}
+	}()
+	return ads.Recv()
+}
+
+func TestDeltaAds(t *testing.T) {
+	server, tearDown := initLocalPilotTestEnv(t)
+	defer tearDown()
+
+	deltastr, cancel, err := connectDeltaADS(util.MockPilotGrpcAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	node := &core.Node{
+		Id:       sidecarID("1.1.1.1", "app3"),
+		Metadata: nodeMetadata,
+	}
+
+	// An empty initial subscription is a wildcard subscription for clusters.
+	if err := deltastr.Send(&xdsapi.DeltaDiscoveryRequest{Node: node, TypeUrl: v2.ClusterType}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := deltaReceive(deltastr, 5*time.Second)
+	if err != nil {
+		t.Fatal("Recv failed", err)
+	}
+	if res.TypeUrl != v2.ClusterType {
+		t.Fatalf("Expecting %s got %s", v2.ClusterType, res.TypeUrl)
+	}
+	if len(res.Resources) == 0 {
+		t.Fatal("Expecting clusters in the initial response")
+	}
+	if len(res.RemovedResources) != 0 {
+		t.Errorf("Expecting no removed clusters, got %v", res.RemovedResources)
+	}
+	for _, r := range res.Resources {
+		if r.Name == "" || r.Version == "" {
+			t.Errorf("Expecting resources with a name and a version, got %q %q", r.Name, r.Version)
+		}
+	}
+	if err := deltastr.Send(&xdsapi.DeltaDiscoveryRequest{TypeUrl: v2.ClusterType, ResponseNonce: res.Nonce}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the subscribed endpoints are sent.
+	cluster := "outbound|80||adsclusterupdate.default.svc.cluster.local"
+	if err := deltastr.Send(&xdsapi.DeltaDiscoveryRequest{
+		TypeUrl:                v2.EndpointType,
+		ResourceNamesSubscribe: []string{cluster},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	res, err = deltaReceive(deltastr, 5*time.Second)
+	if err != nil {
+		t.Fatal("Recv failed", err)
+	}
+	if res.TypeUrl != v2.EndpointType {
+		t.Fatalf("Expecting %s got %s", v2.EndpointType, res.TypeUrl)
+	}
+	if len(res.Resources) != 1 || res.Resources[0].Name != cluster {
+		t.Fatalf("Expecting only %s, got %v", cluster, res.Resources)
+	}
+	if err := deltastr.Send(&xdsapi.DeltaDiscoveryRequest{TypeUrl: v2.EndpointType, ResponseNonce: res.Nonce}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing changed, so a full push must not send anything.
+	v2.AdsPushAll(server.EnvoyXdsServer)
+
+	res, err = deltaReceive(deltastr, 3*time.Second)
+	if err == nil {
+		t.Fatalf("Expecting no response for a push without changes, got %v", res)
+	}
+}
+
+func TestDeltaAdsRemoval(t *testing.T) {
+	server, tearDown := initLocalPilotTestEnv(t)
+	defer tearDown()
+
+	hostname := host.Name("deltaremoval.default.svc.cluster.local")
+	cluster := "outbound|80||deltaremoval.default.svc.cluster.local"
+	server.EnvoyXdsServer.MemRegistry.AddService(hostname, &model.Service{
+		Hostname: hostname,
+		Address:  "10.11.0.2",
+		Ports:    testPorts(0),
+	})
+	server.EnvoyXdsServer.ClearCache()
+	time.Sleep(time.Millisecond * 200)
+
+	deltastr, cancel, err := connectDeltaADS(util.MockPilotGrpcAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	node := &core.Node{
+		Id:       sidecarID("1.1.1.1", "app3"),
+		Metadata: nodeMetadata,
+	}
+	if err := deltastr.Send(&xdsapi.DeltaDiscoveryRequest{Node: node, TypeUrl: v2.ClusterType}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := deltaReceive(deltastr, 5*time.Second)
+	if err != nil {
+		t.Fatal("Recv failed", err)
+	}
+	if !hasDeltaResource(res, cluster) {
+		t.Fatalf("Expecting %s in the initial response", cluster)
+	}
+	if err := deltastr.Send(&xdsapi.DeltaDiscoveryRequest{TypeUrl: v2.ClusterType, ResponseNonce: res.Nonce}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the name of the cluster of the removed service is sent.
+	server.EnvoyXdsServer.MemRegistry.RemoveService(hostname)
+	server.EnvoyXdsServer.ClearCache()
+
+	res, err = deltaReceive(deltastr, 5*time.Second)
+	if err != nil {
+		t.Fatal("Recv failed", err)
+	}
+	if len(res.Resources) != 0 {
+		t.Errorf("Expecting no updated clusters, got %v", res.Resources)
+	}
+	removed := false
+	for _, name := range res.RemovedResources {
+		if name == cluster {
+			removed = true
+		}
+	}
+	if !removed {
+		t.Errorf("Expecting %s to be removed, got %v", cluster, res.RemovedResources)
+	}
+}
+
+func TestDeltaAdsUnsubscribe(t *testing.T) {
+	_, tearDown := initLocalPilotTestEnv(t)
+	defer tearDown()
+
+	deltastr, cancel, err := connectDeltaADS(util.MockPilotGrpcAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	node := &core.Node{
+		Id:       sidecarID("1.1.1.1", "app3"),
+		Metadata: nodeMetadata,
+	}
+	cluster1 := "outbound|80||adsclusterupdate.default.svc.cluster.local"
+	cluster2 := "outbound|80||adsclusterupdate2.default.svc.cluster.local"
+	if err := deltastr.Send(&xdsapi.DeltaDiscoveryRequest{
+		Node:                   node,
+		TypeUrl:                v2.EndpointType,
+		ResourceNamesSubscribe: []string{cluster1, cluster2},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := deltaReceive(deltastr, 5*time.Second)
+	if err != nil {
+		t.Fatal("Recv failed", err)
+	}
+	if len(res.Resources) != 2 || !hasDeltaResource(res, cluster1) || !hasDeltaResource(res, cluster2) {
+		t.Fatalf("Expecting %s and %s, got %v", cluster1, cluster2, res.Resources)
+	}
+
+	// Unsubscribing is answered with an empty response: the unsubscribed resource is not reported
+	// as removed, and nothing else is sent.
+	if err := deltastr.Send(&xdsapi.DeltaDiscoveryRequest{
+		TypeUrl:                  v2.EndpointType,
+		ResponseNonce:            res.Nonce,
+		ResourceNamesUnsubscribe: []string{cluster2},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	res, err = deltaReceive(deltastr, 5*time.Second)
+	if err != nil {
+		t.Fatal("Recv failed", err)
+	}
+	if len(res.Resources) != 0 || len(res.RemovedResources) != 0 {
+		t.Fatalf("Expecting an empty response, got %v", res)
+	}
+
+	// The server forgot the version it sent, so subscribing again sends the resource again.
+	if err := deltastr.Send(&xdsapi.DeltaDiscoveryRequest{
+		TypeUrl:                v2.EndpointType,
+		ResponseNonce:          res.Nonce,
+		ResourceNamesSubscribe: []string{cluster2},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	res, err = deltaReceive(deltastr, 5*time.Second)
+	if err != nil {
+		t.Fatal("Recv failed", err)
+	}
+	if len(res.Resources) != 1 || res.Resources[0].Name != cluster2 {
+		t.Fatalf("Expecting only %s, got %v", cluster2, res.Resources)
+	}
+}
+
+func hasDeltaResource(res *xdsapi.DeltaDiscoveryResponse, name string) bool {
+	for _, r := range res.Resources {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
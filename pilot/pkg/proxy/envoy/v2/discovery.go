@@ -99,6 +99,10 @@ type DiscoveryServer struct {
 	// Defaults to false, can be enabled with PILOT_DEBUG_ADSZ_CONFIG=1
 	DebugConfigs bool
 
+	// PushHistorySize is the number of pushes kept per connection for /debug/push_history.
+	// Defaults to 0, disabling the history, can be set with PILOT_PUSH_HISTORY_SIZE.
+	PushHistorySize int
+
 	// mutex protecting global structs updated or read by ADS service, including EDSUpdates and
 	// shards.
 	mutex sync.RWMutex
@@ -155,13 +159,17 @@ func NewDiscoveryServer(
 	}
 
 	// Flush cached discovery responses whenever services configuration change.
-	serviceHandler := func(*model.Service, model.Event) { out.clearCache() }
+	serviceHandler := func(*model.Service, model.Event) {
+		out.ConfigUpdate(&model.PushRequest{Full: true, Reason: []model.TriggerReason{model.ServiceUpdate}})
+	}
 	if err := ctl.AppendServiceHandler(serviceHandler); err != nil {
 		adsLog.Errorf("Append service handler failed: %v", err)
 		return nil
 	}
 
-	instanceHandler := func(*model.ServiceInstance, model.Event) { out.clearCache() }
+	instanceHandler := func(*model.ServiceInstance, model.Event) {
+		out.ConfigUpdate(&model.PushRequest{Full: true, Reason: []model.TriggerReason{model.ServiceUpdate}})
+	}
 	if err := ctl.AppendInstanceHandler(instanceHandler); err != nil {
 		adsLog.Errorf("Append instance handler failed: %v", err)
 		return nil
@@ -173,13 +181,20 @@ func NewDiscoveryServer(
 	if configCache != nil {
 		// TODO: changes should not trigger a full recompute of LDS/RDS/CDS/EDS
 		// (especially mixerclient HTTP and quota)
-		configHandler := func(model.Config, model.Event) { out.clearCache() }
+		configHandler := func(c model.Config, _ model.Event) {
+			out.ConfigUpdate(&model.PushRequest{
+				Full:           true,
+				Reason:         []model.TriggerReason{model.ConfigUpdate},
+				ConfigsUpdated: map[string]struct{}{c.Key(): {}},
+			})
+		}
 		for _, descriptor := range schemas.Istio {
 			configCache.RegisterEventHandler(descriptor.Type, configHandler)
 		}
 	}
 
 	out.DebugConfigs = features.DebugConfigs
+	out.PushHistorySize = features.PushHistorySize
 
 	pushThrottle := features.PushThrottle
 
@@ -299,7 +314,7 @@ func (s *DiscoveryServer) ClearCache() {
 // clearCache will clear all envoy caches. Called by service, instance and config handlers.
 // This will impact the performance, since envoy will need to recalculate.
 func (s *DiscoveryServer) clearCache() {
-	s.ConfigUpdate(&model.PushRequest{Full: true})
+	s.ConfigUpdate(&model.PushRequest{Full: true, Reason: []model.TriggerReason{model.UnknownTrigger}})
 }
 
 // ConfigUpdate implements ConfigUpdater interface, used to request pushes.
@@ -424,9 +439,11 @@ func doSendPushes(stopCh <-chan struct{}, semaphore chan struct{}, queue *PushQu
 					done:               doneFunc,
 					start:              info.Start,
 					targetNamespaces:   info.TargetNamespaces,
+					reason:             info.Reason,
+					configsUpdated:     info.ConfigsUpdated,
 				}:
 					return
-				case <-client.stream.Context().Done(): // grpc stream was closed
+				case <-client.streamContext().Done(): // grpc stream was closed
 					doneFunc()
 					adsLog.Infof("Client closed connection %v", client.ConID)
 				}
@@ -523,6 +523,7 @@ func (s *DiscoveryServer) edsUpdate(clusterID, serviceName string, namespace str
 			Full:             requireFull,
 			TargetNamespaces: map[string]struct{}{namespace: {}},
 			EdsUpdates:       edsUpdates,
+			Reason:           []model.TriggerReason{model.EndpointUpdate},
 		})
 	}
 }
@@ -640,6 +641,32 @@ func (s *DiscoveryServer) loadAssignmentsForClusterIsolated(proxy *model.Proxy,
 // pushEds is pushing EDS updates for a single connection. Called the first time
 // a client connects, for incremental updates and for full periodic updates.
 func (s *DiscoveryServer) pushEds(push *model.PushContext, con *XdsConnection, version string, edsUpdatedServices map[string]struct{}) error {
+	loadAssignments, endpoints, empty := s.generateEndpoints(push, con, edsUpdatedServices)
+
+	response := endpointDiscoveryResponse(loadAssignments, version)
+	err := con.send(response)
+	if err != nil {
+		adsLog.Warnf("EDS: Send failure %s: %v", con.ConID, err)
+		recordSendError(edsSendErrPushes, err)
+		return err
+	}
+	edsPushes.Increment()
+
+	if edsUpdatedServices == nil {
+		adsLog.Infof("EDS: PUSH for node:%s clusters:%d endpoints:%d empty:%v",
+			con.modelNode.ID, len(con.Clusters), endpoints, empty)
+	} else {
+		adsLog.Infof("EDS: PUSH INC for node:%s clusters:%d endpoints:%d empty:%v",
+			con.modelNode.ID, len(con.Clusters), endpoints, empty)
+	}
+	return nil
+}
+
+// generateEndpoints computes the load assignments for the clusters watched by the connection, along with the total
+// number of endpoints and the names of the clusters without endpoints. If edsUpdatedServices is not nil, only the
+// clusters of the listed services are computed.
+func (s *DiscoveryServer) generateEndpoints(push *model.PushContext, con *XdsConnection,
+	edsUpdatedServices map[string]struct{}) ([]*xdsapi.ClusterLoadAssignment, int, []string) {
 	loadAssignments := make([]*xdsapi.ClusterLoadAssignment, 0)
 	endpoints := 0
 	empty := make([]string, 0)
@@ -695,23 +722,7 @@ func (s *DiscoveryServer) pushEds(push *model.PushContext, con *XdsConnection, v
 		loadAssignments = append(loadAssignments, l)
 	}
 
-	response := endpointDiscoveryResponse(loadAssignments, version)
-	err := con.send(response)
-	if err != nil {
-		adsLog.Warnf("EDS: Send failure %s: %v", con.ConID, err)
-		recordSendError(edsSendErrPushes, err)
-		return err
-	}
-	edsPushes.Increment()
-
-	if edsUpdatedServices == nil {
-		adsLog.Infof("EDS: PUSH for node:%s clusters:%d endpoints:%d empty:%v",
-			con.modelNode.ID, len(con.Clusters), endpoints, empty)
-	} else {
-		adsLog.Infof("EDS: PUSH INC for node:%s clusters:%d endpoints:%d empty:%v",
-			con.modelNode.ID, len(con.Clusters), endpoints, empty)
-	}
-	return nil
+	return loadAssignments, endpoints, empty
 }
 
 // getDestinationRule gets the DestinationRule for a given hostname. As an optimization, this also gets the service port,
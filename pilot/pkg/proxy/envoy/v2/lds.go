@@ -39,6 +39,7 @@ func (s *DiscoveryServer) pushLds(con *XdsConnection, push *model.PushContext, v
 		return err
 	}
 	ldsPushes.Increment()
+	con.pushHistory.recordListeners(rawListeners)
 
 	adsLog.Infof("LDS: PUSH for node:%s listeners:%d", con.modelNode.ID, len(rawListeners))
 	return nil
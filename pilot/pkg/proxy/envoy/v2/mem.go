@@ -133,6 +133,26 @@ func (sd *MemServiceDiscovery) AddService(name host.Name, svc *model.Service) {
 	// TODO: notify listeners
 }
 
+// RemoveService removes an in-memory service, along with its instances.
+func (sd *MemServiceDiscovery) RemoveService(name host.Name) {
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	svc := sd.services[name]
+	if svc == nil {
+		return
+	}
+	delete(sd.services, name)
+	for _, p := range svc.Ports {
+		delete(sd.instancesByPortNum, fmt.Sprintf("%s:%d", name, p.Port))
+		delete(sd.instancesByPortName, fmt.Sprintf("%s:%s", name, p.Name))
+	}
+	for ip, instances := range sd.ip2instance {
+		if len(instances) > 0 && instances[0].Service == svc {
+			delete(sd.ip2instance, ip)
+		}
+	}
+}
+
 // AddInstance adds an in-memory instance.
 func (sd *MemServiceDiscovery) AddInstance(service host.Name, instance *model.ServiceInstance) {
 	// WIP: add enough code to allow tests and load tests to work
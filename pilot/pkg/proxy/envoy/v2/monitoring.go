@@ -25,6 +25,7 @@ var (
 	clusterTag = monitoring.MustCreateLabel("cluster")
 	nodeTag    = monitoring.MustCreateLabel("node")
 	typeTag    = monitoring.MustCreateLabel("type")
+	classTag   = monitoring.MustCreateLabel("class")
 
 	cdsReject = monitoring.NewGauge(
 		"pilot_xds_cds_reject",
@@ -109,6 +110,12 @@ var (
 		[]float64{.1, 1, 3, 5, 10, 20, 30},
 	)
 
+	pushQueueDepth = monitoring.NewGauge(
+		"pilot_push_queue_depth",
+		"Number of proxies pending a push, by class of push (eds or full) and proxy (gateway or sidecar).",
+		monitoring.WithLabels(classTag),
+	)
+
 	// only supported dimension is millis, unfortunately. default to unitdimensionless.
 	proxiesConvergeDelay = monitoring.NewDistribution(
 		"pilot_proxy_convergence_time",
@@ -150,6 +157,10 @@ func recordSendError(metric monitoring.Metric, err error) {
 	}
 }
 
+func recordPushQueueDepth(class pushClass, depth int) {
+	pushQueueDepth.With(classTag.Value(class.String())).Record(float64(depth))
+}
+
 func incrementXDSRejects(metric monitoring.Metric, node, errCode string) {
 	metric.With(nodeTag.Value(node), errTag.Value(errCode)).Increment()
 	totalXDSRejects.Increment()
@@ -170,6 +181,7 @@ func init() {
 		pushes,
 		proxiesConvergeDelay,
 		proxiesQueueTime,
+		pushQueueDepth,
 		proxiesConvergeDelayCdsErrors,
 		proxiesConvergeDelayEdsErrors,
 		proxiesConvergeDelayRdsErrors,
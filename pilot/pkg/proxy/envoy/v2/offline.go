@@ -0,0 +1,102 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	http_conn "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/types"
+)
+
+// OfflineConfig is the xDS configuration generated for a proxy that is not connected to Pilot.
+type OfflineConfig struct {
+	Listeners []*xdsapi.Listener
+	Clusters  []*xdsapi.Cluster
+	Routes    []*xdsapi.RouteConfiguration
+	Endpoints []*xdsapi.ClusterLoadAssignment
+}
+
+// GenerateOfflineConfig computes the LDS, CDS, RDS and EDS responses a proxy identifying itself with the given
+// node would receive, without an ADS stream. The node is initialized the same way as on the first request of a
+// connection; routes and endpoints are generated for the names the proxy would subscribe to after applying the
+// listeners and clusters.
+func (s *DiscoveryServer) GenerateOfflineConfig(node *core.Node) (*OfflineConfig, error) {
+	push := s.globalPushContext()
+	// InitContext returns immediately if the context was already initialized.
+	if err := push.InitContext(s.Env); err != nil {
+		return nil, err
+	}
+	if err := s.updateServiceShards(push); err != nil {
+		return nil, err
+	}
+
+	con := newXdsConnection("offline", nil)
+	if err := s.initConnectionNode(node, con); err != nil {
+		return nil, err
+	}
+
+	out := &OfflineConfig{
+		Listeners: s.generateRawListeners(con, push),
+		Clusters:  s.generateRawClusters(con.modelNode, push),
+	}
+
+	con.Routes = rdsRouteNames(out.Listeners)
+	out.Routes = s.generateRawRoutes(con, push)
+
+	for _, c := range out.Clusters {
+		if c.GetType() == xdsapi.Cluster_EDS {
+			con.Clusters = append(con.Clusters, c.Name)
+		}
+	}
+	out.Endpoints, _, _ = s.generateEndpoints(push, con, nil)
+
+	return out, nil
+}
+
+// rdsRouteNames returns the route configurations referenced by the HTTP connection managers of the listeners,
+// in the order Envoy would request them.
+func rdsRouteNames(listeners []*xdsapi.Listener) []string {
+	names := make([]string, 0)
+	seen := map[string]bool{}
+	for _, l := range listeners {
+		for _, fc := range l.FilterChains {
+			for _, f := range fc.Filters {
+				if f.Name != xdsutil.HTTPConnectionManager {
+					continue
+				}
+				hcm := &http_conn.HttpConnectionManager{}
+				var err error
+				switch c := f.ConfigType.(type) {
+				case *listener.Filter_Config:
+					err = xdsutil.StructToMessage(c.Config, hcm)
+				case *listener.Filter_TypedConfig:
+					err = types.UnmarshalAny(c.TypedConfig, hcm)
+				}
+				if err != nil {
+					adsLog.Warnf("Failed to decode the HTTP connection manager of listener %s: %v", l.Name, err)
+					continue
+				}
+				if rds := hcm.GetRds(); rds != nil && !seen[rds.RouteConfigName] {
+					seen[rds.RouteConfigName] = true
+					names = append(names, rds.RouteConfigName)
+				}
+			}
+		}
+	}
+	return names
+}
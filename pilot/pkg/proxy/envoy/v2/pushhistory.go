@@ -0,0 +1,457 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+// maxPushDiffChanges bounds the number of changes kept per resource type in a push history entry.
+const maxPushDiffChanges = 100
+
+// PushHistoryEntry describes a push to a proxy, returned by /debug/push_history.
+type PushHistoryEntry struct {
+	Time time.Time `json:"time"`
+
+	// Full is false for the pushes of the endpoints of EdsUpdates only.
+	Full bool `json:"full"`
+
+	// Reason and ConfigsUpdated are the triggers of the push, merged during debouncing.
+	Reason         []model.TriggerReason `json:"reason,omitempty"`
+	ConfigsUpdated []string              `json:"configsUpdated,omitempty"`
+	EdsUpdates     []string              `json:"edsUpdates,omitempty"`
+
+	// Versions is the version of the listeners, clusters and routes pushed, a hash of all the resources of the type.
+	Versions map[string]string `json:"versions,omitempty"`
+
+	// Diff holds the changes of the listeners, clusters and routes since the previous push.
+	Diff map[string]*ResourceDiff `json:"diff,omitempty"`
+}
+
+// ResourceDiff is the difference between the resources of a type pushed to a proxy in consecutive pushes.
+type ResourceDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+
+	// Modified lists the changes of the modified resources, by resource name.
+	Modified map[string][]JSONChange `json:"modified,omitempty"`
+
+	// Truncated is set when the changes of some modified resources were dropped to bound the memory use.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// JSONChange is a change of the JSON representation of a resource. Path is a JSON pointer (RFC 6901),
+// From is null for added values and To is null for removed values.
+type JSONChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// pushHistory keeps the last pushes of a connection. A nil history is disabled.
+type pushHistory struct {
+	mu sync.RWMutex
+
+	size    int
+	entries []*PushHistoryEntry
+
+	// current is the entry of the push in progress, nil outside of a push.
+	current *PushHistoryEntry
+
+	// resources holds the resources last sent, by type and name, to compute the next diff.
+	// It is only accessed from the connection goroutine.
+	resources map[string]map[string]historyResource
+}
+
+// historyResource is a resource sent to a proxy. The hash of its JSON detects changes, and the resource
+// itself is only converted to JSON again to diff it when it changed.
+type historyResource struct {
+	hash uint64
+	msg  proto.Message
+}
+
+func newPushHistory(size int) *pushHistory {
+	if size <= 0 {
+		return nil
+	}
+	return &pushHistory{
+		size:      size,
+		resources: map[string]map[string]historyResource{},
+	}
+}
+
+// begin starts recording a push triggered by the event.
+func (h *pushHistory) begin(pushEv *XdsEvent) {
+	if h == nil {
+		return
+	}
+	h.current = &PushHistoryEntry{
+		Time:           time.Now(),
+		Full:           pushEv.edsUpdatedServices == nil,
+		Reason:         pushEv.reason,
+		ConfigsUpdated: sortedKeys(pushEv.configsUpdated),
+		EdsUpdates:     sortedKeys(pushEv.edsUpdatedServices),
+	}
+}
+
+// end adds the push in progress to the history, dropping the oldest push if full.
+func (h *pushHistory) end() {
+	if h == nil || h.current == nil {
+		return
+	}
+	h.mu.Lock()
+	h.entries = append(h.entries, h.current)
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+	h.mu.Unlock()
+	h.current = nil
+}
+
+// record saves the resources of the given type sent to the proxy. During a push, their version and
+// the differences with the resources previously sent are added to the push in progress; responses
+// to the requests of the proxy only update the resources the next push is compared with.
+func (h *pushHistory) record(typeURL string, resources map[string]proto.Message) {
+	if h == nil {
+		return
+	}
+	typ := historyType(typeURL)
+	if typ == "" {
+		return
+	}
+
+	current := make(map[string]historyResource, len(resources))
+	for name, r := range resources {
+		h := fnv.New64a()
+		if err := (&jsonpb.Marshaler{}).Marshal(h, r); err != nil {
+			adsLog.Debugf("Push history: unable to marshal %s %s: %v", typ, name, err)
+			continue
+		}
+		current[name] = historyResource{hash: h.Sum64(), msg: r}
+	}
+	previous := h.resources[typ]
+	h.resources[typ] = current
+
+	if h.current == nil {
+		return
+	}
+	if h.current.Versions == nil {
+		h.current.Versions = map[string]string{}
+		h.current.Diff = map[string]*ResourceDiff{}
+	}
+	h.current.Versions[typ] = resourcesVersion(current)
+	if diff := diffResources(previous, current); diff != nil {
+		h.current.Diff[typ] = diff
+	}
+}
+
+func (h *pushHistory) recordListeners(listeners []*xdsapi.Listener) {
+	if h == nil {
+		return
+	}
+	resources := make(map[string]proto.Message, len(listeners))
+	for _, l := range listeners {
+		resources[l.Name] = l
+	}
+	h.record(ListenerType, resources)
+}
+
+func (h *pushHistory) recordClusters(clusters []*xdsapi.Cluster) {
+	if h == nil {
+		return
+	}
+	resources := make(map[string]proto.Message, len(clusters))
+	for _, c := range clusters {
+		resources[c.Name] = c
+	}
+	h.record(ClusterType, resources)
+}
+
+func (h *pushHistory) recordRoutes(routes []*xdsapi.RouteConfiguration) {
+	if h == nil {
+		return
+	}
+	resources := make(map[string]proto.Message, len(routes))
+	for _, r := range routes {
+		resources[r.Name] = r
+	}
+	h.record(RouteType, resources)
+}
+
+// recordDelta saves the resources of an incremental xDS connection, i.e. all the resources watched by
+// the proxy and not only the ones sent.
+func (h *pushHistory) recordDelta(typeURL string, watched []*xdsapi.Resource) {
+	if h == nil {
+		return
+	}
+	resources := make(map[string]proto.Message, len(watched))
+	for _, r := range watched {
+		resources[r.Name] = r.Resource
+	}
+	h.record(typeURL, resources)
+}
+
+// history returns a copy of the recorded pushes, oldest first.
+func (h *pushHistory) history() []*PushHistoryEntry {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]*PushHistoryEntry{}, h.entries...)
+}
+
+// historyType returns the name of the resource type in the push history, or "" for the types not recorded.
+func historyType(typeURL string) string {
+	switch typeURL {
+	case ListenerType:
+		return "listeners"
+	case ClusterType:
+		return "clusters"
+	case RouteType:
+		return "routes"
+	default:
+		return ""
+	}
+}
+
+// resourcesVersion hashes the names and hashes of all the resources.
+func resourcesVersion(resources map[string]historyResource) string {
+	h := fnv.New64a()
+	for _, name := range resourceNames(resources) {
+		_, _ = h.Write([]byte(name))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(strconv.FormatUint(resources[name].hash, 16)))
+		_, _ = h.Write([]byte{0})
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// decodeJSON returns the JSON representation of the resource, decoded in generic values.
+func decodeJSON(msg proto.Message) (interface{}, error) {
+	s, err := (&jsonpb.Marshaler{}).MarshalToString(msg)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	err = json.Unmarshal([]byte(s), &v)
+	return v, err
+}
+
+// diffResources returns the differences between two sets of resources by name, or nil if they are equal.
+func diffResources(previous, current map[string]historyResource) *ResourceDiff {
+	diff := &ResourceDiff{}
+	changes := 0
+	for _, name := range resourceNames(current) {
+		prev, ok := previous[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+		if prev.hash == current[name].hash {
+			continue
+		}
+		if diff.Modified == nil {
+			diff.Modified = map[string][]JSONChange{}
+		}
+		if changes >= maxPushDiffChanges {
+			diff.Modified[name] = nil
+			diff.Truncated = true
+			continue
+		}
+		from, err := decodeJSON(prev.msg)
+		if err != nil {
+			diff.Modified[name] = nil
+			continue
+		}
+		to, err := decodeJSON(current[name].msg)
+		if err != nil {
+			diff.Modified[name] = nil
+			continue
+		}
+		c := diffJSON("", from, to, nil, maxPushDiffChanges-changes)
+		if len(c) >= maxPushDiffChanges-changes {
+			diff.Truncated = true
+		}
+		changes += len(c)
+		diff.Modified[name] = c
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	sort.Strings(diff.Removed)
+
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Modified) == 0 {
+		return nil
+	}
+	return diff
+}
+
+// diffJSON appends the changes from one decoded JSON value to another to changes, up to limit changes.
+func diffJSON(path string, from, to interface{}, changes []JSONChange, limit int) []JSONChange {
+	if len(changes) >= limit {
+		return changes
+	}
+	switch f := from.(type) {
+	case map[string]interface{}:
+		t, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]struct{}, len(f)+len(t))
+		for k := range f {
+			keys[k] = struct{}{}
+		}
+		for k := range t {
+			keys[k] = struct{}{}
+		}
+		for _, k := range sortedKeys(keys) {
+			fv, fok := f[k]
+			tv, tok := t[k]
+			p := path + "/" + escapeJSONPointer(k)
+			switch {
+			case !fok:
+				changes = append(changes, JSONChange{Path: p, To: tv})
+			case !tok:
+				changes = append(changes, JSONChange{Path: p, From: fv})
+			default:
+				changes = diffJSON(p, fv, tv, changes, limit)
+			}
+			if len(changes) >= limit {
+				return changes[:limit]
+			}
+		}
+		return changes
+	case []interface{}:
+		t, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(f) || i < len(t); i++ {
+			p := path + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(f):
+				changes = append(changes, JSONChange{Path: p, To: t[i]})
+			case i >= len(t):
+				changes = append(changes, JSONChange{Path: p, From: f[i]})
+			default:
+				changes = diffJSON(p, f[i], t[i], changes, limit)
+			}
+			if len(changes) >= limit {
+				return changes[:limit]
+			}
+		}
+		return changes
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		changes = append(changes, JSONChange{Path: path, From: from, To: to})
+	}
+	return changes
+}
+
+func resourceNames(resources map[string]historyResource) []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func escapeJSONPointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pushHistoryz returns the last pushes to a proxy, with their trigger and the changes of its configuration.
+// It is mapped to /debug/push_history and takes the proxyID query parameter. The history is only kept
+// if PILOT_PUSH_HISTORY_SIZE is set.
+func (s *DiscoveryServer) pushHistoryz(w http.ResponseWriter, req *http.Request) {
+	proxyID := req.URL.Query().Get("proxyID")
+	if proxyID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("You must provide a proxyID in the query string"))
+		return
+	}
+	if s.PushHistorySize <= 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("Push history is disabled, set PILOT_PUSH_HISTORY_SIZE to enable it"))
+		return
+	}
+
+	adsClientsMutex.RLock()
+	connections, ok := adsSidecarIDConnectionsMap[proxyID]
+	// Use the latest connection, as the previous ones of the proxy may not have been closed yet.
+	mostRecent := ""
+	var con *XdsConnection
+	for key, c := range connections {
+		if con == nil || c.Connect.After(con.Connect) {
+			mostRecent, con = key, c
+		}
+	}
+	adsClientsMutex.RUnlock()
+	if !ok || con == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("Proxy not connected to this Pilot instance"))
+		return
+	}
+
+	out := struct {
+		ProxyID      string              `json:"proxyID"`
+		ConnectionID string              `json:"connectionID"`
+		Pushes       []*PushHistoryEntry `json:"pushes"`
+	}{
+		ProxyID:      proxyID,
+		ConnectionID: mostRecent,
+		Pushes:       con.pushHistory.history(),
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "unable to marshal push history: %v", err)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
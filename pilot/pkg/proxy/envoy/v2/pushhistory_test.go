@@ -0,0 +1,188 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+
+	"istio.io/istio/pilot/pkg/model"
+)
+
+func routeConfig(name string, domains ...string) *xdsapi.RouteConfiguration {
+	return &xdsapi.RouteConfiguration{
+		Name:         name,
+		VirtualHosts: []*route.VirtualHost{{Name: "vh", Domains: domains}},
+	}
+}
+
+func TestPushHistory(t *testing.T) {
+	var disabled *pushHistory
+	if newPushHistory(0) != disabled {
+		t.Fatalf("expected a disabled push history for size 0")
+	}
+	disabled.begin(&XdsEvent{})
+	disabled.recordRoutes([]*xdsapi.RouteConfiguration{routeConfig("80")})
+	disabled.end()
+	if got := disabled.history(); got != nil {
+		t.Fatalf("expected no history, got %v", got)
+	}
+
+	h := newPushHistory(2)
+
+	// Responses to the proxy requests are not pushes, they only set the resources the next push is compared with.
+	h.recordRoutes([]*xdsapi.RouteConfiguration{routeConfig("80", "a"), routeConfig("9080", "b")})
+	if got := h.history(); len(got) != 0 {
+		t.Fatalf("expected no push, got %v", got)
+	}
+
+	h.begin(&XdsEvent{
+		reason:         []model.TriggerReason{model.ConfigUpdate},
+		configsUpdated: map[string]struct{}{"virtual-service/default/a": {}},
+	})
+	h.recordRoutes([]*xdsapi.RouteConfiguration{routeConfig("80", "a", "a:80"), routeConfig("8080", "c")})
+	h.end()
+
+	h.begin(&XdsEvent{
+		reason:             []model.TriggerReason{model.EndpointUpdate},
+		edsUpdatedServices: map[string]struct{}{"b": {}, "a": {}},
+	})
+	h.end()
+
+	got := h.history()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 pushes, got %d", len(got))
+	}
+
+	full := got[0]
+	if !full.Full || !reflect.DeepEqual(full.Reason, []model.TriggerReason{model.ConfigUpdate}) ||
+		!reflect.DeepEqual(full.ConfigsUpdated, []string{"virtual-service/default/a"}) {
+		t.Errorf("unexpected trigger of the full push: %+v", full)
+	}
+	if full.Versions["routes"] == "" {
+		t.Errorf("expected a routes version, got %v", full.Versions)
+	}
+	wantDiff := &ResourceDiff{
+		Added:    []string{"8080"},
+		Removed:  []string{"9080"},
+		Modified: map[string][]JSONChange{"80": {{Path: "/virtualHosts/0/domains/1", To: "a:80"}}},
+	}
+	if diff := full.Diff["routes"]; !reflect.DeepEqual(diff, wantDiff) {
+		t.Errorf("got routes diff %+v, want %+v", diff, wantDiff)
+	}
+
+	eds := got[1]
+	if eds.Full || !reflect.DeepEqual(eds.EdsUpdates, []string{"a", "b"}) || eds.Diff != nil {
+		t.Errorf("unexpected EDS push: %+v", eds)
+	}
+
+	// The oldest push is dropped once the history is full.
+	h.begin(&XdsEvent{reason: []model.TriggerReason{model.GlobalUpdate}})
+	h.recordRoutes([]*xdsapi.RouteConfiguration{routeConfig("80", "a", "a:80"), routeConfig("8080", "c")})
+	h.end()
+	got = h.history()
+	if len(got) != 2 || got[0] != eds {
+		t.Fatalf("expected the oldest push to be dropped, got %v", got)
+	}
+	if got[1].Diff["routes"] != nil || got[1].Versions["routes"] != full.Versions["routes"] {
+		t.Errorf("expected unchanged routes, got %+v", got[1])
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	from := map[string]interface{}{
+		"name":    "a",
+		"removed": true,
+		"list":    []interface{}{1.0, 2.0, 3.0},
+		"type":    map[string]interface{}{"a": 1.0},
+		"a/b~c":   "x",
+	}
+	to := map[string]interface{}{
+		"name":  "b",
+		"added": "x",
+		"list":  []interface{}{1.0, 4.0},
+		"type":  "STATIC",
+		"a/b~c": "y",
+	}
+
+	want := []JSONChange{
+		{Path: "/a~1b~0c", From: "x", To: "y"},
+		{Path: "/added", To: "x"},
+		{Path: "/list/1", From: 2.0, To: 4.0},
+		{Path: "/list/2", From: 3.0},
+		{Path: "/name", From: "a", To: "b"},
+		{Path: "/removed", From: true},
+		{Path: "/type", From: map[string]interface{}{"a": 1.0}, To: "STATIC"},
+	}
+	if got := diffJSON("", from, to, nil, 100); !reflect.DeepEqual(got, want) {
+		t.Errorf("got changes %v, want %v", got, want)
+	}
+	if got := diffJSON("", from, to, nil, 3); !reflect.DeepEqual(got, want[:3]) {
+		t.Errorf("got limited changes %v, want %v", got, want[:3])
+	}
+	if got := diffJSON("", from, from, nil, 100); len(got) != 0 {
+		t.Errorf("expected no changes, got %v", got)
+	}
+}
+
+func TestJSONChangeKeepsZeroValues(t *testing.T) {
+	b, err := json.Marshal([]JSONChange{{Path: "/a", From: true, To: false}, {Path: "/b", To: ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"path":"/a","from":true,"to":false},{"path":"/b","from":null,"to":""}]`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestPushHistoryzMostRecentConnection(t *testing.T) {
+	s := &DiscoveryServer{PushHistorySize: 1}
+	now := time.Now()
+	// The connection IDs don't sort in the order of the connections.
+	older := &XdsConnection{ConID: "sidecar~1-9", Connect: now, pushHistory: newPushHistory(1)}
+	newer := &XdsConnection{ConID: "sidecar~1-10", Connect: now.Add(time.Second), pushHistory: newPushHistory(1)}
+
+	adsClientsMutex.Lock()
+	adsSidecarIDConnectionsMap["sidecar~1"] = map[string]*XdsConnection{older.ConID: older, newer.ConID: newer}
+	adsClientsMutex.Unlock()
+	defer func() {
+		adsClientsMutex.Lock()
+		delete(adsSidecarIDConnectionsMap, "sidecar~1")
+		adsClientsMutex.Unlock()
+	}()
+
+	w := httptest.NewRecorder()
+	s.pushHistoryz(w, httptest.NewRequest("GET", "/debug/push_history?proxyID=sidecar~1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body.String())
+	}
+	var out struct {
+		ConnectionID string `json:"connectionID"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.ConnectionID != newer.ConID {
+		t.Errorf("got connection %s, want %s", out.ConnectionID, newer.ConID)
+	}
+}
@@ -20,6 +20,58 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 )
 
+// pushClass classifies the pending pushes by push type and proxy kind. Each class has its own FIFO queue.
+type pushClass int
+
+const (
+	edsGatewayPush pushClass = iota
+	edsSidecarPush
+	fullGatewayPush
+	fullSidecarPush
+	numPushClasses
+)
+
+func (c pushClass) String() string {
+	switch c {
+	case edsGatewayPush:
+		return "eds_gateway"
+	case edsSidecarPush:
+		return "eds_sidecar"
+	case fullGatewayPush:
+		return "full_gateway"
+	case fullSidecarPush:
+		return "full_sidecar"
+	default:
+		return "unknown"
+	}
+}
+
+// pushClassWeights is the share of dequeues each class gets when all of them have pending pushes.
+// Endpoint updates are cheap and directly impact traffic, and gateways serve the ingress traffic of
+// the mesh, so they get a larger share. Every class has a non zero weight, so none of them is starved.
+var pushClassWeights = [numPushClasses]int{
+	edsGatewayPush:  8,
+	edsSidecarPush:  4,
+	fullGatewayPush: 4,
+	fullSidecarPush: 1,
+}
+
+// classify returns the class of a push request for the given connection.
+func classify(con *XdsConnection, req *model.PushRequest) pushClass {
+	gateway := con.modelNode != nil && con.modelNode.Type == model.Router
+	full := req != nil && req.Full
+	switch {
+	case full && gateway:
+		return fullGatewayPush
+	case full:
+		return fullSidecarPush
+	case gateway:
+		return edsGatewayPush
+	default:
+		return edsSidecarPush
+	}
+}
+
 type PushQueue struct {
 	mu   *sync.RWMutex
 	cond *sync.Cond
@@ -28,8 +80,14 @@ type PushQueue struct {
 	// PushEvents will be merged.
 	eventsMap map[*XdsConnection]*model.PushRequest
 
-	// connections maintains ordering of the queue
-	connections []*XdsConnection
+	// queues maintains ordering of the queue, for each class of push.
+	queues [numPushClasses][]*XdsConnection
+
+	// classes stores the class of the queue each pending connection is in.
+	classes map[*XdsConnection]pushClass
+
+	// credits are the current weights of the smooth weighted round robin used to pick the class to dequeue.
+	credits [numPushClasses]int
 
 	// inProgress stores all connections that have been Dequeue(), but not MarkDone().
 	// The value stored will be initially be nil, but may be populated if the connection is Enqueue().
@@ -42,6 +100,7 @@ func NewPushQueue() *PushQueue {
 	return &PushQueue{
 		mu:         mu,
 		eventsMap:  make(map[*XdsConnection]*model.PushRequest),
+		classes:    make(map[*XdsConnection]pushClass),
 		inProgress: make(map[*XdsConnection]*model.PushRequest),
 		cond:       sync.NewCond(mu),
 	}
@@ -60,12 +119,18 @@ func (p *PushQueue) Enqueue(proxy *XdsConnection, pushInfo *model.PushRequest) {
 	}
 
 	if event, f := p.eventsMap[proxy]; f {
-		p.eventsMap[proxy] = event.Merge(pushInfo)
+		merged := event.Merge(pushInfo)
+		p.eventsMap[proxy] = merged
+		// An endpoint push merged with a full push becomes a full push, move it to the matching queue.
+		if class := classify(proxy, merged); class != p.classes[proxy] {
+			p.remove(proxy)
+			p.push(proxy, class)
+		}
 		return
 	}
 
 	p.eventsMap[proxy] = pushInfo
-	p.connections = append(p.connections, proxy)
+	p.push(proxy, classify(proxy, pushInfo))
 	// Signal waiters on Dequeue that a new item is available
 	p.cond.Signal()
 }
@@ -76,12 +141,15 @@ func (p *PushQueue) Dequeue() (*XdsConnection, *model.PushRequest) {
 	defer p.mu.Unlock()
 
 	// Block until there is one to remove. Enqueue will signal when one is added.
-	for len(p.connections) == 0 {
+	for len(p.classes) == 0 {
 		p.cond.Wait()
 	}
 
-	head := p.connections[0]
-	p.connections = p.connections[1:]
+	class := p.nextClass()
+	head := p.queues[class][0]
+	p.queues[class] = p.queues[class][1:]
+	delete(p.classes, head)
+	recordPushQueueDepth(class, len(p.queues[class]))
 
 	info := p.eventsMap[head]
 	delete(p.eventsMap, head)
@@ -110,5 +178,47 @@ func (p *PushQueue) MarkDone(con *XdsConnection) {
 func (p *PushQueue) Pending() int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	return len(p.connections)
+	return len(p.classes)
+}
+
+// nextClass picks the class to dequeue from using a smooth weighted round robin over the classes
+// with pending pushes. Must be called with the lock held and at least one pending connection.
+func (p *PushQueue) nextClass() pushClass {
+	total := 0
+	next := pushClass(-1)
+	for c := pushClass(0); c < numPushClasses; c++ {
+		if len(p.queues[c]) == 0 {
+			// Idle classes do not accumulate credits.
+			p.credits[c] = 0
+			continue
+		}
+		p.credits[c] += pushClassWeights[c]
+		total += pushClassWeights[c]
+		if next < 0 || p.credits[c] > p.credits[next] {
+			next = c
+		}
+	}
+	p.credits[next] -= total
+	return next
+}
+
+// push appends the connection to the queue of the class. Must be called with the lock held.
+func (p *PushQueue) push(proxy *XdsConnection, class pushClass) {
+	p.queues[class] = append(p.queues[class], proxy)
+	p.classes[proxy] = class
+	recordPushQueueDepth(class, len(p.queues[class]))
+}
+
+// remove removes a pending connection from the queue of its class. Must be called with the lock held.
+func (p *PushQueue) remove(proxy *XdsConnection) {
+	class := p.classes[proxy]
+	queue := p.queues[class]
+	for i, con := range queue {
+		if con == proxy {
+			p.queues[class] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	delete(p.classes, proxy)
+	recordPushQueueDepth(class, len(p.queues[class]))
 }
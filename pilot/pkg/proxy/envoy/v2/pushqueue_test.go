@@ -243,3 +243,69 @@ func TestProxyQueue(t *testing.T) {
 		}
 	})
 }
+
+func TestProxyQueuePriority(t *testing.T) {
+	sidecars := make([]*XdsConnection, 0, 10)
+	for p := 0; p < 10; p++ {
+		sidecars = append(sidecars, &XdsConnection{ConID: fmt.Sprintf("sidecar-%d", p),
+			modelNode: &model.Proxy{Type: model.SidecarProxy}})
+	}
+	gateways := make([]*XdsConnection, 0, 40)
+	for p := 0; p < 40; p++ {
+		gateways = append(gateways, &XdsConnection{ConID: fmt.Sprintf("gateway-%d", p),
+			modelNode: &model.Proxy{Type: model.Router}})
+	}
+
+	t.Run("gateway endpoint updates go first", func(t *testing.T) {
+		p := NewPushQueue()
+		p.Enqueue(sidecars[0], &model.PushRequest{Full: true})
+		p.Enqueue(sidecars[1], &model.PushRequest{})
+		p.Enqueue(gateways[0], &model.PushRequest{})
+
+		ExpectDequeue(t, p, gateways[0])
+		ExpectDequeue(t, p, sidecars[1])
+		ExpectDequeue(t, p, sidecars[0])
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("merging a full push changes the class", func(t *testing.T) {
+		p := NewPushQueue()
+		p.Enqueue(sidecars[0], &model.PushRequest{})
+		p.Enqueue(sidecars[1], &model.PushRequest{})
+		p.Enqueue(sidecars[0], &model.PushRequest{Full: true})
+
+		ExpectDequeue(t, p, sidecars[1])
+		con, info := p.Dequeue()
+		if con != sidecars[0] || !info.Full {
+			t.Fatalf("Expected full push for %v, got %v %+v", sidecars[0], con, info)
+		}
+		if p.Pending() != 0 {
+			t.Fatalf("Expected no pending proxies, got %d", p.Pending())
+		}
+	})
+
+	t.Run("full sidecar pushes are not starved", func(t *testing.T) {
+		p := NewPushQueue()
+		for _, con := range sidecars {
+			p.Enqueue(con, &model.PushRequest{Full: true})
+		}
+		for _, con := range gateways {
+			p.Enqueue(con, &model.PushRequest{})
+		}
+		if p.Pending() != len(sidecars)+len(gateways) {
+			t.Fatalf("Expected %d pending proxies, got %d", len(sidecars)+len(gateways), p.Pending())
+		}
+
+		// With weights of 8 and 1, a full sidecar push is dequeued in every 9 dequeues.
+		sidecarPushes := 0
+		for i := 0; i < 9*4; i++ {
+			con, _ := p.Dequeue()
+			if con.modelNode.Type == model.SidecarProxy {
+				sidecarPushes++
+			}
+		}
+		if sidecarPushes != 4 {
+			t.Fatalf("Expected 4 sidecar pushes, got %d", sidecarPushes)
+		}
+	})
+}
@@ -46,6 +46,7 @@ func (s *DiscoveryServer) pushRoute(con *XdsConnection, push *model.PushContext,
 		return err
 	}
 	rdsPushes.Increment()
+	con.pushHistory.recordRoutes(rawRoutes)
 
 	adsLog.Infof("RDS: PUSH for node:%s routes:%d", con.modelNode.ID, len(rawRoutes))
 	return nil
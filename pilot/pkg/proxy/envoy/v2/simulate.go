@@ -0,0 +1,392 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/gogo/protobuf/jsonpb"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+)
+
+// SimulatedRequest is the HTTP request evaluated by /debug/route_simulate.
+type SimulatedRequest struct {
+	Authority string            `json:"authority"`
+	Path      string            `json:"path"`
+	Method    string            `json:"method"`
+	Port      int               `json:"port"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// SimulatedCluster is a cluster a simulated request is sent to, with its share of the traffic.
+type SimulatedCluster struct {
+	Name   string `json:"name"`
+	Weight uint32 `json:"weight"`
+}
+
+// RouteSimulation holds the result of running a request through the RouteConfiguration
+// Pilot generates for a proxy.
+type RouteSimulation struct {
+	ProxyID        string             `json:"proxy"`
+	Request        SimulatedRequest   `json:"request"`
+	RouteConfig    string             `json:"route_config"`
+	VirtualHost    string             `json:"virtual_host,omitempty"`
+	RouteName      string             `json:"route_name,omitempty"`
+	VirtualService string             `json:"virtual_service,omitempty"`
+	Clusters       []SimulatedCluster `json:"clusters,omitempty"`
+	Redirect       string             `json:"redirect,omitempty"`
+	DirectResponse uint32             `json:"direct_response,omitempty"`
+	// Route is the matched route, in the Envoy JSON representation.
+	Route json.RawMessage `json:"route,omitempty"`
+}
+
+// routeSimulate evaluates which virtual host, route and clusters a request would hit on the given proxy.
+// It is mapped to /debug/route_simulate and takes the proxyID, port, authority, path, method and
+// header (repeated, as name:value) query parameters. The route parameter selects the RDS route
+// configuration explicitly, otherwise it is inferred from the port.
+func (s *DiscoveryServer) routeSimulate(w http.ResponseWriter, req *http.Request) {
+	_ = req.ParseForm()
+
+	proxyID := req.Form.Get("proxyID")
+	if proxyID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("You must provide a proxyID in the query string"))
+		return
+	}
+	sreq, err := parseSimulatedRequest(req.Form)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+
+	adsClientsMutex.RLock()
+	// Use the latest connection, as the previous ones of the proxy may not have been closed yet.
+	var con *XdsConnection
+	for _, c := range adsSidecarIDConnectionsMap[proxyID] {
+		if con == nil || c.Connect.After(con.Connect) {
+			con = c
+		}
+	}
+	routeNames := []string{req.Form.Get("route")}
+	if con != nil && routeNames[0] == "" {
+		routeNames = simulatedRouteNames(con, sreq.Port)
+	}
+	adsClientsMutex.RUnlock()
+	if con == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("Proxy not connected to this Pilot instance"))
+		return
+	}
+
+	push := s.globalPushContext()
+	var rc *xdsapi.RouteConfiguration
+	for _, name := range routeNames {
+		r := s.ConfigGenerator.BuildHTTPRoutes(s.Env, con.modelNode, push, name)
+		if r == nil {
+			continue
+		}
+		if rc == nil || findVirtualHost(r, sreq.Authority) != nil {
+			rc = r
+		}
+		if findVirtualHost(rc, sreq.Authority) != nil {
+			break
+		}
+	}
+	if rc == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprintf(w, "No route configuration %v for proxy %s", routeNames, proxyID)
+		return
+	}
+
+	out, err := simulateRoute(rc, sreq)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	out.ProxyID = proxyID
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "unable to marshal route simulation: %v", err)
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func parseSimulatedRequest(form url.Values) (SimulatedRequest, error) {
+	sreq := SimulatedRequest{
+		Authority: form.Get("authority"),
+		Path:      form.Get("path"),
+		Method:    form.Get("method"),
+		Headers:   make(map[string]string),
+	}
+	if sreq.Authority == "" {
+		return sreq, fmt.Errorf("you must provide the authority of the request")
+	}
+	port, err := strconv.Atoi(form.Get("port"))
+	if err != nil {
+		return sreq, fmt.Errorf("invalid port %q: %v", form.Get("port"), err)
+	}
+	sreq.Port = port
+	if sreq.Path == "" {
+		sreq.Path = "/"
+	}
+	if sreq.Method == "" {
+		sreq.Method = http.MethodGet
+	}
+	for _, h := range form["header"] {
+		parts := strings.SplitN(h, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return sreq, fmt.Errorf("invalid header %q, expecting name:value", h)
+		}
+		sreq.Headers[strings.ToLower(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return sreq, nil
+}
+
+// simulatedRouteNames returns the RDS route configurations that may serve requests on the port.
+func simulatedRouteNames(con *XdsConnection, port int) []string {
+	if con.modelNode.Type != model.Router {
+		return []string{strconv.Itoa(port)}
+	}
+	var names []string
+	for _, name := range con.Routes {
+		if p, _, _ := model.ParseGatewayRDSRouteName(name); p == port {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		names = append(names, fmt.Sprintf("http.%d", port))
+	}
+	return names
+}
+
+// simulateRoute runs the request through the route configuration, following the matching
+// semantics of Envoy: the virtual host is selected by domain, then the first matching route wins.
+func simulateRoute(rc *xdsapi.RouteConfiguration, sreq SimulatedRequest) (*RouteSimulation, error) {
+	out := &RouteSimulation{
+		Request:     sreq,
+		RouteConfig: rc.Name,
+	}
+	vh := findVirtualHost(rc, sreq.Authority)
+	if vh == nil {
+		return out, nil
+	}
+	out.VirtualHost = vh.Name
+
+	path, rawQuery := sreq.Path, ""
+	if i := strings.Index(path, "?"); i >= 0 {
+		path, rawQuery = path[:i], path[i+1:]
+	}
+	query, _ := url.ParseQuery(rawQuery)
+	headers := map[string]string{
+		":authority": sreq.Authority,
+		":method":    sreq.Method,
+		":path":      sreq.Path,
+		":scheme":    "http",
+	}
+	for k, v := range sreq.Headers {
+		headers[k] = v
+	}
+
+	for _, r := range vh.Routes {
+		if !matchRoute(r.Match, path, headers, query) {
+			continue
+		}
+		out.RouteName = r.Name
+		out.VirtualService = virtualServiceFromMetadata(r)
+		switch {
+		case r.GetRoute() != nil:
+			out.Clusters = routeClusters(r.GetRoute(), headers)
+		case r.GetRedirect() != nil:
+			rd := r.GetRedirect()
+			out.Redirect = rd.GetHostRedirect() + rd.GetPathRedirect()
+		case r.GetDirectResponse() != nil:
+			out.DirectResponse = r.GetDirectResponse().Status
+		}
+		jsonm := &jsonpb.Marshaler{}
+		js, err := jsonm.MarshalToString(r)
+		if err != nil {
+			return nil, err
+		}
+		out.Route = json.RawMessage(js)
+		break
+	}
+	return out, nil
+}
+
+// findVirtualHost selects the virtual host for the authority: an exact domain wins over the
+// longest suffix wildcard, which wins over the longest prefix wildcard, which wins over "*".
+func findVirtualHost(rc *xdsapi.RouteConfiguration, authority string) *route.VirtualHost {
+	authority = strings.ToLower(authority)
+	var suffix, prefix, wildcard *route.VirtualHost
+	suffixLen, prefixLen := 0, 0
+	for _, vh := range rc.VirtualHosts {
+		for _, domain := range vh.Domains {
+			domain = strings.ToLower(domain)
+			switch {
+			case domain == "*":
+				if wildcard == nil {
+					wildcard = vh
+				}
+			case domain == authority:
+				return vh
+			case strings.HasPrefix(domain, "*"):
+				if len(domain) > suffixLen && len(authority) >= len(domain) && strings.HasSuffix(authority, domain[1:]) {
+					suffix, suffixLen = vh, len(domain)
+				}
+			case strings.HasSuffix(domain, "*"):
+				if len(domain) > prefixLen && len(authority) >= len(domain) && strings.HasPrefix(authority, domain[:len(domain)-1]) {
+					prefix, prefixLen = vh, len(domain)
+				}
+			}
+		}
+	}
+	switch {
+	case suffix != nil:
+		return suffix
+	case prefix != nil:
+		return prefix
+	}
+	return wildcard
+}
+
+func matchRoute(m *route.RouteMatch, path string, headers map[string]string, query url.Values) bool {
+	if m == nil {
+		return false
+	}
+	// The case sensitivity setting does not apply to regex matches.
+	ignoreCase := m.CaseSensitive != nil && !m.CaseSensitive.Value
+	switch ps := m.PathSpecifier.(type) {
+	case *route.RouteMatch_Prefix:
+		if ignoreCase && !strings.HasPrefix(strings.ToLower(path), strings.ToLower(ps.Prefix)) ||
+			!ignoreCase && !strings.HasPrefix(path, ps.Prefix) {
+			return false
+		}
+	case *route.RouteMatch_Path:
+		if ignoreCase && !strings.EqualFold(path, ps.Path) || !ignoreCase && path != ps.Path {
+			return false
+		}
+	case *route.RouteMatch_Regex:
+		if !fullMatch(ps.Regex, path) {
+			return false
+		}
+	default:
+		return false
+	}
+
+	for _, h := range m.Headers {
+		if !matchHeader(h, headers) {
+			return false
+		}
+	}
+	for _, q := range m.QueryParameters {
+		values, ok := query[q.Name]
+		if !ok {
+			return false
+		}
+		if q.Value == "" && q.Regex == nil {
+			continue
+		}
+		if q.GetRegex().GetValue() {
+			if !fullMatch(q.Value, values[0]) {
+				return false
+			}
+		} else if values[0] != q.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func matchHeader(h *route.HeaderMatcher, headers map[string]string) bool {
+	value, ok := headers[strings.ToLower(h.Name)]
+	if !ok {
+		return false
+	}
+	var matched bool
+	switch hm := h.HeaderMatchSpecifier.(type) {
+	case *route.HeaderMatcher_ExactMatch:
+		matched = value == hm.ExactMatch
+	case *route.HeaderMatcher_RegexMatch:
+		matched = fullMatch(hm.RegexMatch, value)
+	case *route.HeaderMatcher_PrefixMatch:
+		matched = strings.HasPrefix(value, hm.PrefixMatch)
+	case *route.HeaderMatcher_SuffixMatch:
+		matched = strings.HasSuffix(value, hm.SuffixMatch)
+	case *route.HeaderMatcher_PresentMatch:
+		matched = hm.PresentMatch
+	case *route.HeaderMatcher_RangeMatch:
+		v, err := strconv.ParseInt(value, 10, 64)
+		matched = err == nil && v >= hm.RangeMatch.Start && v < hm.RangeMatch.End
+	default:
+		matched = true
+	}
+	return matched != h.InvertMatch
+}
+
+// fullMatch reports whether the whole value matches the regular expression, as Envoy does.
+func fullMatch(expr, value string) bool {
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		adsLog.Debugf("route simulation: invalid regex %q: %v", expr, err)
+		return false
+	}
+	return re.MatchString(value)
+}
+
+func routeClusters(action *route.RouteAction, headers map[string]string) []SimulatedCluster {
+	switch cs := action.ClusterSpecifier.(type) {
+	case *route.RouteAction_Cluster:
+		return []SimulatedCluster{{Name: cs.Cluster, Weight: 100}}
+	case *route.RouteAction_ClusterHeader:
+		return []SimulatedCluster{{Name: headers[strings.ToLower(cs.ClusterHeader)], Weight: 100}}
+	case *route.RouteAction_WeightedClusters:
+		out := make([]SimulatedCluster, 0, len(cs.WeightedClusters.Clusters))
+		for _, c := range cs.WeightedClusters.Clusters {
+			out = append(out, SimulatedCluster{Name: c.Name, Weight: c.Weight.GetValue()})
+		}
+		return out
+	}
+	return nil
+}
+
+// virtualServiceFromMetadata returns the name.namespace of the VirtualService a route was generated from,
+// using the config path recorded in the route metadata.
+func virtualServiceFromMetadata(r *route.Route) string {
+	md := r.GetMetadata().GetFilterMetadata()[util.IstioMetadataKey]
+	if md == nil {
+		return ""
+	}
+	// /apis/<group>/<version>/namespaces/<namespace>/<type>/<name>
+	parts := strings.Split(md.Fields["config"].GetStringValue(), "/")
+	if len(parts) != 8 || parts[4] != "namespaces" {
+		return ""
+	}
+	return parts[7] + "." + parts[5]
+}
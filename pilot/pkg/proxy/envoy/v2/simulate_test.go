@@ -0,0 +1,212 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"reflect"
+	"testing"
+
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	"github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/gogo/protobuf/types"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/util"
+)
+
+func TestSimulateRoute(t *testing.T) {
+	vsMetadata := util.BuildConfigInfoMetadata(model.ConfigMeta{
+		Group:     "networking.istio.io",
+		Version:   "v1alpha3",
+		Type:      "virtual-service",
+		Name:      "reviews",
+		Namespace: "default",
+	})
+	rc := &xdsapi.RouteConfiguration{
+		Name: "9080",
+		VirtualHosts: []*route.VirtualHost{
+			{
+				Name:    "reviews.default.svc.cluster.local:9080",
+				Domains: []string{"reviews.default.svc.cluster.local", "reviews", "reviews:9080"},
+				Routes: []*route.Route{
+					{
+						Name: "jason",
+						Match: &route.RouteMatch{
+							PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+							Headers: []*route.HeaderMatcher{{
+								Name:                 "end-user",
+								HeaderMatchSpecifier: &route.HeaderMatcher_ExactMatch{ExactMatch: "jason"},
+							}},
+						},
+						Action: &route.Route_Route{Route: &route.RouteAction{
+							ClusterSpecifier: &route.RouteAction_Cluster{Cluster: "outbound|9080|v2|reviews.default.svc.cluster.local"},
+						}},
+						Metadata: vsMetadata,
+					},
+					{
+						Name: "static",
+						Match: &route.RouteMatch{
+							PathSpecifier: &route.RouteMatch_Regex{Regex: "/static/.*"},
+						},
+						Action:   &route.Route_DirectResponse{DirectResponse: &route.DirectResponseAction{Status: 404}},
+						Metadata: vsMetadata,
+					},
+					{
+						Name: "old",
+						Match: &route.RouteMatch{
+							PathSpecifier: &route.RouteMatch_Path{Path: "/OLD"},
+							CaseSensitive: &types.BoolValue{Value: false},
+						},
+						Action: &route.Route_Redirect{Redirect: &route.RedirectAction{
+							HostRedirect:         "ratings",
+							PathRewriteSpecifier: &route.RedirectAction_PathRedirect{PathRedirect: "/new"},
+						}},
+						Metadata: vsMetadata,
+					},
+					{
+						Name: "default",
+						Match: &route.RouteMatch{
+							PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"},
+						},
+						Action: &route.Route_Route{Route: &route.RouteAction{
+							ClusterSpecifier: &route.RouteAction_WeightedClusters{WeightedClusters: &route.WeightedCluster{
+								Clusters: []*route.WeightedCluster_ClusterWeight{
+									{Name: "outbound|9080|v1|reviews.default.svc.cluster.local", Weight: &types.UInt32Value{Value: 80}},
+									{Name: "outbound|9080|v3|reviews.default.svc.cluster.local", Weight: &types.UInt32Value{Value: 20}},
+								},
+							}},
+						}},
+						Metadata: vsMetadata,
+					},
+				},
+			},
+			{
+				Name:    "allow_any",
+				Domains: []string{"*"},
+				Routes: []*route.Route{{
+					Name:  "allow_any",
+					Match: &route.RouteMatch{PathSpecifier: &route.RouteMatch_Prefix{Prefix: "/"}},
+					Action: &route.Route_Route{Route: &route.RouteAction{
+						ClusterSpecifier: &route.RouteAction_Cluster{Cluster: "PassthroughCluster"},
+					}},
+				}},
+			},
+		},
+	}
+
+	cases := []struct {
+		name string
+		req  SimulatedRequest
+		want RouteSimulation
+	}{
+		{
+			name: "header match",
+			req:  SimulatedRequest{Authority: "reviews:9080", Path: "/reviews/1", Headers: map[string]string{"end-user": "jason"}},
+			want: RouteSimulation{
+				VirtualHost:    "reviews.default.svc.cluster.local:9080",
+				RouteName:      "jason",
+				VirtualService: "reviews.default",
+				Clusters:       []SimulatedCluster{{Name: "outbound|9080|v2|reviews.default.svc.cluster.local", Weight: 100}},
+			},
+		},
+		{
+			name: "weighted clusters",
+			req:  SimulatedRequest{Authority: "reviews", Path: "/reviews/1?user=bob", Headers: map[string]string{"end-user": "bob"}},
+			want: RouteSimulation{
+				VirtualHost:    "reviews.default.svc.cluster.local:9080",
+				RouteName:      "default",
+				VirtualService: "reviews.default",
+				Clusters: []SimulatedCluster{
+					{Name: "outbound|9080|v1|reviews.default.svc.cluster.local", Weight: 80},
+					{Name: "outbound|9080|v3|reviews.default.svc.cluster.local", Weight: 20},
+				},
+			},
+		},
+		{
+			name: "regex match",
+			req:  SimulatedRequest{Authority: "reviews", Path: "/static/logo.png"},
+			want: RouteSimulation{
+				VirtualHost:    "reviews.default.svc.cluster.local:9080",
+				RouteName:      "static",
+				VirtualService: "reviews.default",
+				DirectResponse: 404,
+			},
+		},
+		{
+			name: "case insensitive path",
+			req:  SimulatedRequest{Authority: "reviews", Path: "/old"},
+			want: RouteSimulation{
+				VirtualHost:    "reviews.default.svc.cluster.local:9080",
+				RouteName:      "old",
+				VirtualService: "reviews.default",
+				Redirect:       "ratings/new",
+			},
+		},
+		{
+			name: "wildcard virtual host",
+			req:  SimulatedRequest{Authority: "www.google.com", Path: "/"},
+			want: RouteSimulation{
+				VirtualHost: "allow_any",
+				RouteName:   "allow_any",
+				Clusters:    []SimulatedCluster{{Name: "PassthroughCluster", Weight: 100}},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Method = "GET"
+			tt.req.Port = 9080
+			got, err := simulateRoute(rc, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Route) == 0 {
+				t.Errorf("expected the matched route to be returned")
+			}
+			got.Route = nil
+			tt.want.Request = tt.req
+			tt.want.RouteConfig = "9080"
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("simulateRoute() => got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindVirtualHost(t *testing.T) {
+	rc := &xdsapi.RouteConfiguration{
+		VirtualHosts: []*route.VirtualHost{
+			{Name: "default", Domains: []string{"*"}},
+			{Name: "prefix", Domains: []string{"foo.*"}},
+			{Name: "suffix", Domains: []string{"*.example.com"}},
+			{Name: "longer-suffix", Domains: []string{"*.foo.example.com"}},
+			{Name: "exact", Domains: []string{"foo.example.com"}},
+		},
+	}
+	cases := map[string]string{
+		"foo.example.com":     "exact",
+		"FOO.example.com":     "exact",
+		"bar.foo.example.com": "longer-suffix",
+		"bar.example.com":     "suffix",
+		"foo.bar":             "prefix",
+		"bar":                 "default",
+	}
+	for authority, want := range cases {
+		if got := findVirtualHost(rc, authority); got == nil || got.Name != want {
+			t.Errorf("findVirtualHost(%q) => got %v, want %s", authority, got, want)
+		}
+	}
+}
@@ -711,6 +711,7 @@ func TestOnInboundFilterChains(t *testing.T) {
 		sdsUdsPath string
 		expected   []plugin.FilterChain
 		meta       map[string]string
+		crl        []byte
 	}{
 		{
 			name: "NoAuthnPolicy",
@@ -883,11 +884,54 @@ func TestOnInboundFilterChains(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "StrictMTLS with certificate revocation list",
+			in: &authn.Policy{
+				Peers: []*authn.PeerAuthenticationMethod{
+					{
+						Params: &authn.PeerAuthenticationMethod_Mtls{
+							Mtls: &authn.MutualTls{
+								Mode: authn.MutualTls_STRICT,
+							},
+						},
+					},
+				},
+			},
+			sdsUdsPath: "/tmp/sdsuds.sock",
+			crl:        []byte("crl"),
+			expected: []plugin.FilterChain{
+				{
+					TLSContext: &auth.DownstreamTlsContext{
+						CommonTlsContext: &auth.CommonTlsContext{
+							TlsCertificateSdsSecretConfigs: []*auth.SdsSecretConfig{
+								constructSDSConfig(authn_model.SDSDefaultResourceName, "/tmp/sdsuds.sock"),
+							},
+							ValidationContextType: &auth.CommonTlsContext_CombinedValidationContext{
+								CombinedValidationContext: &auth.CommonTlsContext_CombinedCertificateValidationContext{
+									DefaultValidationContext: &auth.CertificateValidationContext{
+										VerifySubjectAltName: []string{}, /*subjectAltNames*/
+										Crl: &core.DataSource{
+											Specifier: &core.DataSource_InlineBytes{
+												InlineBytes: []byte("crl"),
+											},
+										},
+									},
+									ValidationContextSdsSecretConfig: constructSDSConfig(authn_model.SDSRootResourceName, "/tmp/sdsuds.sock"),
+								},
+							},
+							AlpnProtocols: []string{"h2", "http/1.1"},
+						},
+						RequireClientCertificate: proto.BoolTrue,
+					},
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		got := NewPolicyApplier(c.in).InboundFilterChain(
 			c.sdsUdsPath,
 			c.meta,
+			c.crl,
 		)
 		if !reflect.DeepEqual(got, c.expected) {
 			t.Errorf("[%v] unexpected filter chains, got %v, want %v", c.name, got, c.expected)
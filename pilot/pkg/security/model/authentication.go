@@ -155,6 +155,19 @@ func ConstructValidationContext(rootCAFilePath string, subjectAltNames []string)
 	return ret
 }
 
+// ConstructCrl constructs the DataSource of the PEM-encoded certificate revocation list checked in a
+// CertificateValidationContext, or returns nil if the list is empty.
+func ConstructCrl(crl []byte) *core.DataSource {
+	if len(crl) == 0 {
+		return nil
+	}
+	return &core.DataSource{
+		Specifier: &core.DataSource_InlineBytes{
+			InlineBytes: crl,
+		},
+	}
+}
+
 // ConstructgRPCCallCredentials is used to construct SDS config which is only available from 1.1
 func ConstructgRPCCallCredentials(tokenFileName, headerKey string) []*core.GrpcService_GoogleGrpc_CallCredentials {
 	// If k8s sa jwt token file exists, envoy only handles plugin credentials.
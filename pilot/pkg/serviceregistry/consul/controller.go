@@ -16,6 +16,7 @@ package consul
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -36,8 +37,11 @@ type Controller struct {
 	services         map[string]*model.Service //key hostname value service
 	servicesList     []*model.Service
 	serviceInstances map[string][]*model.ServiceInstance //key hostname value serviceInstance array
-	cacheMutex       sync.Mutex
-	initDone         bool
+	// endpoints are the catalog instances of each service, keyed by service name then instanceKey. The monitor
+	// events are applied to them, so that a change only converts the service it belongs to again.
+	endpoints  map[string]map[string]*api.CatalogService
+	cacheMutex sync.Mutex
+	initDone   bool
 }
 
 // NewController creates a new Consul controller
@@ -245,6 +249,7 @@ func (c *Controller) initCache() error {
 
 	c.services = make(map[string]*model.Service)
 	c.serviceInstances = make(map[string][]*model.ServiceInstance)
+	c.endpoints = make(map[string]map[string]*api.CatalogService)
 
 	// get all services from consul
 	consulServices, err := c.getServices()
@@ -265,15 +270,50 @@ func (c *Controller) initCache() error {
 			instances[i] = convertInstance(endpoint)
 		}
 		c.serviceInstances[serviceName] = instances
+
+		c.endpoints[serviceName] = make(map[string]*api.CatalogService, len(endpoints))
+		for _, endpoint := range endpoints {
+			c.endpoints[serviceName][instanceKey(endpoint)] = endpoint
+		}
+	}
+
+	c.updateServicesList()
+
+	c.initDone = true
+	return nil
+}
+
+// updateService converts the service and its instances again from its catalog instances, after a change.
+// A service without instances is dropped, as the monitor only reports services once they have instances.
+func (c *Controller) updateService(name string) {
+	endpoints := c.endpoints[name]
+	if len(endpoints) == 0 {
+		delete(c.endpoints, name)
+		delete(c.services, name)
+		delete(c.serviceInstances, name)
+		return
+	}
+
+	keys := make([]string, 0, len(endpoints))
+	for key := range endpoints {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	sorted := make([]*api.CatalogService, 0, len(keys))
+	instances := make([]*model.ServiceInstance, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, endpoints[key])
+		instances = append(instances, convertInstance(endpoints[key]))
 	}
+	c.services[name] = convertService(sorted)
+	c.serviceInstances[name] = instances
+}
 
+func (c *Controller) updateServicesList() {
 	c.servicesList = make([]*model.Service, 0, len(c.services))
 	for _, value := range c.services {
 		c.servicesList = append(c.servicesList, value)
 	}
-
-	c.initDone = true
-	return nil
 }
 
 func (c *Controller) getServices() (map[string][]string, error) {
@@ -297,18 +337,47 @@ func (c *Controller) getCatalogService(name string, q *api.QueryOptions) ([]*api
 	return endpoints, nil
 }
 
-func (c *Controller) refreshCache() {
+// InstanceChanged applies an instance event of the monitor to the cache. Events received before the cache
+// is initialized are ignored, as they are part of the catalog read by initCache.
+func (c *Controller) InstanceChanged(instance *api.CatalogService, event model.Event) error {
 	c.cacheMutex.Lock()
 	defer c.cacheMutex.Unlock()
-	c.initDone = false
-}
+	if !c.initDone {
+		return nil
+	}
 
-func (c *Controller) InstanceChanged(instance *api.CatalogService, event model.Event) error {
-	c.refreshCache()
+	name := instance.ServiceName
+	switch event {
+	case model.EventDelete:
+		delete(c.endpoints[name], instanceKey(instance))
+	default:
+		if c.endpoints[name] == nil {
+			c.endpoints[name] = make(map[string]*api.CatalogService)
+		}
+		c.endpoints[name][instanceKey(instance)] = instance
+	}
+	c.updateService(name)
+	c.updateServicesList()
 	return nil
 }
 
+// ServiceChanged applies a service event of the monitor to the cache, see InstanceChanged.
 func (c *Controller) ServiceChanged(instances []*api.CatalogService, event model.Event) error {
-	c.refreshCache()
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+	if !c.initDone || len(instances) == 0 {
+		return nil
+	}
+
+	name := instances[0].ServiceName
+	endpoints := make(map[string]*api.CatalogService, len(instances))
+	if event != model.EventDelete {
+		for _, instance := range instances {
+			endpoints[instanceKey(instance)] = instance
+		}
+	}
+	c.endpoints[name] = endpoints
+	c.updateService(name)
+	c.updateServicesList()
 	return nil
 }
@@ -21,6 +21,7 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"sort"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -103,6 +104,13 @@ type mockServer struct {
 	Reviews     []*api.CatalogService
 	Rating      []*api.CatalogService
 	Lock        sync.Mutex
+
+	// responses and indexes hold the last response and the Consul index of each path, the index
+	// being incremented whenever the response changes.
+	responses map[string]string
+	indexes   map[string]uint64
+	// requests counts the requests of each path.
+	requests map[string]int
 }
 
 func newServer() *mockServer {
@@ -111,6 +119,9 @@ func newServer() *mockServer {
 		Reviews:     make([]*api.CatalogService, len(reviews)),
 		Rating:      make([]*api.CatalogService, len(rating)),
 		Services:    make(map[string][]string),
+		responses:   make(map[string]string),
+		indexes:     make(map[string]uint64),
+		requests:    make(map[string]int),
 	}
 
 	copy(m.Reviews, reviews)
@@ -120,35 +131,30 @@ func newServer() *mockServer {
 		m.Services[k] = v
 	}
 
+	// The server supports blocking queries: when the index of the request matches the current
+	// index, the response is delayed until the data changes or the wait time expires.
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/v1/catalog/services" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Services)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/reviews" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Reviews)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/productpage" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Productpage)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else if r.URL.Path == "/v1/catalog/service/rating" {
-			m.Lock.Lock()
-			data, _ := json.Marshal(&m.Rating)
-			m.Lock.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
-		} else {
-			data, _ := json.Marshal(&[]*api.CatalogService{})
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = fmt.Fprintln(w, string(data))
+		wait := 5 * time.Minute
+		if d, err := time.ParseDuration(r.URL.Query().Get("wait")); err == nil {
+			wait = d
+		}
+		deadline := time.Now().Add(wait)
+		m.Lock.Lock()
+		m.requests[r.URL.Path]++
+		m.Lock.Unlock()
+		for {
+			data, index := m.response(r.URL.Path)
+			if r.URL.Query().Get("index") != strconv.FormatUint(index, 10) || time.Now().After(deadline) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+				_, _ = fmt.Fprintln(w, data)
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Millisecond):
+			}
 		}
 	}))
 
@@ -156,6 +162,32 @@ func newServer() *mockServer {
 	return &m
 }
 
+// response returns the current response for the path, and its index.
+func (m *mockServer) response(path string) (string, uint64) {
+	m.Lock.Lock()
+	defer m.Lock.Unlock()
+
+	var data []byte
+	switch path {
+	case "/v1/catalog/services":
+		data, _ = json.Marshal(&m.Services)
+	case "/v1/catalog/service/reviews":
+		data, _ = json.Marshal(&m.Reviews)
+	case "/v1/catalog/service/productpage":
+		data, _ = json.Marshal(&m.Productpage)
+	case "/v1/catalog/service/rating":
+		data, _ = json.Marshal(&m.Rating)
+	default:
+		data, _ = json.Marshal(&[]*api.CatalogService{})
+	}
+
+	if m.responses[path] != string(data) {
+		m.responses[path] = string(data)
+		m.indexes[path]++
+	}
+	return string(data), m.indexes[path]
+}
+
 func TestInstances(t *testing.T) {
 	ts := newServer()
 	defer ts.Server.Close()
@@ -548,7 +580,10 @@ func TestGetInstanceByCacheAfterChanged(t *testing.T) {
 	if err != nil {
 		t.Errorf("could not create Consul Controller: %v", err)
 	}
-	go controller.Run(make(chan struct{}))
+	controller.monitor.(*consulMonitor).waitTime = 100 * time.Millisecond
+	stop := make(chan struct{})
+	go controller.Run(stop)
+	defer close(stop)
 
 	hostname := serviceHostname("reviews")
 	svc := &model.Service{
@@ -602,3 +637,59 @@ func TestGetInstanceByCacheAfterChanged(t *testing.T) {
 		}
 	}
 }
+
+// requestCount returns the number of requests of the path.
+func (m *mockServer) requestCount(path string) int {
+	m.Lock.Lock()
+	defer m.Lock.Unlock()
+	return m.requests[path]
+}
+
+func TestInstanceChangeOnlyQueriesTheService(t *testing.T) {
+	ts := newServer()
+	defer ts.Server.Close()
+	controller, err := NewController(ts.Server.URL, 1*time.Second)
+	if err != nil {
+		t.Fatalf("could not create Consul Controller: %v", err)
+	}
+	stop := make(chan struct{})
+	go controller.Run(stop)
+	defer close(stop)
+
+	svc := &model.Service{Hostname: serviceHostname("reviews")}
+	if instances, err := controller.InstancesByPort(svc, 0, labels.Collection{}); err != nil || len(instances) != 3 {
+		t.Fatalf("Instances() => %v, %v, want 3 instances", instances, err)
+	}
+	// Let the watches of the monitor block on their second query.
+	time.Sleep(500 * time.Millisecond)
+	others := []string{"/v1/catalog/services", "/v1/catalog/service/productpage", "/v1/catalog/service/rating"}
+	counts := make(map[string]int)
+	for _, path := range others {
+		counts[path] = ts.requestCount(path)
+	}
+
+	ts.Lock.Lock()
+	ts.Reviews = ts.Reviews[0:1]
+	ts.Lock.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		instances, err := controller.InstancesByPort(svc, 0, labels.Collection{})
+		if err == nil && len(instances) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Instances() => %v, %v, want 1 instance", instances, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The change is applied from the event, without reading the other services again.
+	for _, path := range others {
+		if count := ts.requestCount(path); count != counts[path] {
+			t.Errorf("%s was queried %d times after the change, want 0", path, count-counts[path])
+		}
+	}
+	if services, err := controller.Services(); err != nil || len(services) != 3 {
+		t.Errorf("Services() => %v, %v, want 3 services", services, err)
+	}
+}
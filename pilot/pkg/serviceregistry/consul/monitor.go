@@ -15,8 +15,11 @@
 package consul
 
 import (
+	"context"
 	"reflect"
 	"sort"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/consul/api"
@@ -25,8 +28,8 @@ import (
 	"istio.io/pkg/log"
 )
 
-type consulServices map[string][]string
-type consulServiceInstances []*api.CatalogService
+// blockingQueryWaitTime is the maximum duration a blocking query waits for a change. Consul caps it at 10 minutes.
+const blockingQueryWaitTime = 5 * time.Minute
 
 // Monitor handles service and instance changes
 type Monitor interface {
@@ -42,23 +45,46 @@ type InstanceHandler func(instance *api.CatalogService, event model.Event) error
 type ServiceHandler func(instances []*api.CatalogService, event model.Event) error
 
 type consulMonitor struct {
-	discovery            *api.Client
-	instanceCachedRecord consulServiceInstances
-	serviceCachedRecord  consulServices
-	instanceHandlers     []InstanceHandler
-	serviceHandlers      []ServiceHandler
-	period               time.Duration
+	discovery        *api.Client
+	instanceHandlers []InstanceHandler
+	serviceHandlers  []ServiceHandler
+	// period is the delay before retrying a failed query.
+	period time.Duration
+	// waitTime is the maximum duration a blocking query waits for a change.
+	waitTime time.Duration
+	// services holds the watched services, keyed by name. Only accessed by the catalog watch.
+	services map[string]*serviceWatch
 }
 
-// NewConsulMonitor polls for changes in Consul Services and CatalogServices
+// serviceWatch holds the state of the blocking query watching the instances of a single service.
+type serviceWatch struct {
+	name   string
+	cancel context.CancelFunc
+
+	mu sync.Mutex
+	// notifyMu is held while sending the notifications of the service. It is locked before mu is released, so
+	// that the notifications are sent in the order of the changes, e.g. a stale add is never sent after the delete.
+	notifyMu sync.Mutex
+	// tags are the sorted tags of the service in the catalog.
+	tags []string
+	// synced is set once the instances were fetched and the service add event was sent.
+	synced bool
+	// removed is set once the service is removed from the catalog, to ignore in-flight query results.
+	removed bool
+	// instances are the last known instances, keyed by instanceKey.
+	instances map[string]*api.CatalogService
+}
+
+// NewConsulMonitor watches for changes in Consul Services and CatalogServices using blocking queries.
+// Failed queries are retried after period.
 func NewConsulMonitor(client *api.Client, period time.Duration) Monitor {
 	return &consulMonitor{
-		discovery:            client,
-		period:               period,
-		instanceCachedRecord: make(consulServiceInstances, 0),
-		serviceCachedRecord:  make(consulServices),
-		instanceHandlers:     make([]InstanceHandler, 0),
-		serviceHandlers:      make([]ServiceHandler, 0),
+		discovery:        client,
+		period:           period,
+		waitTime:         blockingQueryWaitTime,
+		instanceHandlers: make([]InstanceHandler, 0),
+		serviceHandlers:  make([]ServiceHandler, 0),
+		services:         make(map[string]*serviceWatch),
 	}
 }
 
@@ -67,86 +93,208 @@ func (m *consulMonitor) Start(stop <-chan struct{}) {
 }
 
 func (m *consulMonitor) run(stop <-chan struct{}) {
-	ticker := time.NewTicker(m.period)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+	m.watchCatalog(ctx)
+}
+
+// watchCatalog watches the list of services in the catalog, and starts or stops watching the
+// instances of each service as they are added or removed.
+func (m *consulMonitor) watchCatalog(ctx context.Context) {
+	var index uint64
 	for {
-		select {
-		case <-stop:
-			ticker.Stop()
+		q := (&api.QueryOptions{WaitIndex: index, WaitTime: m.waitTime}).WithContext(ctx)
+		svcs, meta, err := m.discovery.Catalog().Services(q)
+		if ctx.Err() != nil {
 			return
-		case <-ticker.C:
-			m.updateServiceRecord()
-			m.updateInstanceRecord()
 		}
+		if err != nil {
+			log.Warnf("Could not fetch services: %v", err)
+			if !m.wait(ctx) {
+				return
+			}
+			continue
+		}
+		index = nextWaitIndex(index, meta.LastIndex)
+		m.updateServices(ctx, svcs)
 	}
 }
 
-func (m *consulMonitor) updateServiceRecord() {
-	svcs, _, err := m.discovery.Catalog().Services(nil)
-	if err != nil {
-		log.Warnf("Could not fetch services: %v", err)
-		return
+func (m *consulMonitor) updateServices(ctx context.Context, svcs map[string][]string) {
+	for name, tags := range svcs {
+		// The order of service tags may change even there is no service change
+		// Sort the service tags to avoid unnecessary pushes to envoy
+		sort.Strings(tags)
+
+		w, f := m.services[name]
+		if !f {
+			watchCtx, cancel := context.WithCancel(ctx)
+			w = &serviceWatch{
+				name:      name,
+				cancel:    cancel,
+				tags:      tags,
+				instances: make(map[string]*api.CatalogService),
+			}
+			m.services[name] = w
+			go m.watchService(watchCtx, w)
+			continue
+		}
+
+		var n notifications
+		w.mu.Lock()
+		if !reflect.DeepEqual(w.tags, tags) {
+			w.tags = tags
+			if w.synced {
+				n.service(w.sortedInstances(), model.EventUpdate)
+			}
+		}
+		w.deliver(m, n)
 	}
 
-	// The order of service tags may change even there is no service change
-	// Sort the service tags to avoid unnecessary pushes to envoy
-	for _, tags := range svcs {
-		sort.Strings(tags)
+	for name, w := range m.services {
+		if _, f := svcs[name]; f {
+			continue
+		}
+		delete(m.services, name)
+		w.cancel()
+
+		var n notifications
+		w.mu.Lock()
+		w.removed = true
+		instances := w.sortedInstances()
+		for _, instance := range instances {
+			n.instance(instance, model.EventDelete)
+		}
+		if w.synced {
+			n.service(instances, model.EventDelete)
+		}
+		w.instances = nil
+		w.deliver(m, n)
 	}
-	newRecord := consulServices(svcs)
-	if !reflect.DeepEqual(newRecord, m.serviceCachedRecord) {
-		// This is only a work-around solution currently
-		// Since Handler functions generally act as a refresher
-		// regardless of the input, thus passing in meaningless
-		// input should make functionalities work
-		//TODO
-		var obj []*api.CatalogService
-		var event model.Event
-		for _, f := range m.serviceHandlers {
-			go func(handler ServiceHandler) {
-				if err := handler(obj, event); err != nil {
-					log.Warnf("Error executing service handler function: %v", err)
-				}
-			}(f)
+}
+
+// watchService watches the instances of a single service until the context is cancelled.
+func (m *consulMonitor) watchService(ctx context.Context, w *serviceWatch) {
+	var index uint64
+	for {
+		q := (&api.QueryOptions{WaitIndex: index, WaitTime: m.waitTime}).WithContext(ctx)
+		instances, meta, err := m.discovery.Catalog().Service(w.name, "", q)
+		if ctx.Err() != nil {
+			return
 		}
-		m.serviceCachedRecord = newRecord
+		if err != nil {
+			log.Warnf("Could not retrieve service catalogue for %s from consul: %v", w.name, err)
+			if !m.wait(ctx) {
+				return
+			}
+			continue
+		}
+		index = nextWaitIndex(index, meta.LastIndex)
+		w.mu.Lock()
+		w.deliver(m, w.updateInstances(instances))
 	}
 }
 
-func (m *consulMonitor) updateInstanceRecord() {
-	svcs, _, err := m.discovery.Catalog().Services(nil)
-	if err != nil {
-		log.Warnf("Could not fetch instances: %v", err)
-		return
+// updateInstances records the current instances of the service, and returns the resulting notifications.
+// Must be called with w.mu held.
+func (w *serviceWatch) updateInstances(instances []*api.CatalogService) notifications {
+	var n notifications
+	if w.removed {
+		return n
 	}
 
-	instances := make([]*api.CatalogService, 0)
-	for name := range svcs {
-		endpoints, _, err := m.discovery.Catalog().Service(name, "", nil)
-		if err != nil {
-			log.Warnf("Could not retrieve service catalogue from consul: %v", err)
-			continue
+	current := make(map[string]*api.CatalogService, len(instances))
+	for _, instance := range instances {
+		sort.Strings(instance.ServiceTags)
+		current[instanceKey(instance)] = instance
+	}
+	previous := w.instances
+	w.instances = current
+
+	if !w.synced {
+		if len(current) == 0 {
+			return n
 		}
-		instances = append(instances, endpoints...)
-	}
-
-	newRecord := consulServiceInstances(instances)
-	sort.Sort(newRecord)
-	if !reflect.DeepEqual(newRecord, m.instanceCachedRecord) {
-		// This is only a work-around solution currently
-		// Since Handler functions generally act as a refresher
-		// regardless of the input, thus passing in meaningless
-		// input should make functionalities work
-		// TODO
-		obj := &api.CatalogService{}
-		var event model.Event
-		for _, f := range m.instanceHandlers {
-			go func(handler InstanceHandler) {
-				if err := handler(obj, event); err != nil {
-					log.Warnf("Error executing instance handler function: %v", err)
-				}
-			}(f)
+		w.synced = true
+		sorted := w.sortedInstances()
+		n.service(sorted, model.EventAdd)
+		for _, instance := range sorted {
+			n.instance(instance, model.EventAdd)
+		}
+		return n
+	}
+
+	for key, instance := range current {
+		old, f := previous[key]
+		if !f {
+			n.instance(instance, model.EventAdd)
+		} else if !reflect.DeepEqual(old, instance) {
+			n.instance(instance, model.EventUpdate)
+		}
+	}
+	for key, instance := range previous {
+		if _, f := current[key]; !f {
+			n.instance(instance, model.EventDelete)
+		}
+	}
+	return n
+}
+
+// wait sleeps for the retry period, returning false if the context was cancelled in the meantime.
+func (m *consulMonitor) wait(ctx context.Context) bool {
+	t := time.NewTimer(m.period)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// notifications are the handler calls resulting from a change. They are collected while w.mu is held, and
+// sent once it is released, so that slow handlers don't block the watches.
+type notifications []func(m *consulMonitor)
+
+// deliver releases w.mu, which must be held, and sends the notifications collected under it. The notifications
+// of the service are sent one change at a time, in order.
+func (w *serviceWatch) deliver(m *consulMonitor, n notifications) {
+	w.notifyMu.Lock()
+	defer w.notifyMu.Unlock()
+	w.mu.Unlock()
+	m.notify(n)
+}
+
+func (n *notifications) service(instances []*api.CatalogService, event model.Event) {
+	*n = append(*n, func(m *consulMonitor) { m.notifyService(instances, event) })
+}
+
+func (n *notifications) instance(instance *api.CatalogService, event model.Event) {
+	*n = append(*n, func(m *consulMonitor) { m.notifyInstance(instance, event) })
+}
+
+func (m *consulMonitor) notify(n notifications) {
+	for _, f := range n {
+		f(m)
+	}
+}
+
+func (m *consulMonitor) notifyService(instances []*api.CatalogService, event model.Event) {
+	for _, f := range m.serviceHandlers {
+		if err := f(instances, event); err != nil {
+			log.Warnf("Error executing service handler function: %v", err)
+		}
+	}
+}
+
+func (m *consulMonitor) notifyInstance(instance *api.CatalogService, event model.Event) {
+	for _, f := range m.instanceHandlers {
+		if err := f(instance, event); err != nil {
+			log.Warnf("Error executing instance handler function: %v", err)
 		}
-		m.instanceCachedRecord = newRecord
 	}
 }
 
@@ -158,19 +306,36 @@ func (m *consulMonitor) AppendInstanceHandler(h InstanceHandler) {
 	m.instanceHandlers = append(m.instanceHandlers, h)
 }
 
-// Len of the array
-func (a consulServiceInstances) Len() int {
-	return len(a)
+// sortedInstances returns the known instances of the service in a stable order. Must be called with w.mu held.
+func (w *serviceWatch) sortedInstances() []*api.CatalogService {
+	keys := make([]string, 0, len(w.instances))
+	for key := range w.instances {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out := make([]*api.CatalogService, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, w.instances[key])
+	}
+	return out
 }
 
-// Swap i and j
-func (a consulServiceInstances) Swap(i, j int) {
-	a[i], a[j] = a[j], a[i]
+// instanceKey identifies a service instance. The ServiceID is only unique within a node, and the address
+// and port are included to tell apart instances registered with the same ID.
+func instanceKey(instance *api.CatalogService) string {
+	return instance.Node + "/" + instance.ServiceID + "/" + instance.ServiceAddress + ":" + strconv.Itoa(instance.ServicePort)
 }
 
-// Less i and j
-func (a consulServiceInstances) Less(i, j int) bool {
-	// ID is the node ID
-	// ServiceID is a unique service instance identifier
-	return a[i].ID+a[i].ServiceID < a[j].ID+a[j].ServiceID
+// nextWaitIndex returns the index to use for the next blocking query, following
+// https://www.consul.io/api/features/blocking.html#implementation-details
+func nextWaitIndex(last, current uint64) uint64 {
+	// The index going backwards means the state of Consul was reset, start over.
+	if current < last {
+		return 0
+	}
+	// An index of 0 would make the query non-blocking, resulting in a busy loop.
+	if current < 1 {
+		return 1
+	}
+	return current
 }
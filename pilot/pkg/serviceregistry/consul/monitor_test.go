@@ -15,6 +15,9 @@
 package consul
 
 import (
+	"context"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -42,6 +45,8 @@ func TestController(t *testing.T) {
 	updateChannel := make(chan struct{}, 10)
 
 	ctl := NewConsulMonitor(cl, resync)
+	// Return blocking queries quickly, so the fake server is not left waiting when the test ends.
+	ctl.(*consulMonitor).waitTime = resync
 	ctl.AppendInstanceHandler(func(instance *api.CatalogService, event model.Event) error {
 		updateChannel <- struct{}{}
 		return nil
@@ -89,7 +94,7 @@ func TestController(t *testing.T) {
 
 	// re-ordering of service instances -> does not trigger update
 	ts.Lock.Lock()
-	reviews[0], reviews[len(reviews)-1] = reviews[len(reviews)-1], reviews[0]
+	ts.Reviews[0], ts.Reviews[len(ts.Reviews)-1] = ts.Reviews[len(ts.Reviews)-1], ts.Reviews[0]
 	ts.Lock.Unlock()
 	expectNotify(t, 0)
 
@@ -101,13 +106,92 @@ func TestController(t *testing.T) {
 
 	// delete a service instance -> trigger instance update
 	ts.Lock.Lock()
-	ts.Reviews = reviews[0:1]
+	ts.Reviews = ts.Reviews[0:2]
 	ts.Lock.Unlock()
 	expectNotify(t, 1)
 
+	// delete two service instances -> trigger an instance update for each
+	ts.Lock.Lock()
+	ts.Reviews = []*api.CatalogService{}
+	ts.Lock.Unlock()
+	expectNotify(t, 2)
+
 	// delete a service -> trigger service and instance update
 	ts.Lock.Lock()
 	delete(ts.Services, "productpage")
 	ts.Lock.Unlock()
 	expectNotify(t, 2)
 }
+
+func TestNotificationsOrderedPerService(t *testing.T) {
+	m := NewConsulMonitor(nil, resync).(*consulMonitor)
+	var mu sync.Mutex
+	var events []model.Event
+	addReceived := make(chan struct{})
+	release := make(chan struct{})
+	m.AppendServiceHandler(func(instances []*api.CatalogService, event model.Event) error {
+		if event == model.EventAdd {
+			close(addReceived)
+			// A slow handler, still handling the add when the service is removed.
+			<-release
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+		return nil
+	})
+
+	_, cancel := context.WithCancel(context.Background())
+	w := &serviceWatch{name: "reviews", cancel: cancel, instances: make(map[string]*api.CatalogService)}
+	m.services["reviews"] = w
+
+	added := make(chan struct{})
+	go func() {
+		w.mu.Lock()
+		w.deliver(m, w.updateInstances(reviews))
+		close(added)
+	}()
+	<-addReceived
+
+	removed := make(chan struct{})
+	go func() {
+		m.updateServices(context.Background(), map[string][]string{})
+		close(removed)
+	}()
+	select {
+	case <-removed:
+		t.Fatal("the delete was sent while the add was being sent")
+	case <-time.After(notifyThreshold):
+	}
+
+	close(release)
+	<-added
+	<-removed
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []model.Event{model.EventAdd, model.EventDelete}; !reflect.DeepEqual(events, want) {
+		t.Errorf("got service events %v, want %v", events, want)
+	}
+}
+
+func TestNextWaitIndex(t *testing.T) {
+	cases := []struct {
+		name    string
+		last    uint64
+		current uint64
+		want    uint64
+	}{
+		{"first query", 0, 10, 10},
+		{"index moves forward", 10, 12, 12},
+		{"index unchanged", 12, 12, 12},
+		{"index goes backwards", 12, 3, 0},
+		{"index is zero", 0, 0, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := nextWaitIndex(c.last, c.current); got != c.want {
+				t.Errorf("nextWaitIndex(%d, %d) => %d, want %d", c.last, c.current, got, c.want)
+			}
+		})
+	}
+}
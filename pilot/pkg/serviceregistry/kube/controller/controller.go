@@ -32,6 +32,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	listerv1 "k8s.io/client-go/listers/core/v1"
@@ -104,9 +105,26 @@ type Options struct {
 	// TrustDomain used in SPIFFE identity
 	TrustDomain string
 
+	// EndpointMode decides what source to use to get endpoint information
+	EndpointMode EndpointMode
+
+	// DynamicClient is used to watch EndpointSlices. It is required when EndpointMode is EndpointSliceOnly.
+	DynamicClient dynamic.Interface
+
 	stop chan struct{}
 }
 
+// EndpointMode decides what source to use to get endpoint information
+type EndpointMode int
+
+const (
+	// EndpointsOnly type will use only Kubernetes Endpoints
+	EndpointsOnly EndpointMode = iota
+
+	// EndpointSliceOnly type will use only Kubernetes EndpointSlices
+	EndpointSliceOnly
+)
+
 // Controller is a collection of synchronized resource watchers
 // Caches are thread-safe
 type Controller struct {
@@ -118,6 +136,9 @@ type Controller struct {
 	endpoints cacheHandler
 	nodes     cacheHandler
 
+	// endpointMode is the source of the objects watched by endpoints.
+	endpointMode EndpointMode
+
 	pods *PodCache
 
 	// Env is set by server to point to the environment, to allow the controller to
@@ -172,8 +193,17 @@ func NewController(client kubernetes.Interface, options Options) *Controller {
 	svcInformer := sharedInformers.Core().V1().Services().Informer()
 	out.services = out.createCacheHandler(svcInformer, "Services")
 
-	epInformer := sharedInformers.Core().V1().Endpoints().Informer()
-	out.endpoints = out.createEDSCacheHandler(epInformer, "Endpoints")
+	if options.EndpointMode == EndpointSliceOnly && options.DynamicClient != nil {
+		out.endpointMode = EndpointSliceOnly
+		sliceInformer := newEndpointSliceInformer(options.DynamicClient, options.WatchedNamespace, options.ResyncPeriod)
+		out.endpoints = out.createEDSCacheHandler(sliceInformer, "EndpointSlice")
+	} else {
+		if options.EndpointMode == EndpointSliceOnly {
+			log.Warnf("No dynamic client provided to watch EndpointSlices, falling back to Endpoints")
+		}
+		epInformer := sharedInformers.Core().V1().Endpoints().Informer()
+		out.endpoints = out.createEDSCacheHandler(epInformer, "Endpoints")
+	}
 
 	nodeInformer := sharedInformers.Core().V1().Nodes().Informer()
 	out.nodes = out.createCacheHandler(nodeInformer, "Nodes")
@@ -238,10 +268,7 @@ func (c *Controller) createEDSCacheHandler(informer cache.SharedIndexInformer, o
 			},
 			UpdateFunc: func(old, cur interface{}) {
 				// Avoid pushes if only resource version changed (kube-scheduller, cluster-autoscaller, etc)
-				oldE := old.(*v1.Endpoints)
-				curE := cur.(*v1.Endpoints)
-
-				if !reflect.DeepEqual(oldE.Subsets, curE.Subsets) {
+				if endpointsChanged(old, cur) {
 					incrementEvent(otype, "update")
 					c.queue.Push(kube.Task{Handler: handler.Apply, Obj: cur, Event: model.EventUpdate})
 				} else {
@@ -261,6 +288,14 @@ func (c *Controller) createEDSCacheHandler(informer cache.SharedIndexInformer, o
 	return cacheHandler{informer: informer, handler: handler}
 }
 
+// endpointsChanged returns whether the addresses or ports of an Endpoints or EndpointSlice changed.
+func endpointsChanged(old, cur interface{}) bool {
+	if oldE, ok := old.(*v1.Endpoints); ok {
+		return !reflect.DeepEqual(oldE.Subsets, cur.(*v1.Endpoints).Subsets)
+	}
+	return endpointSliceChanged(old, cur)
+}
+
 // HasSynced returns true after the initial state synchronization
 func (c *Controller) HasSynced() bool {
 	if !c.services.informer.HasSynced() ||
@@ -439,6 +474,10 @@ func (c *Controller) InstancesByPort(svc *model.Service, reqSvcPort int,
 		return inScopeInstances, nil
 	}
 
+	if c.endpointMode == EndpointSliceOnly {
+		return c.instancesByPortFromEndpointSlices(svc, svcPortEntry, labelsList), nil
+	}
+
 	item, exists, err := c.endpoints.informer.GetStore().GetByKey(kube.KeyFunc(svc.Attributes.Name, svc.Attributes.Namespace))
 	if err != nil {
 		log.Infof("get endpoint(%s, %s) => error %v", svc.Attributes.Name, svc.Attributes.Namespace, err)
@@ -448,7 +487,7 @@ func (c *Controller) InstancesByPort(svc *model.Service, reqSvcPort int,
 		return nil, nil
 	}
 
-	mixerEnabled := c.Env != nil && c.Env.Mesh != nil && (c.Env.Mesh.MixerCheckServer != "" || c.Env.Mesh.MixerReportServer != "")
+	mixerEnabled := c.mixerEnabled()
 
 	ep := item.(*v1.Endpoints)
 	var out []*model.ServiceInstance
@@ -495,6 +534,11 @@ func (c *Controller) InstancesByPort(svc *model.Service, reqSvcPort int,
 	return out, nil
 }
 
+// mixerEnabled returns whether Mixer is configured, in which case instances carry the UID of their pod.
+func (c *Controller) mixerEnabled() bool {
+	return c.Env != nil && c.Env.Mesh != nil && (c.Env.Mesh.MixerCheckServer != "" || c.Env.Mesh.MixerReportServer != "")
+}
+
 // GetProxyServiceInstances returns service instances co-located with a given proxy
 func (c *Controller) GetProxyServiceInstances(proxy *model.Proxy) ([]*model.ServiceInstance, error) {
 	out := make([]*model.ServiceInstance, 0)
@@ -541,13 +585,28 @@ func (c *Controller) GetProxyServiceInstances(proxy *model.Proxy) ([]*model.Serv
 	endpointsForPodInSameNS := make([]*model.ServiceInstance, 0)
 	endpointsForPodInDifferentNS := make([]*model.ServiceInstance, 0)
 	for _, item := range c.endpoints.informer.GetStore().List() {
-		ep := *item.(*v1.Endpoints)
+		var namespace string
+		var instances []*model.ServiceInstance
+		if c.endpointMode == EndpointSliceOnly {
+			slice, err := convertEndpointSlice(item)
+			if err != nil {
+				log.Warnf("Failed to convert EndpointSlice: %v", err)
+				continue
+			}
+			namespace = slice.Namespace
+			instances = c.getProxyServiceInstancesByEndpointSlice(slice, proxy)
+		} else {
+			ep := *item.(*v1.Endpoints)
+			namespace = ep.Namespace
+			instances = c.getProxyServiceInstancesByEndpoint(ep, proxy)
+		}
+
 		endpoints := &endpointsForPodInSameNS
-		if ep.Namespace != proxyNamespace {
+		if namespace != proxyNamespace {
 			endpoints = &endpointsForPodInDifferentNS
 		}
 
-		*endpoints = append(*endpoints, c.getProxyServiceInstancesByEndpoint(ep, proxy)...)
+		*endpoints = append(*endpoints, instances...)
 	}
 
 	// Put the endpointsForPodInSameNS in front of endpointsForPodInDifferentNS so that Pilot will
@@ -876,6 +935,10 @@ func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.
 		return nil
 	}
 	c.endpoints.handler.Append(func(obj interface{}, event model.Event) error {
+		if c.endpointMode == EndpointSliceOnly {
+			return c.handleEndpointSliceEvent(obj, event)
+		}
+
 		ep, ok := obj.(*v1.Endpoints)
 		if !ok {
 			tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
@@ -900,7 +963,7 @@ func (c *Controller) AppendInstanceHandler(f func(*model.ServiceInstance, model.
 
 func (c *Controller) updateEDS(ep *v1.Endpoints, event model.Event) {
 	hostname := kube.ServiceHostname(ep.Name, ep.Namespace, c.domainSuffix)
-	mixerEnabled := c.Env != nil && c.Env.Mesh != nil && (c.Env.Mesh.MixerCheckServer != "" || c.Env.Mesh.MixerReportServer != "")
+	mixerEnabled := c.mixerEnabled()
 
 	endpoints := make([]*model.IstioEndpoint, 0)
 	if event != model.EventDelete {
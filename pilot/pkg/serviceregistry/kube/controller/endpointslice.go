@@ -0,0 +1,400 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	configKube "istio.io/istio/pkg/config/kube"
+	"istio.io/istio/pkg/config/labels"
+	"istio.io/pkg/log"
+)
+
+const (
+	// EndpointSliceServiceLabel is set by Kubernetes on EndpointSlices to the name of the service they belong to.
+	EndpointSliceServiceLabel = "kubernetes.io/service-name"
+	// TopologyRegionLabel is the topology key holding the region of an EndpointSlice endpoint
+	TopologyRegionLabel = "topology.kubernetes.io/region"
+	// TopologyZoneLabel is the topology key holding the zone of an EndpointSlice endpoint
+	TopologyZoneLabel = "topology.kubernetes.io/zone"
+
+	// endpointSliceServiceIndex indexes EndpointSlices by the key of their service.
+	endpointSliceServiceIndex = "service"
+	// endpointSliceAddressTypeFQDN is the address type of slices holding host names rather than IPs.
+	endpointSliceAddressTypeFQDN = "FQDN"
+)
+
+// endpointSliceResource is the EndpointSlice API. The version of client-go Istio builds against has no typed
+// client for it, so slices are watched with the dynamic client and converted to the types below.
+var endpointSliceResource = schema.GroupVersionResource{
+	Group:    "discovery.k8s.io",
+	Version:  "v1alpha1",
+	Resource: "endpointslices",
+}
+
+// endpointSlice mirrors the discovery.k8s.io/v1alpha1 EndpointSlice type.
+type endpointSlice struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	AddressType *string                 `json:"addressType,omitempty"`
+	Endpoints   []endpointSliceEndpoint `json:"endpoints"`
+	Ports       []endpointSlicePort     `json:"ports"`
+}
+
+type endpointSliceEndpoint struct {
+	Addresses  []string            `json:"addresses"`
+	Conditions endpointConditions  `json:"conditions,omitempty"`
+	Hostname   *string             `json:"hostname,omitempty"`
+	TargetRef  *v1.ObjectReference `json:"targetRef,omitempty"`
+	Topology   map[string]string   `json:"topology,omitempty"`
+}
+
+type endpointConditions struct {
+	Ready *bool `json:"ready,omitempty"`
+}
+
+type endpointSlicePort struct {
+	Name     *string      `json:"name,omitempty"`
+	Protocol *v1.Protocol `json:"protocol,omitempty"`
+	Port     *int32       `json:"port,omitempty"`
+}
+
+// ready returns whether the endpoint can receive traffic. A nil condition is interpreted as ready.
+func (e endpointSliceEndpoint) ready() bool {
+	return e.Conditions.Ready == nil || *e.Conditions.Ready
+}
+
+func (p endpointSlicePort) name() string {
+	if p.Name == nil {
+		return ""
+	}
+	return *p.Name
+}
+
+func newEndpointSliceInformer(client dynamic.Interface, namespace string, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	r := client.Resource(endpointSliceResource).Namespace(namespace)
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return r.List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.Watch = true
+				return r.Watch(options)
+			},
+		},
+		&unstructured.Unstructured{},
+		resyncPeriod,
+		cache.Indexers{endpointSliceServiceIndex: endpointSliceServiceKey})
+}
+
+// endpointSliceServiceKey indexes EndpointSlices by the namespace and name of their service.
+func endpointSliceServiceKey(obj interface{}) ([]string, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("not an EndpointSlice: %T", obj)
+	}
+	svcName := u.GetLabels()[EndpointSliceServiceLabel]
+	if svcName == "" {
+		return nil, nil
+	}
+	return []string{kube.KeyFunc(svcName, u.GetNamespace())}, nil
+}
+
+func convertEndpointSlice(obj interface{}) (*endpointSlice, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("not an EndpointSlice: %T", obj)
+	}
+	slice := &endpointSlice{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, slice); err != nil {
+		return nil, fmt.Errorf("failed to convert EndpointSlice %s/%s: %v", u.GetNamespace(), u.GetName(), err)
+	}
+	return slice, nil
+}
+
+// endpointSliceChanged returns whether the endpoints or ports of an EndpointSlice changed.
+func endpointSliceChanged(old, cur interface{}) bool {
+	oldU, ok := old.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	curU, ok := cur.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	return !reflect.DeepEqual(oldU.Object["endpoints"], curU.Object["endpoints"]) ||
+		!reflect.DeepEqual(oldU.Object["ports"], curU.Object["ports"])
+}
+
+// handleEndpointSliceEvent recomputes the endpoints of the service owning the EndpointSlice.
+func (c *Controller) handleEndpointSliceEvent(obj interface{}, event model.Event) error {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			log.Errorf("Couldn't get object from tombstone %#v", obj)
+			return nil
+		}
+		u, ok = tombstone.Obj.(*unstructured.Unstructured)
+		if !ok {
+			log.Errorf("Tombstone contained object that is not an EndpointSlice %#v", obj)
+			return nil
+		}
+	}
+
+	svcName := u.GetLabels()[EndpointSliceServiceLabel]
+	if svcName == "" {
+		// Slices not managed for a service can not be associated with a hostname.
+		return nil
+	}
+
+	// A service may be backed by many slices, and the informer store already reflects the event,
+	// so the endpoints are recomputed from all the remaining slices of the service.
+	c.updateEDSFromEndpointSlices(svcName, u.GetNamespace())
+	return nil
+}
+
+// endpointSlicesForService returns the EndpointSlices belonging to the service.
+func (c *Controller) endpointSlicesForService(name, namespace string) []*endpointSlice {
+	items, err := c.endpoints.informer.GetIndexer().ByIndex(endpointSliceServiceIndex, kube.KeyFunc(name, namespace))
+	if err != nil {
+		log.Warnf("Failed to get EndpointSlices for %s/%s: %v", namespace, name, err)
+		return nil
+	}
+
+	out := make([]*endpointSlice, 0, len(items))
+	for _, item := range items {
+		slice, err := convertEndpointSlice(item)
+		if err != nil {
+			log.Warnf("Failed to convert EndpointSlice: %v", err)
+			continue
+		}
+		if slice.AddressType != nil && *slice.AddressType == endpointSliceAddressTypeFQDN {
+			continue
+		}
+		out = append(out, slice)
+	}
+	return out
+}
+
+func (c *Controller) updateEDSFromEndpointSlices(name, namespace string) {
+	hostname := kube.ServiceHostname(name, namespace, c.domainSuffix)
+	mixerEnabled := c.mixerEnabled()
+
+	endpoints := make([]*model.IstioEndpoint, 0)
+	var addresses []string
+	for _, slice := range c.endpointSlicesForService(name, namespace) {
+		for _, e := range slice.Endpoints {
+			if !e.ready() {
+				continue
+			}
+			for _, ip := range e.Addresses {
+				pod := c.pods.getPodByIP(ip)
+				if pod == nil {
+					// For service without selector, maybe there are no related pods
+					if e.TargetRef != nil && e.TargetRef.Kind == "Pod" {
+						log.Warnf("Endpoint without pod %s %s.%s", ip, name, namespace)
+						if c.Env != nil {
+							c.Env.PushContext.Add(model.EndpointNoPod, string(hostname), nil, ip)
+						}
+						continue
+					}
+				}
+
+				var labels map[string]string
+				sa, uid := "", ""
+				if pod != nil {
+					sa = kube.SecureNamingSAN(pod)
+					if mixerEnabled {
+						uid = fmt.Sprintf("kubernetes://%s.%s", pod.Name, pod.Namespace)
+					}
+					labels = map[string]string(configKube.ConvertLabels(pod.ObjectMeta))
+				}
+				locality := c.endpointSliceLocality(e, pod)
+
+				// EDS and ServiceEntry use name for service port - ADS will need to
+				// map to numbers.
+				for _, port := range slice.Ports {
+					if port.Port == nil {
+						continue
+					}
+					endpoints = append(endpoints, &model.IstioEndpoint{
+						Address:         ip,
+						EndpointPort:    uint32(*port.Port),
+						ServicePortName: port.name(),
+						Labels:          labels,
+						UID:             uid,
+						ServiceAccount:  sa,
+						Network:         c.endpointNetwork(ip),
+						Locality:        locality,
+						Attributes:      model.ServiceAttributes{Name: name, Namespace: namespace},
+					})
+				}
+				addresses = append(addresses, ip)
+			}
+		}
+	}
+
+	log.Infof("Handle EDS endpoint slices %s in namespace %s -> %v", name, namespace, addresses)
+
+	_ = c.XDSUpdater.EDSUpdate(c.ClusterID, string(hostname), namespace, endpoints)
+}
+
+// instancesByPortFromEndpointSlices is the EndpointSlice equivalent of InstancesByPort.
+func (c *Controller) instancesByPortFromEndpointSlices(svc *model.Service, svcPortEntry *model.Port,
+	labelsList labels.Collection) []*model.ServiceInstance {
+	mixerEnabled := c.mixerEnabled()
+
+	var out []*model.ServiceInstance
+	for _, slice := range c.endpointSlicesForService(svc.Attributes.Name, svc.Attributes.Namespace) {
+		for _, e := range slice.Endpoints {
+			if !e.ready() {
+				continue
+			}
+			for _, ip := range e.Addresses {
+				podLabels, _ := c.pods.labelsByIP(ip)
+				// check that one of the input labels is a subset of the labels
+				if !labelsList.HasSubsetOf(podLabels) {
+					continue
+				}
+
+				pod := c.pods.getPodByIP(ip)
+				sa, uid := "", ""
+				if pod != nil {
+					sa = kube.SecureNamingSAN(pod)
+					if mixerEnabled {
+						uid = fmt.Sprintf("kubernetes://%s.%s", pod.Name, pod.Namespace)
+					}
+				}
+				locality := c.endpointSliceLocality(e, pod)
+
+				// identify the port by name. K8S EndpointPort uses the service port name
+				for _, port := range slice.Ports {
+					if port.Port == nil {
+						continue
+					}
+					if port.name() == "" || // 'name optional if single port is defined'
+						svcPortEntry.Name == port.name() {
+						out = append(out, &model.ServiceInstance{
+							Endpoint: model.NetworkEndpoint{
+								Address:     ip,
+								Port:        int(*port.Port),
+								ServicePort: svcPortEntry,
+								UID:         uid,
+								Network:     c.endpointNetwork(ip),
+								Locality:    locality,
+							},
+							Service:        svc,
+							Labels:         podLabels,
+							ServiceAccount: sa,
+						})
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// getProxyServiceInstancesByEndpointSlice is the EndpointSlice equivalent of getProxyServiceInstancesByEndpoint.
+func (c *Controller) getProxyServiceInstancesByEndpointSlice(slice *endpointSlice, proxy *model.Proxy) []*model.ServiceInstance {
+	out := make([]*model.ServiceInstance, 0)
+
+	svcName := slice.Labels[EndpointSliceServiceLabel]
+	if svcName == "" {
+		return out
+	}
+	hostname := kube.ServiceHostname(svcName, slice.Namespace, c.domainSuffix)
+	c.RLock()
+	svc := c.servicesMap[hostname]
+	c.RUnlock()
+
+	if svc == nil {
+		return out
+	}
+
+	podIP := proxy.IPAddresses[0]
+	pod := c.pods.getPodByIP(podIP)
+	for _, port := range slice.Ports {
+		if port.Port == nil {
+			continue
+		}
+		svcPort, exists := svc.Ports.Get(port.name())
+		if !exists {
+			continue
+		}
+
+		for _, e := range slice.Endpoints {
+			// consider multiple IP scenarios
+			for _, ip := range proxy.IPAddresses {
+				if !hasAddress(e.Addresses, ip) {
+					continue
+				}
+				instance := c.getEndpoints(podIP, ip, *port.Port, svcPort, svc)
+				instance.Endpoint.Locality = c.endpointSliceLocality(e, pod)
+				out = append(out, instance)
+
+				if !e.ready() && c.Env != nil {
+					c.Env.PushContext.Add(model.ProxyStatusEndpointNotReady, proxy.ID, proxy, "")
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// endpointSliceLocality returns the locality of an endpoint from its topology, falling back to the locality
+// of the node of its pod. As for Endpoints, the locality label of the pod takes precedence.
+func (c *Controller) endpointSliceLocality(e endpointSliceEndpoint, pod *v1.Pod) string {
+	region := e.Topology[TopologyRegionLabel]
+	zone := e.Topology[TopologyZoneLabel]
+	if region == "" && zone == "" {
+		if pod == nil {
+			return ""
+		}
+		return c.GetPodLocality(pod)
+	}
+
+	locality := fmt.Sprintf("%v/%v", region, zone)
+	if pod == nil {
+		return locality
+	}
+	return model.GetLocalityOrDefault(pod.Labels[model.LocalityLabel], locality)
+}
+
+func hasAddress(addresses []string, ip string) bool {
+	for _, a := range addresses {
+		if a == ip {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube"
+	"istio.io/istio/pkg/test"
+)
+
+func newFakeEndpointSliceController(t *testing.T) (*Controller, *FakeXdsUpdater, dynamic.Interface) {
+	fx := NewFakeXDS()
+	dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	c := NewController(fake.NewSimpleClientset(), Options{
+		WatchedNamespace: "",
+		ResyncPeriod:     resync,
+		DomainSuffix:     domainSuffix,
+		XDSUpdater:       fx,
+		EndpointMode:     EndpointSliceOnly,
+		DynamicClient:    dc,
+		stop:             make(chan struct{}),
+	})
+	_ = c.AppendInstanceHandler(func(instance *model.ServiceInstance, event model.Event) {})
+	_ = c.AppendServiceHandler(func(service *model.Service, event model.Event) {})
+	c.Env = &model.Environment{
+		Mesh: &meshconfig.MeshConfig{
+			MixerCheckServer: "mixer",
+		},
+	}
+	go c.Run(c.stop)
+	return c, fx, dc
+}
+
+func createEndpointSlice(dc dynamic.Interface, name, svcName, namespace string, endpoints []interface{},
+	portName string, port int64, t *testing.T) {
+	slice := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "discovery.k8s.io/v1alpha1",
+			"kind":       "EndpointSlice",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels": map[string]interface{}{
+					EndpointSliceServiceLabel: svcName,
+				},
+			},
+			"addressType": "IP",
+			"endpoints":   endpoints,
+			"ports": []interface{}{
+				map[string]interface{}{"name": portName, "port": port},
+			},
+		},
+	}
+	if _, err := dc.Resource(endpointSliceResource).Namespace(namespace).Create(slice, metaV1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create EndpointSlice %s in namespace %s (error %v)", name, namespace, err)
+	}
+}
+
+func TestEndpointSlices(t *testing.T) {
+	ctl, fx, dc := newFakeEndpointSliceController(t)
+	defer ctl.Stop()
+
+	ns := "nsa"
+	hostname := kube.ServiceHostname("svc1", ns, domainSuffix)
+
+	addNodes(t, ctl, generateNode("node2", map[string]string{NodeRegionLabel: "region2", NodeZoneLabel: "zone2"}))
+	pod1 := generatePod("128.0.0.1", "pod1", ns, "sa1", "node1", map[string]string{"app": "prod-app"}, map[string]string{})
+	pod2 := generatePod("128.0.0.2", "pod2", ns, "sa2", "node2", map[string]string{"app": "prod-app"}, map[string]string{})
+	addPods(t, ctl, pod1, pod2)
+	test.Eventually(t, "pods are cached", func() bool {
+		return ctl.pods.getPodByIP("128.0.0.1") != nil && ctl.pods.getPodByIP("128.0.0.2") != nil
+	})
+
+	createService(ctl, "svc1", ns, nil, []int32{8080}, map[string]string{"app": "prod-app"}, t)
+	if ev := fx.Wait("service"); ev == nil {
+		t.Fatal("Timeout creating service")
+	}
+
+	createEndpointSlice(dc, "svc1-abc", "svc1", ns, []interface{}{
+		// Locality taken from the topology of the endpoint.
+		map[string]interface{}{
+			"addresses":  []interface{}{"128.0.0.1"},
+			"conditions": map[string]interface{}{"ready": true},
+			"topology": map[string]interface{}{
+				TopologyRegionLabel: "region1",
+				TopologyZoneLabel:   "zone1",
+			},
+		},
+		// No topology, the locality of the node of the pod is used.
+		map[string]interface{}{
+			"addresses": []interface{}{"128.0.0.2"},
+		},
+		// Not ready endpoints are ignored.
+		map[string]interface{}{
+			"addresses":  []interface{}{"128.0.0.3"},
+			"conditions": map[string]interface{}{"ready": false},
+		},
+	}, "tcp-port", 8080, t)
+
+	if ev := fx.Wait("eds"); ev == nil || ev.ID != string(hostname) {
+		t.Fatalf("Expecting EDS update for %s, got %v", hostname, ev)
+	}
+
+	svc, _ := ctl.GetService(hostname)
+	if svc == nil {
+		t.Fatalf("GetService(%q) => should exist", hostname)
+	}
+
+	want := map[string]string{
+		"128.0.0.1": "region1/zone1",
+		"128.0.0.2": "region2/zone2",
+	}
+	test.Eventually(t, "instances are built from EndpointSlices", func() bool {
+		instances, err := ctl.InstancesByPort(svc, 8080, nil)
+		if err != nil || len(instances) != len(want) {
+			return false
+		}
+		for _, i := range instances {
+			if want[i.Endpoint.Address] != i.Endpoint.Locality || i.Endpoint.Port != 8080 {
+				return false
+			}
+		}
+		return true
+	})
+
+	fx.Clear()
+	if err := dc.Resource(endpointSliceResource).Namespace(ns).Delete("svc1-abc", &metaV1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if ev := fx.Wait("eds"); ev == nil || ev.ID != string(hostname) {
+		t.Fatalf("Expecting EDS update for %s, got %v", hostname, ev)
+	}
+	test.Eventually(t, "instances are removed with the EndpointSlice", func() bool {
+		instances, err := ctl.InstancesByPort(svc, 8080, nil)
+		return err == nil && len(instances) == 0
+	})
+}
@@ -34,6 +34,8 @@ import (
 	"istio.io/istio/security/pkg/cmd"
 	"istio.io/istio/security/pkg/k8s/controller"
 	"istio.io/istio/security/pkg/pki/ca"
+	"istio.io/istio/security/pkg/pki/signer"
+	"istio.io/istio/security/pkg/pki/util"
 	probecontroller "istio.io/istio/security/pkg/probe"
 	"istio.io/istio/security/pkg/registry"
 	"istio.io/istio/security/pkg/registry/kube"
@@ -60,8 +62,15 @@ type cliOptions struct { // nolint: maligned
 	signingKeyFile  string
 	rootCertFile    string
 
+	// The external signer holding the CA signing key, used instead of the signing key file if set.
+	externalSignerConfig signer.GRPCSignerConfig
+
 	selfSignedCA        bool
 	selfSignedCACertTTL time.Duration
+	// The self-signed root certificate is rotated when it expires within the threshold, if set.
+	rootCertRotationThreshold time.Duration
+	// The interval of checking the self-signed root certificate rotation.
+	rootCertRotationCheckInterval time.Duration
 
 	workloadCertTTL    time.Duration
 	maxWorkloadCertTTL time.Duration
@@ -81,6 +90,13 @@ type cliOptions struct { // nolint: maligned
 	signCACerts bool
 	// Whether to generate PKCS#8 private keys.
 	pkcs8Keys bool
+	// The ECC signature algorithm of the generated keys, RSA keys are generated if empty.
+	eccSigAlg string
+
+	// Comma separated identities allowed to revoke certificates, revocation is disabled if empty.
+	crlAdminIdentities string
+	// The validity of the certificate revocation list, which is signed again after half of it.
+	crlTTL time.Duration
 
 	cAClientConfig caclient.Config
 
@@ -114,6 +130,9 @@ type cliOptions struct { // nolint: maligned
 	sdsEnabled bool
 }
 
+// crlPath is the path of the certificate revocation list on the monitoring port.
+const crlPath = "/crl"
+
 var (
 	opts = cliOptions{
 		loggingOptions:       log.DefaultOptions(),
@@ -185,12 +204,32 @@ func initCLI() {
 	// Both self-signed or non-self-signed Citadel may take a root certificate file with a list of root certificates.
 	flags.StringVar(&opts.rootCertFile, "root-cert", "", "Path to the root certificate file.")
 
+	// Configuration if the CA signing key is held by an external signer, e.g. an HSM.
+	flags.StringVar(&opts.externalSignerConfig.Address, "external-signer-address", "", "The address of the external "+
+		"signer holding the key of '--signing-cert', e.g. unix:///var/run/istio-signer/socket. When set, "+
+		"'--signing-key' is not used.")
+	flags.StringVar(&opts.externalSignerConfig.RootCertFile, "external-signer-root-cert", "", "Path to the root "+
+		"certificate verifying the external signer. The connection is in plaintext if empty.")
+	flags.StringVar(&opts.externalSignerConfig.CertFile, "external-signer-client-cert", "", "Path to the client "+
+		"certificate authenticating Citadel to the external signer.")
+	flags.StringVar(&opts.externalSignerConfig.KeyFile, "external-signer-client-key", "", "Path to the client "+
+		"key authenticating Citadel to the external signer.")
+	flags.DurationVar(&opts.externalSignerConfig.Timeout, "external-signer-timeout", cmd.DefaultExternalSignerTimeout,
+		"The timeout of the requests to the external signer.")
+
 	// Configuration if Citadel acts as a self signed CA.
 	flags.BoolVar(&opts.selfSignedCA, "self-signed-ca", false,
 		"Indicates whether to use auto-generated self-signed CA certificate. "+
 			"When set to true, the '--signing-cert' and '--signing-key' options are ignored.")
 	flags.DurationVar(&opts.selfSignedCACertTTL, "self-signed-ca-cert-ttl", cmd.DefaultSelfSignedCACertTTL,
 		"The TTL of self-signed CA root certificate.")
+	flags.DurationVar(&opts.rootCertRotationThreshold, "root-cert-rotation-threshold", 0,
+		"When set, the self-signed CA root certificate is rotated when it expires within the threshold. The new root "+
+			"is first trusted along with the old root, then used for signing, and the old root is eventually retired, "+
+			"each phase lasting the max workload cert TTL. The threshold must be greater than twice the max workload "+
+			"cert TTL. The trusted roots and the rotation status are published in the "+ca.RootCertConfigMap+" ConfigMap.")
+	flags.DurationVar(&opts.rootCertRotationCheckInterval, "root-cert-rotation-check-interval",
+		cmd.DefaultRootCertRotationCheckInterval, "The interval of checking the self-signed CA root certificate rotation.")
 	flags.StringVar(&opts.trustDomain, "trust-domain", "",
 		"The domain serves to identify the system with SPIFFE.")
 	// Upstream CA configuration if Citadel interacts with upstream CA.
@@ -222,6 +261,15 @@ func initCLI() {
 
 	flags.BoolVar(&opts.signCACerts, "sign-ca-certs", false, "Whether Citadel signs certificates for other CAs.")
 	flags.BoolVar(&opts.pkcs8Keys, "pkcs8-keys", false, "Whether to generate PKCS#8 private keys.")
+	flags.StringVar(&opts.eccSigAlg, "ecc-signature-algorithm", "", "The ECC signature algorithm of the self-signed "+
+		"CA key and of the workload keys written to the secrets, only ECDSA is supported. RSA keys are generated if empty.")
+
+	// Certificate revocation configuration.
+	flags.StringVar(&opts.crlAdminIdentities, "crl-admin-identities", "", "The list of identities allowed to "+
+		"revoke certificates, separated by comma. When set, Citadel serves the RevokeCertificate API and publishes "+
+		"the certificate revocation list in the "+ca.CRLConfigMap+" ConfigMap and on the monitoring port at /crl.")
+	flags.DurationVar(&opts.crlTTL, "crl-ttl", cmd.DefaultCRLTTL, "The validity of the certificate revocation "+
+		"list. The list is signed again after half of its validity.")
 
 	// Monitoring configuration
 	flags.IntVar(&opts.monitoringPort, "monitoring-port", 15014, "The port number for monitoring Citadel. "+
@@ -316,6 +364,8 @@ func runCA() {
 	ca := createCA(cs.CoreV1())
 
 	stopCh := make(chan struct{})
+	revocationList := createRevocationList(ca, cs.CoreV1(), stopCh)
+	startRootCertRotator(ca, cs.CoreV1(), stopCh)
 	if !opts.serverOnly {
 		log.Infof("Creating Kubernetes controller to write issued keys and certs into secret ...")
 		// For workloads in K8s, we apply the configured workload cert TTL.
@@ -323,7 +373,8 @@ func runCA() {
 			opts.enableNamespacesByDefault,
 			opts.workloadCertTTL,
 			opts.workloadCertGracePeriodRatio, opts.workloadCertMinGracePeriod, opts.dualUse,
-			cs.CoreV1(), opts.signCACerts, opts.pkcs8Keys, listenedNamespaces, webhooks, opts.istioCaStorageNamespace)
+			cs.CoreV1(), opts.signCACerts, opts.pkcs8Keys, util.SupportedECSignatureAlgorithms(opts.eccSigAlg),
+			listenedNamespaces, webhooks, opts.istioCaStorageNamespace)
 		if err != nil {
 			fatalf("Failed to create secret controller: %v", err)
 		}
@@ -363,6 +414,9 @@ func runCA() {
 		if startErr != nil {
 			fatalf("Failed to create istio ca server: %v", startErr)
 		}
+		if revocationList != nil {
+			caServer.EnableRevocation(revocationList, strings.Split(opts.crlAdminIdentities, ","))
+		}
 		if serverErr := caServer.Run(); serverErr != nil {
 			// stop the registry-related controllers
 			ch <- struct{}{}
@@ -378,6 +432,9 @@ func runCA() {
 		if mErr != nil {
 			fatalf("Unable to setup monitoring: %v", mErr)
 		}
+		if revocationList != nil {
+			monitor.Handle(crlPath, revocationList)
+		}
 		go monitor.Start(monitorErrCh)
 		log.Info("Citadel monitor has started.")
 		defer monitor.Close()
@@ -419,6 +476,49 @@ func runCA() {
 	}
 }
 
+// createRevocationList returns the revocation list of the CA, or nil if certificate revocation is disabled.
+func createRevocationList(istioCA *ca.IstioCA, client corev1.CoreV1Interface,
+	stopCh <-chan struct{}) *ca.RevocationList {
+	if opts.crlAdminIdentities == "" {
+		return nil
+	}
+	// Revoked certificates are kept in the CRL until all the certificates issued before their revocation expired.
+	revocationList, err := ca.NewRevocationList(istioCA.GetCAKeyCertBundle(), opts.maxWorkloadCertTTL, opts.crlTTL,
+		opts.istioCaStorageNamespace, client)
+	if err != nil {
+		fatalf("Failed to create the certificate revocation list: %v", err)
+	}
+	go revocationList.Run(stopCh)
+	log.Infof("Certificate revocation is enabled for %s", opts.crlAdminIdentities)
+	return revocationList
+}
+
+// startRootCertRotator starts the rotation of the self-signed root certificate, if enabled.
+func startRootCertRotator(istioCA *ca.IstioCA, client corev1.CoreV1Interface, stopCh <-chan struct{}) {
+	if !opts.selfSignedCA || opts.rootCertRotationThreshold <= 0 {
+		return
+	}
+	// Each phase lasts until the certificates issued before it expired.
+	rotator, err := ca.NewRootCertRotator(istioCA.GetCAKeyCertBundle(), ca.RootCertRotatorConfig{
+		CheckInterval:     opts.rootCertRotationCheckInterval,
+		RotationThreshold: opts.rootCertRotationThreshold,
+		PhaseDuration:     opts.maxWorkloadCertTTL,
+		CACertTTL:         opts.selfSignedCACertTTL,
+		Org:               spiffe.GetTrustDomain(),
+		DualUse:           opts.dualUse,
+		ECSigAlg:          util.SupportedECSignatureAlgorithms(opts.eccSigAlg),
+		RootCertFile:      opts.rootCertFile,
+		ReadOnly:          opts.readSigningCertOnly,
+		Namespace:         opts.istioCaStorageNamespace,
+		Client:            client,
+	})
+	if err != nil {
+		fatalf("Failed to create the root certificate rotator: %v", err)
+	}
+	go rotator.Run(stopCh)
+	log.Infof("Root certificate rotation is enabled with threshold %v", opts.rootCertRotationThreshold)
+}
+
 func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 	var caOpts *ca.IstioCAOptions
 	var err error
@@ -437,10 +537,23 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 		}
 		caOpts, err = ca.NewSelfSignedIstioCAOptions(ctx, opts.selfSignedCACertTTL, opts.workloadCertTTL,
 			opts.maxWorkloadCertTTL, spiffe.GetTrustDomain(), opts.dualUse,
-			opts.istioCaStorageNamespace, checkInterval, client, opts.rootCertFile)
+			opts.istioCaStorageNamespace, checkInterval, client, opts.rootCertFile,
+			util.SupportedECSignatureAlgorithms(opts.eccSigAlg))
 		if err != nil {
 			fatalf("Failed to create a self-signed Citadel (error: %v)", err)
 		}
+	} else if opts.externalSignerConfig.Address != "" {
+		log.Infof("Use certificate from argument as the CA certificate, signed by the external signer %s",
+			opts.externalSignerConfig.Address)
+		externalSigner, signerErr := signer.NewGRPCSigner(opts.externalSignerConfig)
+		if signerErr != nil {
+			fatalf("Failed to connect to the external signer (error: %v)", signerErr)
+		}
+		caOpts, err = ca.NewExternalSignerIstioCAOptions(opts.certChainFile, opts.signingCertFile, opts.rootCertFile,
+			externalSigner, opts.workloadCertTTL, opts.maxWorkloadCertTTL, opts.istioCaStorageNamespace, client)
+		if err != nil {
+			fatalf("Failed to create an Citadel (error: %v)", err)
+		}
 	} else {
 		log.Info("Use certificate from argument as the CA certificate")
 		caOpts, err = ca.NewPluggedCertIstioCAOptions(opts.certChainFile, opts.signingCertFile, opts.signingKeyFile,
@@ -473,6 +586,15 @@ func createCA(client corev1.CoreV1Interface) *ca.IstioCA {
 }
 
 func verifyCommandLineOptions() {
+	if opts.externalSignerConfig.Address != "" {
+		if opts.selfSignedCA {
+			fatalf("'--external-signer-address' cannot be used with '--self-signed-ca'")
+		}
+		if len(opts.cAClientConfig.CAAddress) != 0 {
+			fatalf("'--external-signer-address' cannot be used with '--upstream-ca-address'")
+		}
+	}
+
 	if opts.selfSignedCA {
 		return
 	}
@@ -483,10 +605,10 @@ func verifyCommandLineOptions() {
 				"or use '-self-signed-ca'")
 	}
 
-	if opts.signingKeyFile == "" {
+	if opts.signingKeyFile == "" && opts.externalSignerConfig.Address == "" {
 		fatalf(
-			"No signing key has been specified. Either specify a key file via '-signing-key' option " +
-				"or use '-self-signed-ca'")
+			"No signing key has been specified. Either specify a key file via '-signing-key' option, " +
+				"an external signer via '-external-signer-address' option, or use '-self-signed-ca'")
 	}
 
 	if opts.rootCertFile == "" {
@@ -499,4 +621,19 @@ func verifyCommandLineOptions() {
 		fatalf("Workload cert grace period ratio %f is invalid. It should be within [0, 1]",
 			opts.workloadCertGracePeriodRatio)
 	}
+
+	if opts.eccSigAlg != "" && opts.eccSigAlg != string(util.EcdsaSigAlg) {
+		fatalf("ECC signature algorithm %q is not supported, only %s is supported", opts.eccSigAlg, util.EcdsaSigAlg)
+	}
+
+	if opts.crlAdminIdentities != "" && opts.crlTTL <= 0 {
+		fatalf("CRL TTL %v is invalid. It should be positive", opts.crlTTL)
+	}
+
+	// The CRL of the new root must be published before the CA switches to signing with it.
+	if opts.crlAdminIdentities != "" && opts.selfSignedCA && opts.rootCertRotationThreshold > 0 &&
+		opts.crlTTL/2 >= opts.maxWorkloadCertTTL {
+		fatalf("CRL TTL %v is invalid with root certificate rotation. Half of it should be less than the max "+
+			"workload cert TTL %v", opts.crlTTL, opts.maxWorkloadCertTTL)
+	}
 }
@@ -57,6 +57,8 @@ func init() {
 	flags.DurationVar(&cAClientConfig.RequestedCertTTL, "workload-cert-ttl", 90*24*time.Hour,
 		"The requested TTL for the workload")
 	flags.IntVar(&cAClientConfig.RSAKeySize, "key-size", 2048, "Size of generated private key")
+	flags.StringVar(&cAClientConfig.ECCSigAlg, "ecc-signature-algorithm", "",
+		"ECC signature algorithm of the generated private key, only ECDSA is supported. RSA is used if empty")
 	flags.StringVar(&cAClientConfig.CAAddress,
 		"ca-address", "istio-citadel:8060", "Istio CA address")
 
@@ -30,6 +30,7 @@ import (
 	"istio.io/istio/security/pkg/nodeagent/cache"
 	"istio.io/istio/security/pkg/nodeagent/sds"
 	"istio.io/istio/security/pkg/nodeagent/secretfetcher"
+	"istio.io/istio/security/pkg/pki/util"
 	"istio.io/istio/security/pkg/server/monitoring"
 	"istio.io/pkg/collateral"
 	"istio.io/pkg/env"
@@ -94,6 +95,11 @@ const (
 	// validate the certificate's format which is returned by CA.
 	skipValidateCertFlag = "SKIP_CERT_VALIDATION"
 
+	// The environmental variable name for the ECC signature algorithm of the workload keys.
+	// example value format like "ECDSA"
+	eccSigAlg     = "ECC_SIGNATURE_ALGORITHM"
+	eccSigAlgFlag = "eccSignatureAlgorithm"
+
 	// The environmental variable name for secret TTL, node agent decides whether a secret
 	// is expired if time.now - secret.createtime >= secretTTL.
 	// example value format like "90m"
@@ -235,6 +241,7 @@ var (
 	enableIngressGatewaySDSEnv         = env.RegisterBoolVar(enableIngressGatewaySDS, false, "").Get()
 	alwaysValidTokenFlagEnv            = env.RegisterBoolVar(alwaysValidTokenFlag, false, "").Get()
 	skipValidateCertFlagEnv            = env.RegisterBoolVar(skipValidateCertFlag, false, "").Get()
+	eccSigAlgEnv                       = env.RegisterStringVar(eccSigAlg, "", "").Get()
 	caProviderEnv                      = env.RegisterStringVar(caProvider, "", "").Get()
 	caEndpointEnv                      = env.RegisterStringVar(caEndpoint, "", "").Get()
 	trustDomainEnv                     = env.RegisterStringVar(trustDomain, "", "").Get()
@@ -321,6 +328,10 @@ func applyEnvVars(cmd *cobra.Command) {
 		workloadSdsCacheOptions.SkipValidateCert = skipValidateCertFlagEnv
 	}
 
+	if !cmd.Flag(eccSigAlgFlag).Changed {
+		workloadSdsCacheOptions.ECCSigAlg = eccSigAlgEnv
+	}
+
 	serverOptions.RecycleInterval = staledConnectionRecycleIntervalEnv
 
 	if !cmd.Flag(InitialBackoffFlag).Changed {
@@ -343,6 +354,10 @@ func validateOptions() error {
 		return fmt.Errorf("UDS paths for ingress gateway and workload cannot be the same: %s", serverOptions.IngressGatewayUDSPath)
 	}
 
+	if alg := workloadSdsCacheOptions.ECCSigAlg; alg != "" && alg != string(util.EcdsaSigAlg) {
+		return fmt.Errorf("unsupported ECC signature algorithm %q, only %s is supported", alg, util.EcdsaSigAlg)
+	}
+
 	if serverOptions.EnableWorkloadSDS {
 		if serverOptions.CAProviderName == "" {
 			return fmt.Errorf("CA provider cannot be empty when workload SDS is enabled")
@@ -399,6 +414,9 @@ func main() {
 		false,
 		"If true, node agent skip validating format of certificate returned from CA.")
 
+	rootCmd.PersistentFlags().StringVar(&workloadSdsCacheOptions.ECCSigAlg, eccSigAlgFlag, "",
+		"The ECC signature algorithm of the workload keys, only ECDSA is supported. RSA keys are generated if empty.")
+
 	rootCmd.PersistentFlags().StringVar(&serverOptions.VaultAddress, vaultAddressFlag, "",
 		"Vault address")
 	rootCmd.PersistentFlags().StringVar(&serverOptions.VaultRole, vaultRoleFlag, "",
@@ -32,6 +32,9 @@ type Config struct {
 	// Size of RSA private key
 	RSAKeySize int
 
+	// ECC signature algorithm of the private key, only ECDSA is supported. An RSA key is generated if empty.
+	ECCSigAlg string
+
 	// The environment this CA client is running on.
 	Env string
 
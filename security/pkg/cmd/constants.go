@@ -36,6 +36,16 @@ const (
 	// DefaultWorkloadMinCertGracePeriod is the default minimum grace period for workload cert rotation.
 	DefaultWorkloadMinCertGracePeriod = 10 * time.Minute
 
+	// DefaultCRLTTL is the default validity of the certificate revocation list.
+	DefaultCRLTTL = 24 * time.Hour
+
+	// DefaultRootCertRotationCheckInterval is the default interval of checking the self-signed root
+	// certificate rotation.
+	DefaultRootCertRotationCheckInterval = time.Hour
+
+	// DefaultExternalSignerTimeout is the default timeout of the requests to the external signer.
+	DefaultExternalSignerTimeout = 10 * time.Second
+
 	// DefaultProbeCheckInterval is the default interval of checking the liveness of the CA.
 	DefaultProbeCheckInterval = 30 * time.Second
 
@@ -109,6 +109,9 @@ type SecretController struct {
 	// If true, generate a PKCS#8 private key.
 	pkcs8Key bool
 
+	// The ECC signature algorithm of the generated keys. RSA keys are generated if empty.
+	ecSigAlg util.SupportedECSignatureAlgorithms
+
 	// The set of namespaces explicitly set for monitoring via commandline (an entry could be metav1.NamespaceAll)
 	namespaces map[string]struct{}
 
@@ -135,7 +138,7 @@ type SecretController struct {
 // NewSecretController returns a pointer to a newly constructed SecretController instance.
 func NewSecretController(ca ca.CertificateAuthority, enableNamespacesByDefault bool, certTTL time.Duration,
 	gracePeriodRatio float32, minGracePeriod time.Duration, dualUse bool,
-	core corev1.CoreV1Interface, forCA bool, pkcs8Key bool, namespaces []string,
+	core corev1.CoreV1Interface, forCA bool, pkcs8Key bool, ecSigAlg util.SupportedECSignatureAlgorithms, namespaces []string,
 	dnsNames map[string]*DNSNameEntry, istioCaStorageNamespace string) (*SecretController, error) {
 
 	if gracePeriodRatio < 0 || gracePeriodRatio > 1 {
@@ -157,6 +160,7 @@ func NewSecretController(ca ca.CertificateAuthority, enableNamespacesByDefault b
 		core:                      core,
 		forCA:                     forCA,
 		pkcs8Key:                  pkcs8Key,
+		ecSigAlg:                  ecSigAlg,
 		namespaces:                make(map[string]struct{}),
 		dnsNames:                  dnsNames,
 		monitoring:                newMonitoringMetrics(),
@@ -424,6 +428,7 @@ func (sc *SecretController) generateKeyAndCert(saName string, saNamespace string
 		RSAKeySize: keySize,
 		IsDualUse:  sc.dualUse,
 		PKCS8Key:   sc.pkcs8Key,
+		ECSigAlg:   sc.ecSigAlg,
 	}
 
 	csrPEM, keyPEM, err := util.GenCSR(options)
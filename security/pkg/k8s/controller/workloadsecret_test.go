@@ -153,7 +153,7 @@ func TestSecretController(t *testing.T) {
 			},
 		}
 		controller, err := NewSecretController(createFakeCA(), enableNamespacesByDefault, defaultTTL,
-			tc.gracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false,
+			tc.gracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false, "",
 			[]string{metav1.NamespaceAll}, webhooks, "test-ns")
 		if tc.shouldFail {
 			if err == nil {
@@ -191,7 +191,7 @@ func TestSecretContent(t *testing.T) {
 	saNamespace := "test-namespace"
 	client := fake.NewSimpleClientset()
 	controller, err := NewSecretController(createFakeCA(), enableNamespacesByDefault, defaultTTL,
-		defaultGracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false,
+		defaultGracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false, "",
 		[]string{metav1.NamespaceAll}, map[string]*DNSNameEntry{}, "test-namespace")
 	if err != nil {
 		t.Errorf("Failed to create secret controller: %v", err)
@@ -214,7 +214,7 @@ func TestSecretContent(t *testing.T) {
 func TestDeletedIstioSecret(t *testing.T) {
 	client := fake.NewSimpleClientset()
 	controller, err := NewSecretController(createFakeCA(), enableNamespacesByDefault, defaultTTL,
-		defaultGracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false,
+		defaultGracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false, "",
 		[]string{metav1.NamespaceAll}, nil, "test-ns")
 	if err != nil {
 		t.Errorf("failed to create secret controller: %v", err)
@@ -348,7 +348,7 @@ func TestUpdateSecret(t *testing.T) {
 		client := fake.NewSimpleClientset()
 
 		controller, err := NewSecretController(createFakeCA(), enableNamespacesByDefault, time.Hour,
-			tc.gracePeriodRatio, tc.minGracePeriod, false, client.CoreV1(), false, false,
+			tc.gracePeriodRatio, tc.minGracePeriod, false, client.CoreV1(), false, false, "",
 			[]string{metav1.NamespaceAll}, nil, "")
 		if err != nil {
 			t.Errorf("failed to create secret controller: %v", err)
@@ -423,7 +423,7 @@ func TestManagedNamespaceRules(t *testing.T) {
 		t.Run(k, func(t *testing.T) {
 			client := fake.NewSimpleClientset()
 			controller, err := NewSecretController(createFakeCA(), tc.enableNamespacesByDefault, defaultTTL,
-				defaultGracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false,
+				defaultGracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false, "",
 				[]string{metav1.NamespaceAll}, nil, tc.istioCaStorageNamespace)
 			if err != nil {
 				t.Errorf("failed to create secret controller: %v", err)
@@ -497,7 +497,7 @@ func TestRetroactiveNamespaceActivation(t *testing.T) {
 		t.Run(k, func(t *testing.T) {
 			client := fake.NewSimpleClientset()
 			controller, err := NewSecretController(createFakeCA(), tc.enableNamespacesByDefault, defaultTTL,
-				defaultGracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false,
+				defaultGracePeriodRatio, defaultMinGracePeriod, false, client.CoreV1(), false, false, "",
 				[]string{metav1.NamespaceAll}, nil, tc.istioCaStorageNamespace)
 			if err != nil {
 				t.Errorf("failed to create secret controller: %v", err)
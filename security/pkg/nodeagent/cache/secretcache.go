@@ -105,6 +105,9 @@ type Options struct {
 
 	// set this flag to true if skip validate format for certificate chain returned from CA.
 	SkipValidateCert bool
+
+	// ECC signature algorithm of the workload keys, only ECDSA is supported. RSA keys are generated if empty.
+	ECCSigAlg string
 }
 
 // SecretManager defines secrets management interface which is used by SDS.
@@ -589,6 +592,7 @@ func (sc *SecretCache) generateSecret(ctx context.Context, token string, connKey
 	options := util.CertOptions{
 		Host:       csrHostName,
 		RSAKeySize: keySize,
+		ECSigAlg:   util.SupportedECSignatureAlgorithms(sc.configOptions.ECCSigAlg),
 	}
 
 	// Generate the cert/key, send CSR to CA.
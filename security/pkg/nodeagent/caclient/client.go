@@ -17,6 +17,7 @@ package caclient
 import (
 	"encoding/base64"
 	"fmt"
+	"io/ioutil"
 	"strings"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 	caClientInterface "istio.io/istio/security/pkg/nodeagent/caclient/interface"
 	citadel "istio.io/istio/security/pkg/nodeagent/caclient/providers/citadel"
 	gca "istio.io/istio/security/pkg/nodeagent/caclient/providers/google"
+	k8scsr "istio.io/istio/security/pkg/nodeagent/caclient/providers/kubernetes"
 	vault "istio.io/istio/security/pkg/nodeagent/caclient/providers/vault"
 	"istio.io/pkg/env"
 	"istio.io/pkg/log"
@@ -34,11 +36,17 @@ const (
 	googleCAName  = "GoogleCA"
 	citadelName   = "Citadel"
 	vaultCAName   = "VaultCA"
+	k8sCSRName    = "KubernetesCSR"
 	retryInterval = time.Second * 2
 	maxRetries    = 100
 )
 
-var namespace = env.RegisterStringVar("NAMESPACE", "istio-system", "namespace that nodeagent/citadel run in").Get()
+var (
+	namespace          = env.RegisterStringVar("NAMESPACE", "istio-system", "namespace that nodeagent/citadel run in").Get()
+	k8sCSRRootCertFile = env.RegisterStringVar("K8S_CSR_ROOT_CERT",
+		"/var/run/secrets/kubernetes.io/serviceaccount/ca.crt",
+		"path of the root certificate of the Kubernetes CSR signer, the cluster CA by default").Get()
+)
 
 type configMap interface {
 	GetCATLSRootCert() (string, error)
@@ -63,9 +71,19 @@ func NewCAClient(endpoint, caProviderName string, tlsFlag bool, tlsRootCert []by
 			return nil, err
 		}
 		return citadel.NewCitadelClient(endpoint, tlsFlag, rootCert)
+	case k8sCSRName:
+		cs, err := kube.CreateClientset("", "")
+		if err != nil {
+			return nil, fmt.Errorf("could not create k8s clientset: %v", err)
+		}
+		rootCert, err := ioutil.ReadFile(k8sCSRRootCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the Kubernetes CSR signer root cert: %v", err)
+		}
+		return k8scsr.NewKubernetesCSRClient(cs.CertificatesV1beta1().CertificateSigningRequests(), rootCert)
 	default:
 		return nil, fmt.Errorf(
-			"CA provider %q isn't supported. Currently Istio supports %q", caProviderName, strings.Join([]string{googleCAName, citadelName, vaultCAName}, ","))
+			"CA provider %q isn't supported. Currently Istio supports %q", caProviderName, strings.Join([]string{googleCAName, citadelName, vaultCAName, k8sCSRName}, ","))
 	}
 }
 
@@ -35,6 +35,7 @@ var (
 )
 
 type mockCAServer struct {
+	pb.UnimplementedIstioCertificateServiceServer
 	Certs []string
 	Err   error
 }
@@ -0,0 +1,172 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	certv1beta1 "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	certclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+
+	caClientInterface "istio.io/istio/security/pkg/nodeagent/caclient/interface"
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+const (
+	// csrNamePrefix is the name prefix of the CertificateSigningRequests created by the client.
+	csrNamePrefix = "istio-csr-"
+	// defaultPollInterval is the interval of checking whether a CertificateSigningRequest is issued.
+	defaultPollInterval = time.Second
+	// defaultIssueTimeout is the time a CertificateSigningRequest is given to be approved and issued.
+	defaultIssueTimeout = time.Minute
+)
+
+var (
+	k8sCSRClientLog = log.RegisterScope("k8sCSRClientLog", "Kubernetes CSR client debugging", 0)
+)
+
+type kubernetesCSRClient struct {
+	client   certclient.CertificateSigningRequestInterface
+	rootCert []byte
+
+	pollInterval time.Duration
+	issueTimeout time.Duration
+}
+
+// NewKubernetesCSRClient create a CA client submitting the CSRs to the Kubernetes CSR API. The CSRs are approved
+// and signed by the signers of the cluster, e.g. the Kubernetes controller manager or cert-manager, whose root
+// certificate is rootCert.
+func NewKubernetesCSRClient(client certclient.CertificateSigningRequestInterface,
+	rootCert []byte) (caClientInterface.Client, error) {
+	if block, _ := pem.Decode(rootCert); block == nil {
+		return nil, fmt.Errorf("invalid root certificate for the Kubernetes CSR signer")
+	}
+	return &kubernetesCSRClient{
+		client:       client,
+		rootCert:     rootCert,
+		pollInterval: defaultPollInterval,
+		issueTimeout: defaultIssueTimeout,
+	}, nil
+}
+
+// CSR Sign submits the CSR as a CertificateSigningRequest, and waits until it is approved and issued. The TTL is
+// decided by the signer, as the Kubernetes CSR API does not allow requesting one.
+func (c *kubernetesCSRClient) CSRSign(ctx context.Context, csrPEM []byte, subjectID string,
+	certValidTTLInSec int64) ([]string /*PEM-encoded certificate chain*/, error) {
+	usages, err := keyUsages(csrPEM)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	csr := &certv1beta1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: csrNamePrefix + rand.String(10),
+		},
+		Spec: certv1beta1.CertificateSigningRequestSpec{
+			Request: csrPEM,
+			Usages:  usages,
+		},
+	}
+	csr, err = c.client.Create(csr)
+	if err != nil {
+		k8sCSRClientLog.Errorf("Failed to create the CertificateSigningRequest: %v", err)
+		return nil, status.Errorf(codes.Unavailable, "failed to create the CertificateSigningRequest: %v", err)
+	}
+	name := csr.Name
+	// The issued certificate is only read once, the CertificateSigningRequest is not kept.
+	defer func() {
+		if err := c.client.Delete(name, &metav1.DeleteOptions{}); err != nil {
+			k8sCSRClientLog.Warnf("Failed to delete the CertificateSigningRequest %s: %v", name, err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, c.issueTimeout)
+	defer cancel()
+	var certificate []byte
+	err = wait.PollImmediateUntil(c.pollInterval, func() (bool, error) {
+		issued, err := c.client.Get(name, metav1.GetOptions{})
+		if err != nil {
+			k8sCSRClientLog.Warnf("Failed to get the CertificateSigningRequest %s: %v", name, err)
+			return false, nil
+		}
+		for _, condition := range issued.Status.Conditions {
+			if condition.Type == certv1beta1.CertificateDenied {
+				return false, status.Errorf(codes.PermissionDenied, "the CertificateSigningRequest %s is denied: %s",
+					name, condition.Message)
+			}
+		}
+		certificate = issued.Status.Certificate
+		return len(certificate) > 0, nil
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		return nil, status.Errorf(codes.DeadlineExceeded, "the CertificateSigningRequest %s is not issued in %v",
+			name, c.issueTimeout)
+	}
+	if err != nil {
+		k8sCSRClientLog.Errorf("The CertificateSigningRequest %s failed: %v", name, err)
+		return nil, err
+	}
+
+	certChain, err := c.certChain(certificate)
+	if err != nil {
+		k8sCSRClientLog.Errorf("Invalid certificate issued for the CertificateSigningRequest %s: %v", name, err)
+		return nil, fmt.Errorf("invalid certificate issued for the CertificateSigningRequest %s: %v", name, err)
+	}
+	return certChain, nil
+}
+
+// certChain splits the issued certificates, and appends the root certificate if the signer did not.
+func (c *kubernetesCSRClient) certChain(certificate []byte) ([]string, error) {
+	var certChain []string
+	for rest := certificate; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		certChain = append(certChain, string(pem.EncodeToMemory(block)))
+	}
+	if len(certChain) == 0 {
+		return nil, fmt.Errorf("no PEM-encoded certificate")
+	}
+	if !bytes.Equal(bytes.TrimSpace([]byte(certChain[len(certChain)-1])), bytes.TrimSpace(c.rootCert)) {
+		certChain = append(certChain, string(c.rootCert))
+	}
+	return certChain, nil
+}
+
+// keyUsages returns the usages to request for the CSR. Key encipherment only applies to RSA keys, ECDSA keys
+// are only used for signing.
+func keyUsages(csrPEM []byte) ([]certv1beta1.KeyUsage, error) {
+	csr, err := util.ParsePemEncodedCSR(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	usages := []certv1beta1.KeyUsage{certv1beta1.UsageDigitalSignature}
+	if _, ok := csr.PublicKey.(*rsa.PublicKey); ok {
+		usages = append(usages, certv1beta1.UsageKeyEncipherment)
+	}
+	return append(usages, certv1beta1.UsageServerAuth, certv1beta1.UsageClientAuth), nil
+}
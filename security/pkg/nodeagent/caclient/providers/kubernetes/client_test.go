@@ -0,0 +1,204 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caclient
+
+import (
+	"context"
+	"encoding/pem"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	certv1beta1 "k8s.io/api/certificates/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	certclient "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// approver approves or denies the CertificateSigningRequests, and issues the approved ones with the CA, as the
+// Kubernetes controller manager does.
+func approver(t *testing.T, client certclient.CertificateSigningRequestInterface, ca *util.KeyCertBundleImpl,
+	approve bool, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		csrs, err := client.List(metav1.ListOptions{})
+		if err != nil {
+			t.Errorf("Failed to list the CertificateSigningRequests: %v", err)
+			return
+		}
+		for i := range csrs.Items {
+			csr := &csrs.Items[i]
+			if len(csr.Status.Conditions) > 0 {
+				continue
+			}
+			if !approve {
+				csr.Status.Conditions = []certv1beta1.CertificateSigningRequestCondition{
+					{Type: certv1beta1.CertificateDenied, Message: "not allowed"}}
+				if _, err := client.UpdateApproval(csr); err != nil {
+					t.Errorf("Failed to deny the CertificateSigningRequest: %v", err)
+				}
+				continue
+			}
+			csr.Status.Conditions = []certv1beta1.CertificateSigningRequestCondition{{Type: certv1beta1.CertificateApproved}}
+			if csr, err = client.UpdateApproval(csr); err != nil {
+				t.Errorf("Failed to approve the CertificateSigningRequest: %v", err)
+				continue
+			}
+			parsedCSR, err := util.ParsePemEncodedCSR(csr.Spec.Request)
+			if err != nil {
+				t.Errorf("Failed to parse the CSR: %v", err)
+				continue
+			}
+			signingCert, signingKey, _, _ := ca.GetAll()
+			certificate, err := util.GenCertFromCSR(parsedCSR, signingCert, parsedCSR.PublicKey, *signingKey,
+				[]string{"spiffe://cluster.local/ns/default/sa/default"}, time.Hour, false)
+			if err != nil {
+				t.Errorf("Failed to sign the CSR: %v", err)
+				continue
+			}
+			csr.Status.Certificate = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})
+			if _, err := client.UpdateStatus(csr); err != nil {
+				t.Errorf("Failed to issue the CertificateSigningRequest: %v", err)
+			}
+		}
+	}
+}
+
+func TestKubernetesCSRClient(t *testing.T) {
+	ca, err := util.NewVerifiedKeyCertBundleFromFile("../../../../pki/testdata/multilevelpki/root-cert.pem",
+		"../../../../pki/testdata/multilevelpki/root-key.pem", "", "../../../../pki/testdata/multilevelpki/root-cert.pem")
+	if err != nil {
+		t.Fatalf("Failed to load the CA: %v", err)
+	}
+	_, _, _, rootCert := ca.GetAllPem()
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: "spiffe://cluster.local/ns/default/sa/default", RSAKeySize: 2048})
+	if err != nil {
+		t.Fatalf("Failed to generate the CSR: %v", err)
+	}
+
+	testCases := map[string]struct {
+		approve      bool
+		runApprover  bool
+		expectedCode codes.Code
+	}{
+		"approved": {
+			approve:     true,
+			runApprover: true,
+		},
+		"denied": {
+			approve:      false,
+			runApprover:  true,
+			expectedCode: codes.PermissionDenied,
+		},
+		"not issued": {
+			runApprover:  false,
+			expectedCode: codes.DeadlineExceeded,
+		},
+	}
+	for id, tc := range testCases {
+		t.Run(id, func(t *testing.T) {
+			csrClient := fake.NewSimpleClientset().CertificatesV1beta1().CertificateSigningRequests()
+			stop := make(chan struct{})
+			defer close(stop)
+			if tc.runApprover {
+				go approver(t, csrClient, ca, tc.approve, stop)
+			}
+
+			client, err := NewKubernetesCSRClient(csrClient, rootCert)
+			if err != nil {
+				t.Fatalf("Failed to create the client: %v", err)
+			}
+			client.(*kubernetesCSRClient).pollInterval = 10 * time.Millisecond
+			client.(*kubernetesCSRClient).issueTimeout = 500 * time.Millisecond
+
+			certChain, err := client.CSRSign(context.Background(), csrPEM, "token", 3600)
+			if tc.expectedCode != codes.OK {
+				if status.Code(err) != tc.expectedCode {
+					t.Errorf("Expected error code %v, got %v", tc.expectedCode, err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Failed to sign the CSR: %v", err)
+				}
+				if len(certChain) != 2 || !strings.Contains(certChain[0], "BEGIN CERTIFICATE") ||
+					strings.TrimSpace(certChain[1]) != strings.TrimSpace(string(rootCert)) {
+					t.Errorf("Unexpected certificate chain %v", certChain)
+				}
+			}
+
+			// The CertificateSigningRequest is deleted once done.
+			csrs, err := csrClient.List(metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("Failed to list the CertificateSigningRequests: %v", err)
+			}
+			if len(csrs.Items) != 0 {
+				t.Errorf("The CertificateSigningRequests are not deleted: %v", csrs.Items)
+			}
+		})
+	}
+}
+
+func TestNewKubernetesCSRClientInvalidRootCert(t *testing.T) {
+	if _, err := NewKubernetesCSRClient(fake.NewSimpleClientset().CertificatesV1beta1().CertificateSigningRequests(),
+		[]byte("invalid")); err == nil {
+		t.Errorf("Expected an error with an invalid root cert")
+	}
+}
+
+func TestKeyUsages(t *testing.T) {
+	testCases := map[string]struct {
+		options  util.CertOptions
+		expected []certv1beta1.KeyUsage
+	}{
+		"RSA": {
+			options: util.CertOptions{Host: "spiffe://cluster.local/ns/default/sa/default", RSAKeySize: 2048},
+			expected: []certv1beta1.KeyUsage{certv1beta1.UsageDigitalSignature, certv1beta1.UsageKeyEncipherment,
+				certv1beta1.UsageServerAuth, certv1beta1.UsageClientAuth},
+		},
+		"ECDSA": {
+			options: util.CertOptions{Host: "spiffe://cluster.local/ns/default/sa/default", ECSigAlg: util.EcdsaSigAlg},
+			expected: []certv1beta1.KeyUsage{certv1beta1.UsageDigitalSignature,
+				certv1beta1.UsageServerAuth, certv1beta1.UsageClientAuth},
+		},
+	}
+	for id, tc := range testCases {
+		t.Run(id, func(t *testing.T) {
+			csrPEM, _, err := util.GenCSR(tc.options)
+			if err != nil {
+				t.Fatalf("Failed to generate the CSR: %v", err)
+			}
+			usages, err := keyUsages(csrPEM)
+			if err != nil {
+				t.Fatalf("Failed to get the key usages: %v", err)
+			}
+			if !reflect.DeepEqual(usages, tc.expected) {
+				t.Errorf("Expected key usages %v, got %v", tc.expected, usages)
+			}
+		})
+	}
+
+	if _, err := keyUsages([]byte("invalid")); err == nil {
+		t.Error("Expected an error for an invalid CSR")
+	}
+}
@@ -118,6 +118,7 @@ func (na *nodeAgentInternal) createRequest() ([]byte, *pb.CsrRequest, error) {
 		Host:       na.identity,
 		Org:        na.config.CAClientConfig.Org,
 		RSAKeySize: na.config.CAClientConfig.RSAKeySize,
+		ECSigAlg:   pkiutil.SupportedECSignatureAlgorithms(na.config.CAClientConfig.ECCSigAlg),
 		IsDualUse:  na.config.DualUse,
 	})
 	if err != nil {
@@ -16,6 +16,7 @@ package ca
 
 import (
 	"context"
+	"crypto"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
@@ -67,6 +68,8 @@ const (
 	selfSignedCA caTypes = iota
 	// pluggedCertCA means the Istio CA uses a operator-specified key/cert.
 	pluggedCertCA
+	// externalSignerCA means the Istio CA uses a operator-specified cert, whose key is held by an external signer.
+	externalSignerCA
 )
 
 // CertificateAuthority contains methods to be supported by a CA.
@@ -129,8 +132,10 @@ func appendRootCerts(pemCert []byte, rootCertFile string) ([]byte, error) {
 }
 
 // NewSelfSignedIstioCAOptions returns a new IstioCAOptions instance using self-signed certificate.
+// The self-signed key is an RSA key, unless ecSigAlg is set.
 func NewSelfSignedIstioCAOptions(ctx context.Context, caCertTTL, certTTL, maxCertTTL time.Duration, org string, dualUse bool,
-	namespace string, readCertRetryInterval time.Duration, client corev1.CoreV1Interface, rootCertFile string) (caOpts *IstioCAOptions, err error) {
+	namespace string, readCertRetryInterval time.Duration, client corev1.CoreV1Interface, rootCertFile string,
+	ecSigAlg util.SupportedECSignatureAlgorithms) (caOpts *IstioCAOptions, err error) {
 	// For the first time the CA is up, if readSigningCertOnly is unset,
 	// it generates a self-signed key/cert pair and write it to CASecret.
 	// For subsequent restart, CA will reads key/cert from CASecret.
@@ -160,14 +165,7 @@ func NewSelfSignedIstioCAOptions(ctx context.Context, caCertTTL, certTTL, maxCer
 	if scrtErr != nil {
 		log.Infof("Failed to get secret (error: %s), will create one", scrtErr)
 
-		options := util.CertOptions{
-			TTL:          caCertTTL,
-			Org:          org,
-			IsCA:         true,
-			IsSelfSigned: true,
-			RSAKeySize:   caKeySize,
-			IsDualUse:    dualUse,
-		}
+		options := selfSignedCACertOptions(caCertTTL, org, dualUse, ecSigAlg)
 		pemCert, pemKey, ckErr := util.GenCertKeyFromOptions(options)
 		if ckErr != nil {
 			return nil, fmt.Errorf("unable to generate CA cert and key for self-signed CA (%v)", ckErr)
@@ -191,7 +189,8 @@ func NewSelfSignedIstioCAOptions(ctx context.Context, caCertTTL, certTTL, maxCer
 		log.Infof("Using self-generated public key: %v", string(rootCerts))
 	} else {
 		log.Infof("Load signing key and cert from existing secret %s:%s", caSecret.Namespace, caSecret.Name)
-		rootCerts, err := appendRootCerts(caSecret.Data[caCertID], rootCertFile)
+		// During a root rotation, the secret also holds the new or the retiring root.
+		rootCerts, err := appendRootCerts(trustedRootCerts(caSecret.Data), rootCertFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to append root certificates (%v)", err)
 		}
@@ -208,6 +207,20 @@ func NewSelfSignedIstioCAOptions(ctx context.Context, caCertTTL, certTTL, maxCer
 	return caOpts, nil
 }
 
+// selfSignedCACertOptions returns the options to generate the self-signed root certificate of the CA.
+func selfSignedCACertOptions(caCertTTL time.Duration, org string, dualUse bool,
+	ecSigAlg util.SupportedECSignatureAlgorithms) util.CertOptions {
+	return util.CertOptions{
+		TTL:          caCertTTL,
+		Org:          org,
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   caKeySize,
+		ECSigAlg:     ecSigAlg,
+		IsDualUse:    dualUse,
+	}
+}
+
 // NewPluggedCertIstioCAOptions returns a new IstioCAOptions instance using given certificate.
 func NewPluggedCertIstioCAOptions(certChainFile, signingCertFile, signingKeyFile, rootCertFile string,
 	certTTL, maxCertTTL time.Duration, namespace string, client corev1.CoreV1Interface) (caOpts *IstioCAOptions, err error) {
@@ -220,34 +233,75 @@ func NewPluggedCertIstioCAOptions(certChainFile, signingCertFile, signingKeyFile
 		signingCertFile, signingKeyFile, certChainFile, rootCertFile); err != nil {
 		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
 	}
+	if err = initPluggedCert(caOpts.KeyCertBundle, signingCertFile, namespace, client); err != nil {
+		return nil, err
+	}
+	return caOpts, nil
+}
+
+// NewExternalSignerIstioCAOptions returns a new IstioCAOptions instance using given certificate, whose private key
+// is held by the external signer, e.g. in an HSM, and never loaded by the CA.
+func NewExternalSignerIstioCAOptions(certChainFile, signingCertFile, rootCertFile string, signer crypto.Signer,
+	certTTL, maxCertTTL time.Duration, namespace string, client corev1.CoreV1Interface) (caOpts *IstioCAOptions, err error) {
+	caOpts = &IstioCAOptions{
+		CAType:     externalSignerCA,
+		CertTTL:    certTTL,
+		MaxCertTTL: maxCertTTL,
+	}
+	certBytes, err := ioutil.ReadFile(signingCertFile)
+	if err != nil {
+		return nil, err
+	}
+	certChainBytes := []byte{}
+	if len(certChainFile) != 0 {
+		if certChainBytes, err = ioutil.ReadFile(certChainFile); err != nil {
+			return nil, err
+		}
+	}
+	rootCertBytes, err := ioutil.ReadFile(rootCertFile)
+	if err != nil {
+		return nil, err
+	}
+	if caOpts.KeyCertBundle, err = util.NewVerifiedKeyCertBundleFromSigner(
+		certBytes, signer, certChainBytes, rootCertBytes); err != nil {
+		return nil, fmt.Errorf("failed to create CA KeyCertBundle (%v)", err)
+	}
+	if err = initPluggedCert(caOpts.KeyCertBundle, signingCertFile, namespace, client); err != nil {
+		return nil, err
+	}
+	return caOpts, nil
+}
 
+// initPluggedCert validates the operator-specified signing cert, and writes the cert chain to the configmap.
+func initPluggedCert(keyCertBundle util.KeyCertBundle, signingCertFile, namespace string,
+	client corev1.CoreV1Interface) error {
 	// Validate that the passed in signing cert can be used as CA.
 	// The check can't be done inside `KeyCertBundle`, since bundle could also be used to
 	// validate workload certificates (i.e., where the leaf certificate is not a CA).
 	b, err := ioutil.ReadFile(signingCertFile)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	block, _ := pem.Decode(b)
 	if block == nil {
-		return nil, fmt.Errorf("invalid PEM encoded certificate")
+		return fmt.Errorf("invalid PEM encoded certificate")
 	}
 	cert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse X.509 certificate")
+		return fmt.Errorf("failed to parse X.509 certificate")
 	}
 	if !cert.IsCA {
-		return nil, fmt.Errorf("certificate is not authorized to sign other certificates")
+		return fmt.Errorf("certificate is not authorized to sign other certificates")
 	}
 
-	crt := caOpts.KeyCertBundle.GetCertChainPem()
+	crt := keyCertBundle.GetCertChainPem()
 	if len(crt) == 0 {
-		crt = caOpts.KeyCertBundle.GetRootCertPem()
+		crt = keyCertBundle.GetRootCertPem()
 	}
 	if err = updateCertInConfigmap(namespace, client, crt); err != nil {
 		log.Errorf("Failed to write Citadel cert to configmap (%v). Node agents will not be able to connect.", err)
 	}
-	return caOpts, nil
+	return nil
 }
 
 // NewIstioCA returns a new IstioCA instance.
@@ -17,6 +17,7 @@ package ca
 import (
 	"bytes"
 	"context"
+	"crypto"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
@@ -96,7 +97,7 @@ func TestCreateSelfSignedIstioCAWithoutSecret(t *testing.T) {
 	rootCertFile := ""
 
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), caCertTTL, defaultCertTTL, maxCertTTL,
-		org, false, caNamespace, -1, client.CoreV1(), rootCertFile)
+		org, false, caNamespace, -1, client.CoreV1(), rootCertFile, "")
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -183,7 +184,7 @@ func TestCreateSelfSignedIstioCAWithSecret(t *testing.T) {
 	const rootCertFile = ""
 
 	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), caCertTTL, certTTL, maxCertTTL,
-		org, false, caNamespace, -1, client.CoreV1(), rootCertFile)
+		org, false, caNamespace, -1, client.CoreV1(), rootCertFile, "")
 	if err != nil {
 		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
 	}
@@ -251,7 +252,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	ctx0, cancel0 := context.WithTimeout(context.Background(), time.Millisecond*50)
 	defer cancel0()
 	_, err := NewSelfSignedIstioCAOptions(ctx0, caCertTTL, certTTL, maxCertTTL,
-		org, false, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile)
+		org, false, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, "")
 	if err == nil {
 		t.Errorf("Expected error, but succeeded.")
 	} else if err.Error() != expectedErr {
@@ -269,7 +270,7 @@ func TestCreateSelfSignedIstioCAReadSigningCertOnly(t *testing.T) {
 	ctx1, cancel1 := context.WithCancel(context.Background())
 	defer cancel1()
 	caopts, err := NewSelfSignedIstioCAOptions(ctx1, caCertTTL, certTTL, maxCertTTL,
-		org, false, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile)
+		org, false, caNamespace, time.Millisecond*10, client.CoreV1(), rootCertFile, "")
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -358,6 +359,54 @@ func TestCreatePluggedCertCA(t *testing.T) {
 	}
 }
 
+func TestCreateExternalSignerCA(t *testing.T) {
+	rootCertFile := "../testdata/multilevelpki/root-cert.pem"
+	certChainFile := "../testdata/multilevelpki/int2-cert-chain.pem"
+	signingCertFile := "../testdata/multilevelpki/int2-cert.pem"
+	signingKeyFile := "../testdata/multilevelpki/int2-key.pem"
+	caNamespace := "default"
+
+	client := fake.NewSimpleClientset()
+
+	// The signing key is held by the signer, as in an HSM.
+	keyBytes, err := ioutil.ReadFile(signingKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := util.ParsePemEncodedKey(keyBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caopts, err := NewExternalSignerIstioCAOptions(certChainFile, signingCertFile, rootCertFile, key.(crypto.Signer),
+		30*time.Minute, time.Hour, caNamespace, client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create an external signer CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while creating external signer CA: %v", err)
+	}
+
+	signingCertBytes, signingKeyBytes, _, rootCertBytes := ca.GetCAKeyCertBundle().GetAllPem()
+	if !comparePem(signingCertBytes, signingCertFile) {
+		t.Errorf("Failed to verify loading of signing cert pem.")
+	}
+	if len(signingKeyBytes) != 0 {
+		t.Errorf("The signing key pem is loaded by the CA.")
+	}
+	verifyIssuedCert(t, ca, rootCertBytes)
+
+	cmc := configmap.NewController(caNamespace, client.CoreV1())
+	if _, err := cmc.GetCATLSRootCert(); err != nil {
+		t.Errorf("Cannot get the CA cert from configmap (%v)", err)
+	}
+
+	if _, err := NewExternalSignerIstioCAOptions(certChainFile, "../testdata/cert.pem", rootCertFile, key.(crypto.Signer),
+		30*time.Minute, time.Hour, caNamespace, client.CoreV1()); err == nil {
+		t.Errorf("Expected an error when the signing cert does not match the signer")
+	}
+}
+
 // TODO: merge tests for SignCSR.
 func TestSignCSRForWorkload(t *testing.T) {
 	subjectID := "spiffe://example.com/ns/foo/sa/bar"
@@ -416,6 +465,46 @@ func TestSignCSRForWorkload(t *testing.T) {
 	}
 }
 
+func TestSignECCSRWithSelfSignedECCA(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, 30*time.Minute, time.Hour,
+		"test.ca.org", false, "default", -1, client.CoreV1(), "", util.EcdsaSigAlg)
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while creating self-signed CA: %v", err)
+	}
+	signingCert, _, _, rootCertBytes := ca.GetCAKeyCertBundle().GetAll()
+	if signingCert.PublicKeyAlgorithm != x509.ECDSA {
+		t.Errorf("Unexpected CA key algorithm %v, expecting ECDSA", signingCert.PublicKeyAlgorithm)
+	}
+
+	subjectID := "spiffe://example.com/ns/foo/sa/bar"
+	csrPEM, keyPEM, err := util.GenCSR(util.CertOptions{
+		Host:     subjectID,
+		ECSigAlg: util.EcdsaSigAlg,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.Sign(csrPEM, []string{subjectID}, 30*time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := &util.VerifyFields{
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		IsCA:        false,
+		Host:        subjectID,
+	}
+	if err = util.VerifyCertificate(keyPEM, certPEM, rootCertBytes, fields); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestSignCSRForCA(t *testing.T) {
 	subjectID := "spiffe://example.com/ns/foo/sa/baz"
 	opts := util.CertOptions{
@@ -439,7 +528,7 @@ func TestSignCSRForCA(t *testing.T) {
 	}
 
 	fields := &util.VerifyFields{
-		KeyUsage: x509.KeyUsageCertSign,
+		KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		IsCA:     true,
 		Host:     subjectID,
 	}
@@ -52,9 +52,21 @@ func TestError(t *testing.T) {
 			message: "CERT_GEN_ERROR",
 			code:    codes.Internal,
 		},
+		"REVOCATION_ERROR": {
+			eType:   RevocationError,
+			err:     fmt.Errorf("test error5"),
+			message: "REVOCATION_ERROR",
+			code:    codes.InvalidArgument,
+		},
+		"CRL_ERROR": {
+			eType:   CRLError,
+			err:     fmt.Errorf("test error6"),
+			message: "CRL_ERROR",
+			code:    codes.Internal,
+		},
 		"UNKNOWN": {
 			eType:   -1,
-			err:     fmt.Errorf("test error5"),
+			err:     fmt.Errorf("test error7"),
 			message: "UNKNOWN",
 			code:    codes.Internal,
 		},
@@ -0,0 +1,51 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"istio.io/pkg/monitoring"
+)
+
+var (
+	rootRotationPhase = monitoring.NewGauge(
+		"citadel_root_rotation_phase",
+		"The phase of the root certificate rotation: 0 when idle, 1 when the new root is trusted "+
+			"along with the old root, 2 when Citadel signs with the new root.",
+	)
+
+	trustedRootCertCount = monitoring.NewGauge(
+		"citadel_trusted_root_cert_count",
+		"The number of root certificates trusted by Citadel.",
+	)
+
+	rootRotationCounts = monitoring.NewSum(
+		"citadel_root_rotation_count",
+		"The number of completed root certificate rotations.",
+	)
+
+	rootRotationErrorCounts = monitoring.NewSum(
+		"citadel_root_rotation_err_count",
+		"The number of errors occurred when rotating the root certificate.",
+	)
+)
+
+func init() {
+	monitoring.MustRegister(
+		rootRotationPhase,
+		trustedRootCertCount,
+		rootRotationCounts,
+		rootRotationErrorCounts,
+	)
+}
@@ -0,0 +1,360 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+const (
+	// CRLConfigMap stores the certificates revoked by the CA, and the CRL listing them.
+	CRLConfigMap = "istio-ca-crl"
+	// CRLID is the ID/name for the PEM-encoded CRL in CRLConfigMap.
+	CRLID = "crl.pem"
+	// revokedCertsID is the ID/name for the JSON-encoded revoked certificates in CRLConfigMap.
+	revokedCertsID = "revoked-certs.json"
+
+	// blockTypeCRL is the PEM block type of a CRL.
+	blockTypeCRL = "X509 CRL"
+
+	// Reasons which are not valid in a CRL entry, see RFC 5280 section 5.3.1.
+	reasonUnused         = 7
+	reasonRemoveFromCRL  = 8
+	reasonAACompromise   = 10
+	reasonCodeMaxAllowed = reasonAACompromise
+)
+
+// oidExtensionReasonCode is the OID of the CRL entry reason code extension.
+var oidExtensionReasonCode = asn1.ObjectIdentifier{2, 5, 29, 21}
+
+// RevokedCert is a certificate revoked by the CA.
+type RevokedCert struct {
+	// SerialNumber is the hex-encoded serial number of the certificate.
+	SerialNumber string `json:"serialNumber"`
+	// RevocationTime is the time the certificate was revoked at.
+	RevocationTime time.Time `json:"revocationTime"`
+	// Reason is the CRL reason code of the revocation, 0 (unspecified) if not set.
+	Reason int `json:"reason,omitempty"`
+}
+
+// RevocationList keeps track of the certificates revoked by the CA in a ConfigMap, and publishes the
+// certificate revocation list (CRL) signed by the CA key in the same ConfigMap. During a root certificate
+// rotation, a CRL is published for each trusted root, so that the certificates issued by either root can be
+// checked, see RootCertRotator.
+type RevocationList struct {
+	client        corev1.CoreV1Interface
+	namespace     string
+	keyCertBundle util.KeyCertBundle
+
+	// Revoked certificates are dropped from the CRL after retention, i.e. once all the certificates
+	// issued before their revocation expired. It must be at least the max TTL of the issued certificates.
+	retention time.Duration
+	// crlTTL is the validity of the CRL, which is signed again after half of it.
+	crlTTL time.Duration
+
+	mutex   sync.Mutex
+	revoked []RevokedCert
+	crl     []byte
+}
+
+// NewRevocationList loads the certificates revoked by the CA from the CRLConfigMap in the namespace, and
+// publishes their CRL signed by the CA key.
+func NewRevocationList(keyCertBundle util.KeyCertBundle, retention, crlTTL time.Duration, namespace string,
+	client corev1.CoreV1Interface) (*RevocationList, error) {
+	if crlTTL <= 0 {
+		return nil, fmt.Errorf("the CRL TTL must be positive, got %v", crlTTL)
+	}
+	r := &RevocationList{
+		client:        client,
+		namespace:     namespace,
+		keyCertBundle: keyCertBundle,
+		retention:     retention,
+		crlTTL:        crlTTL,
+	}
+	if err := r.update(nil); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Revoke adds the certificate with the hex-encoded serial number to the revoked certificates, and returns
+// the updated CRL. Revoking a certificate which is already revoked returns the current CRL.
+func (r *RevocationList) Revoke(serialNumber string, reason int) ([]byte, error) {
+	serial, err := parseSerialNumber(serialNumber)
+	if err != nil {
+		return nil, NewError(RevocationError, err)
+	}
+	if reason < 0 || reason > reasonCodeMaxAllowed || reason == reasonUnused || reason == reasonRemoveFromCRL {
+		return nil, NewError(RevocationError, fmt.Errorf("invalid CRL reason code %d", reason))
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err := r.update(&RevokedCert{
+		SerialNumber:   formatSerialNumber(serial),
+		RevocationTime: time.Now().UTC(),
+		Reason:         reason,
+	}); err != nil {
+		return nil, NewError(CRLError, err)
+	}
+	log.Infof("Revoked the certificate with serial number %s (reason %d)", formatSerialNumber(serial), reason)
+	return r.crl, nil
+}
+
+// IsRevoked returns whether the certificate is revoked.
+func (r *RevocationList) IsRevoked(cert *x509.Certificate) bool {
+	serial := formatSerialNumber(cert.SerialNumber)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, rc := range r.revoked {
+		if rc.SerialNumber == serial {
+			return true
+		}
+	}
+	return false
+}
+
+// CRL returns the PEM-encoded CRLs, starting with the CRL signed by the CA key.
+func (r *RevocationList) CRL() []byte {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.crl
+}
+
+// Run signs the CRL again after half of its validity, until the stop channel is closed.
+func (r *RevocationList) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.crlTTL / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mutex.Lock()
+			if err := r.update(nil); err != nil {
+				log.Errorf("Failed to update the certificate revocation list: %v", err)
+			}
+			r.mutex.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// ServeHTTP serves the DER-encoded CRL signed by the CA key.
+func (r *RevocationList) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	block, _ := pem.Decode(r.CRL())
+	if block == nil {
+		http.Error(w, "the certificate revocation list is not available", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pkix-crl")
+	if _, err := w.Write(block.Bytes); err != nil {
+		log.Errorf("Failed to write the certificate revocation list: %v", err)
+	}
+}
+
+// update reads the revoked certificates from the ConfigMap, adds the newly revoked certificate if any,
+// drops the certificates revoked before the retention, and writes the revoked certificates and their
+// signed CRL back to the ConfigMap. The caller must hold the mutex, or own r.
+func (r *RevocationList) update(revoked *RevokedCert) error {
+	configmap, err := r.client.ConfigMaps(r.namespace).Get(CRLConfigMap, metav1.GetOptions{})
+	exists := true
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to read the revoked certificates: %v", err)
+		}
+		configmap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      CRLConfigMap,
+				Namespace: r.namespace,
+			},
+		}
+		exists = false
+	}
+
+	var revokedCerts []RevokedCert
+	if data := configmap.Data[revokedCertsID]; data != "" {
+		if err := json.Unmarshal([]byte(data), &revokedCerts); err != nil {
+			return fmt.Errorf("failed to parse the revoked certificates: %v", err)
+		}
+	}
+	if revoked != nil && !containsSerialNumber(revokedCerts, revoked.SerialNumber) {
+		revokedCerts = append(revokedCerts, *revoked)
+	}
+
+	now := time.Now().UTC()
+	kept := revokedCerts[:0]
+	for _, rc := range revokedCerts {
+		if r.retention <= 0 || now.Before(rc.RevocationTime.Add(r.retention)) {
+			kept = append(kept, rc)
+		}
+	}
+	revokedCerts = kept
+
+	crl, err := r.signCRLs(revokedCerts, now)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(revokedCerts)
+	if err != nil {
+		return err
+	}
+	if configmap.Data == nil {
+		configmap.Data = map[string]string{}
+	}
+	configmap.Data[revokedCertsID] = string(data)
+	configmap.Data[CRLID] = string(crl)
+	if exists {
+		_, err = r.client.ConfigMaps(r.namespace).Update(configmap)
+	} else {
+		_, err = r.client.ConfigMaps(r.namespace).Create(configmap)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the certificate revocation list: %v", err)
+	}
+
+	r.revoked = revokedCerts
+	r.crl = crl
+	return nil
+}
+
+// signCRLs returns the PEM-encoded CRLs of the revoked certificates, signed by the CA key, and by the key
+// of the other root certificate trusted during a root rotation, if any.
+func (r *RevocationList) signCRLs(revokedCerts []RevokedCert, now time.Time) ([]byte, error) {
+	signingCert, signingKey, _, _ := r.keyCertBundle.GetAll()
+	if signingCert == nil || signingKey == nil {
+		return nil, fmt.Errorf("the CA signing key and certificate are not available")
+	}
+	certs := []*x509.Certificate{signingCert}
+	keys := []crypto.PrivateKey{*signingKey}
+	rotatingCert, rotatingKey, err := r.rotatingRoot(signingCert)
+	if err != nil {
+		return nil, err
+	}
+	if rotatingCert != nil {
+		certs = append(certs, rotatingCert)
+		keys = append(keys, rotatingKey)
+	}
+
+	entries := make([]pkix.RevokedCertificate, 0, len(revokedCerts))
+	for _, rc := range revokedCerts {
+		serial, err := parseSerialNumber(rc.SerialNumber)
+		if err != nil {
+			return nil, err
+		}
+		entry := pkix.RevokedCertificate{
+			SerialNumber:   serial,
+			RevocationTime: rc.RevocationTime,
+		}
+		if rc.Reason != 0 {
+			value, err := asn1.Marshal(asn1.Enumerated(rc.Reason))
+			if err != nil {
+				return nil, err
+			}
+			entry.Extensions = []pkix.Extension{{Id: oidExtensionReasonCode, Value: value}}
+		}
+		entries = append(entries, entry)
+	}
+
+	var crls []byte
+	for i, cert := range certs {
+		crl, err := cert.CreateCRL(rand.Reader, keys[i], entries, now, now.Add(r.crlTTL))
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign the certificate revocation list: %v", err)
+		}
+		crls = append(crls, pem.EncodeToMemory(&pem.Block{Type: blockTypeCRL, Bytes: crl})...)
+	}
+	return crls, nil
+}
+
+// rotatingRoot returns the certificate and key of the root which is trusted along with the signing root
+// during a root rotation: the new root before the CA switched to it, then the retiring root. It returns
+// nil if no rotation is in progress, or if the CA is not self-signed.
+func (r *RevocationList) rotatingRoot(signingCert *x509.Certificate) (*x509.Certificate, crypto.PrivateKey, error) {
+	secret, err := r.client.Secrets(r.namespace).Get(CASecret, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read the CA secret: %v", err)
+	}
+	for _, ids := range [][2]string{{nextCACertID, nextCAPrivateKeyID}, {previousCACertID, previousCAPrivateKeyID}} {
+		certPEM, keyPEM := secret.Data[ids[0]], secret.Data[ids[1]]
+		if len(certPEM) == 0 || len(keyPEM) == 0 {
+			continue
+		}
+		cert, err := util.ParsePemEncodedCertificate(certPEM)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse the %s certificate: %v", ids[0], err)
+		}
+		if cert.Equal(signingCert) {
+			continue
+		}
+		key, err := util.ParsePemEncodedKey(keyPEM)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse the %s key: %v", ids[1], err)
+		}
+		return cert, key, nil
+	}
+	return nil, nil, nil
+}
+
+func containsSerialNumber(revokedCerts []RevokedCert, serialNumber string) bool {
+	for _, rc := range revokedCerts {
+		if rc.SerialNumber == serialNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSerialNumber parses a hex-encoded serial number, e.g. 0A1B2C as printed by 'openssl x509 -serial',
+// or 0a:1b:2c.
+func parseSerialNumber(serialNumber string) (*big.Int, error) {
+	s := strings.Replace(strings.TrimPrefix(serialNumber, "serial="), ":", "", -1)
+	serial, ok := new(big.Int).SetString(s, 16)
+	if !ok || serial.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid serial number %q", serialNumber)
+	}
+	return serial, nil
+}
+
+// formatSerialNumber returns the hex-encoded serial number, in the format of 'openssl x509 -serial'.
+func formatSerialNumber(serial *big.Int) string {
+	s := strings.ToUpper(serial.Text(16))
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return s
+}
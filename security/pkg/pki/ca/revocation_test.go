@@ -0,0 +1,346 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestRevocationList(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, 30*time.Minute, time.Hour,
+		"test.ca.org", false, "default", -1, client.CoreV1(), "", "")
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while creating self-signed CA: %v", err)
+	}
+
+	rl, err := NewRevocationList(ca.GetCAKeyCertBundle(), time.Hour, 24*time.Hour, "default", client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create the revocation list: %v", err)
+	}
+	signingCert, _, _, _ := ca.GetCAKeyCertBundle().GetAll()
+	if crl := parseCRL(t, signingCert, rl.CRL()); len(crl.TBSCertList.RevokedCertificates) != 0 {
+		t.Errorf("Expected an empty CRL, got %v", crl.TBSCertList.RevokedCertificates)
+	}
+
+	subjectID := "spiffe://example.com/ns/foo/sa/bar"
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: subjectID, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.Sign(csrPEM, []string{subjectID}, 30*time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.IsRevoked(cert) {
+		t.Errorf("The certificate is revoked before being revoked")
+	}
+
+	serial := formatSerialNumber(cert.SerialNumber)
+	crlPEM, err := rl.Revoke(serial, 1)
+	if err != nil {
+		t.Fatalf("Failed to revoke the certificate: %v", err)
+	}
+	crl := parseCRL(t, signingCert, crlPEM)
+	if len(crl.TBSCertList.RevokedCertificates) != 1 ||
+		crl.TBSCertList.RevokedCertificates[0].SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		t.Errorf("Expected the revoked certificate in the CRL, got %v", crl.TBSCertList.RevokedCertificates)
+	}
+	if !rl.IsRevoked(cert) {
+		t.Errorf("The certificate is not revoked")
+	}
+
+	// Revoking again is a no-op.
+	if crlPEM, err = rl.Revoke(serial, 0); err != nil {
+		t.Fatalf("Failed to revoke the certificate again: %v", err)
+	}
+	if crl = parseCRL(t, signingCert, crlPEM); len(crl.TBSCertList.RevokedCertificates) != 1 {
+		t.Errorf("Expected one revoked certificate, got %v", crl.TBSCertList.RevokedCertificates)
+	}
+
+	// The revoked certificates are loaded back from the ConfigMap.
+	reloaded, err := NewRevocationList(ca.GetCAKeyCertBundle(), time.Hour, 24*time.Hour, "default", client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to reload the revocation list: %v", err)
+	}
+	if !reloaded.IsRevoked(cert) {
+		t.Errorf("The reloaded revocation list does not contain the revoked certificate")
+	}
+	configmap, err := client.CoreV1().ConfigMaps("default").Get(CRLConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configmap.Data[CRLID] != string(reloaded.CRL()) {
+		t.Errorf("The CRL in the ConfigMap is not the CRL of the revocation list")
+	}
+
+	// The CRL is served DER-encoded.
+	recorder := httptest.NewRecorder()
+	reloaded.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/crl", nil))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/pkix-crl" {
+		t.Errorf("Unexpected response %d with content type %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	if _, err := x509.ParseDERCRL(recorder.Body.Bytes()); err != nil {
+		t.Errorf("Failed to parse the served CRL: %v", err)
+	}
+}
+
+func TestRevokeErrors(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, 30*time.Minute, time.Hour,
+		"test.ca.org", false, "default", -1, client.CoreV1(), "", "")
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	rl, err := NewRevocationList(caopts.KeyCertBundle, time.Hour, 24*time.Hour, "default", client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create the revocation list: %v", err)
+	}
+
+	cases := map[string]struct {
+		serial string
+		reason int
+	}{
+		"invalid serial number": {serial: "not-hex", reason: 0},
+		"zero serial number":    {serial: "00", reason: 0},
+		"negative reason":       {serial: "0A1B", reason: -1},
+		"unused reason":         {serial: "0A1B", reason: 7},
+		"removeFromCRL reason":  {serial: "0A1B", reason: 8},
+		"unknown reason":        {serial: "0A1B", reason: 11},
+	}
+	for id, tc := range cases {
+		_, err := rl.Revoke(tc.serial, tc.reason)
+		if err == nil {
+			t.Errorf("Case %s: expected an error", id)
+			continue
+		}
+		if caErr, ok := err.(*Error); !ok || caErr.ErrorType() != "REVOCATION_ERROR" {
+			t.Errorf("Case %s: unexpected error %v", id, err)
+		}
+	}
+
+	if _, err := rl.Revoke("0a:1b", 1); err != nil {
+		t.Errorf("Failed to revoke a colon-separated serial number: %v", err)
+	}
+}
+
+func TestRevocationListRetention(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, 30*time.Minute, time.Hour,
+		"test.ca.org", false, "default", -1, client.CoreV1(), "", "")
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+
+	revoked, err := json.Marshal([]RevokedCert{
+		{SerialNumber: "0A", RevocationTime: time.Now().Add(-2 * time.Hour)},
+		{SerialNumber: "0B", RevocationTime: time.Now().Add(-time.Minute)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().ConfigMaps("default").Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: CRLConfigMap, Namespace: "default"},
+		Data:       map[string]string{revokedCertsID: string(revoked)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	rl, err := NewRevocationList(caopts.KeyCertBundle, time.Hour, 24*time.Hour, "default", client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create the revocation list: %v", err)
+	}
+	if len(rl.revoked) != 1 || rl.revoked[0].SerialNumber != "0B" {
+		t.Errorf("Expected the certificate revoked before the retention to be dropped, got %v", rl.revoked)
+	}
+}
+
+func TestRevocationListRootRotation(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	// The root certificate expires within the rotation threshold, so the rotation starts right away.
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, 10*time.Minute, 10*time.Minute,
+		"test.ca.org", false, "default", -1, client.CoreV1(), "", "")
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while creating self-signed CA: %v", err)
+	}
+	rotator, err := NewRootCertRotator(ca.GetCAKeyCertBundle(), RootCertRotatorConfig{
+		CheckInterval:     time.Minute,
+		RotationThreshold: 75 * time.Minute,
+		PhaseDuration:     15 * time.Minute,
+		CACertTTL:         2 * time.Hour,
+		Org:               "test.ca.org",
+		Namespace:         "default",
+		Client:            client.CoreV1(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create the root cert rotator: %v", err)
+	}
+	now := time.Now()
+	rotator.now = func() time.Time { return now }
+	rl, err := NewRevocationList(ca.GetCAKeyCertBundle(), time.Hour, 24*time.Hour, "default", client.CoreV1())
+	if err != nil {
+		t.Fatalf("Failed to create the revocation list: %v", err)
+	}
+
+	// A certificate issued by the old root is revoked before the rotation.
+	subjectID := "spiffe://example.com/ns/foo/sa/bar"
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: subjectID, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.Sign(csrPEM, []string{subjectID}, 10*time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rl.Revoke(formatSerialNumber(cert.SerialNumber), 1); err != nil {
+		t.Fatalf("Failed to revoke the certificate: %v", err)
+	}
+	oldRoot, _, _, _ := ca.GetCAKeyCertBundle().GetAll()
+
+	steps := []struct {
+		elapsed time.Duration
+		phase   RootRotationPhase
+		crls    int
+	}{
+		{elapsed: 0, phase: RootRotationTrustBundle, crls: 2},
+		{elapsed: 15 * time.Minute, phase: RootRotationSwitched, crls: 2},
+		{elapsed: 15 * time.Minute, phase: RootRotationIdle, crls: 1},
+	}
+	for i, step := range steps {
+		now = now.Add(step.elapsed)
+		if err := rotator.reconcile(); err != nil {
+			t.Fatalf("Step %d: failed to rotate the root certificate: %v", i, err)
+		}
+		rl.mutex.Lock()
+		err := rl.update(nil)
+		rl.mutex.Unlock()
+		if err != nil {
+			t.Fatalf("Step %d: failed to update the revocation list: %v", i, err)
+		}
+
+		crls := parseCRLs(t, rl.CRL())
+		if len(crls) != step.crls {
+			t.Fatalf("Step %d: expected %d CRLs in the %s phase, got %d", i, step.crls, step.phase, len(crls))
+		}
+		// Each trusted root signed a CRL listing the revoked certificate.
+		_, _, _, rootCerts := ca.GetCAKeyCertBundle().GetAllPem()
+		var roots []*x509.Certificate
+		for rest := rootCerts; ; {
+			var block *pem.Block
+			if block, rest = pem.Decode(rest); block == nil {
+				break
+			}
+			root, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatalf("Step %d: failed to parse a root certificate: %v", i, err)
+			}
+			roots = append(roots, root)
+		}
+		if len(roots) != step.crls {
+			t.Fatalf("Step %d: expected %d trusted roots, got %d", i, step.crls, len(roots))
+		}
+		for _, root := range roots {
+			crl := crlSignedBy(crls, root)
+			if crl == nil {
+				t.Errorf("Step %d: no CRL signed by the root %s", i, formatSerialNumber(root.SerialNumber))
+				continue
+			}
+			if len(crl.TBSCertList.RevokedCertificates) != 1 ||
+				crl.TBSCertList.RevokedCertificates[0].SerialNumber.Cmp(cert.SerialNumber) != 0 {
+				t.Errorf("Step %d: expected the revoked certificate in the CRL, got %v",
+					i, crl.TBSCertList.RevokedCertificates)
+			}
+		}
+		if step.phase != RootRotationIdle && crlSignedBy(crls, oldRoot) == nil {
+			t.Errorf("Step %d: no CRL signed by the old root in the %s phase", i, step.phase)
+		}
+	}
+}
+
+func parseCRL(t *testing.T, issuer *x509.Certificate, crlPEM []byte) *pkix.CertificateList {
+	t.Helper()
+	block, _ := pem.Decode(crlPEM)
+	if block == nil || block.Type != blockTypeCRL {
+		t.Fatalf("Invalid PEM-encoded CRL %q", crlPEM)
+	}
+	crl, err := x509.ParseDERCRL(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse the CRL: %v", err)
+	}
+	if err := issuer.CheckCRLSignature(crl); err != nil {
+		t.Fatalf("Invalid CRL signature: %v", err)
+	}
+	if crl.HasExpired(time.Now()) {
+		t.Fatalf("The CRL has expired")
+	}
+	return crl
+}
+
+func parseCRLs(t *testing.T, crlPEM []byte) []*pkix.CertificateList {
+	t.Helper()
+	var crls []*pkix.CertificateList
+	for rest := crlPEM; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		crl, err := x509.ParseDERCRL(block.Bytes)
+		if err != nil {
+			t.Fatalf("Failed to parse the CRL: %v", err)
+		}
+		crls = append(crls, crl)
+	}
+	return crls
+}
+
+// crlSignedBy returns the CRL signed by the issuer, or nil.
+func crlSignedBy(crls []*pkix.CertificateList, issuer *x509.Certificate) *pkix.CertificateList {
+	for _, crl := range crls {
+		if issuer.CheckCRLSignature(crl) == nil {
+			return crl
+		}
+	}
+	return nil
+}
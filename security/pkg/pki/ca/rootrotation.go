@@ -0,0 +1,419 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	"istio.io/istio/security/pkg/pki/util"
+	"istio.io/pkg/log"
+)
+
+const (
+	// RootCertConfigMap publishes the root certificates trusted by the CA. During a root rotation, it holds
+	// both the old and the new root certificates.
+	RootCertConfigMap = "istio-ca-root-cert"
+	// RootRotationStatusID is the ID/name for the JSON-encoded RootRotationStatus in RootCertConfigMap.
+	RootRotationStatusID = "root-rotation.json"
+
+	// nextCACertID is the new root certificate in CASecret, while it is trusted but not yet used for signing.
+	nextCACertID = "next-ca-cert.pem"
+	// nextCAPrivateKeyID is the private key of the new root certificate in CASecret.
+	nextCAPrivateKeyID = "next-ca-key.pem"
+	// previousCACertID is the retiring root certificate in CASecret, while it is still trusted.
+	previousCACertID = "previous-ca-cert.pem"
+	// previousCAPrivateKeyID is the private key of the retiring root certificate in CASecret. It is only used
+	// to sign the CRL of the certificates issued by the retiring root.
+	previousCAPrivateKeyID = "previous-ca-key.pem"
+
+	// rootRotationPhaseAnnotation is the annotation of CASecret holding the phase of the root rotation.
+	rootRotationPhaseAnnotation = "istio.io/root-rotation-phase"
+	// rootRotationPhaseStartAnnotation is the annotation of CASecret holding the RFC 3339 start time of the phase.
+	rootRotationPhaseStartAnnotation = "istio.io/root-rotation-phase-start"
+)
+
+// RootRotationPhase is the phase of a root certificate rotation.
+type RootRotationPhase string
+
+const (
+	// RootRotationIdle means no rotation is in progress: the CA signs with, and trusts, its root certificate.
+	RootRotationIdle RootRotationPhase = "Idle"
+	// RootRotationTrustBundle means the new root certificate is trusted along with the old one, while the CA
+	// still signs with the old root, until the trust bundle propagated to all the workloads.
+	RootRotationTrustBundle RootRotationPhase = "TrustBundle"
+	// RootRotationSwitched means the CA signs with the new root certificate, while the old root is still
+	// trusted until the certificates it signed expired. The old root is retired at the end of the phase.
+	RootRotationSwitched RootRotationPhase = "Switched"
+)
+
+// value returns the value of the phase reported in the root rotation phase metric.
+func (p RootRotationPhase) value() float64 {
+	switch p {
+	case RootRotationTrustBundle:
+		return 1
+	case RootRotationSwitched:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// next returns the phase following p.
+func (p RootRotationPhase) next() RootRotationPhase {
+	switch p {
+	case RootRotationTrustBundle:
+		return RootRotationSwitched
+	case RootRotationSwitched:
+		return RootRotationIdle
+	default:
+		return RootRotationTrustBundle
+	}
+}
+
+// RootCertInfo describes a root certificate trusted by the CA.
+type RootCertInfo struct {
+	// SerialNumber is the hex-encoded serial number of the certificate.
+	SerialNumber string `json:"serialNumber"`
+	// NotAfter is the expiry time of the certificate.
+	NotAfter time.Time `json:"notAfter"`
+}
+
+// RootRotationStatus is the progress of the root certificate rotation, published in RootCertConfigMap.
+type RootRotationStatus struct {
+	// Phase is the current phase of the rotation.
+	Phase RootRotationPhase `json:"phase"`
+	// PhaseStartTime is the time the current phase started at, zero if the CA never rotated its root.
+	PhaseStartTime time.Time `json:"phaseStartTime"`
+	// NextPhase is the phase following the current phase.
+	NextPhase RootRotationPhase `json:"nextPhase"`
+	// NextPhaseTime is the earliest time the next phase starts at.
+	NextPhaseTime time.Time `json:"nextPhaseTime"`
+	// SigningRoot is the root certificate the CA signs with.
+	SigningRoot RootCertInfo `json:"signingRoot"`
+	// TrustedRoots are the root certificates trusted by the CA, including the signing root.
+	TrustedRoots []RootCertInfo `json:"trustedRoots"`
+}
+
+// RootCertRotatorConfig configures the root certificate rotation of a self-signed CA.
+type RootCertRotatorConfig struct {
+	// CheckInterval is the interval between two checks of the rotation progress.
+	CheckInterval time.Duration
+	// RotationThreshold starts a rotation when the signing root certificate expires within it. It must be
+	// greater than twice the PhaseDuration, so the old root outlives the rotation.
+	RotationThreshold time.Duration
+	// PhaseDuration is the time spent in each of the TrustBundle and Switched phases. It must be at least
+	// the max TTL of the issued certificates, so that all the workloads received the new trust bundle
+	// before the switch, and all the certificates signed by the old root expired before it is retired.
+	PhaseDuration time.Duration
+
+	// CACertTTL, Org, DualUse and ECSigAlg configure the new root certificate, as for
+	// NewSelfSignedIstioCAOptions.
+	CACertTTL time.Duration
+	Org       string
+	DualUse   bool
+	ECSigAlg  util.SupportedECSignatureAlgorithms
+	// RootCertFile holds additional root certificates to trust, if set.
+	RootCertFile string
+
+	// ReadOnly only follows the rotation performed by another CA instance sharing the CASecret,
+	// without advancing it.
+	ReadOnly bool
+
+	Namespace string
+	Client    corev1.CoreV1Interface
+}
+
+// RootCertRotator rotates the self-signed root certificate of the CA before it expires, without breaking
+// the trust between workloads. The new root certificate is first trusted along with the old one, then the
+// CA switches to signing with the new root, and eventually the old root is retired. The progress is
+// persisted in the CASecret, so it survives restarts and is shared by all the CA instances.
+type RootCertRotator struct {
+	config        RootCertRotatorConfig
+	keyCertBundle util.KeyCertBundle
+
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+}
+
+// NewRootCertRotator returns a RootCertRotator for the self-signed CA using the keyCertBundle.
+func NewRootCertRotator(keyCertBundle util.KeyCertBundle, config RootCertRotatorConfig) (*RootCertRotator, error) {
+	if config.CheckInterval <= 0 || config.PhaseDuration <= 0 {
+		return nil, fmt.Errorf("the check interval and phase duration must be positive, got %v and %v",
+			config.CheckInterval, config.PhaseDuration)
+	}
+	if config.RotationThreshold <= 2*config.PhaseDuration {
+		return nil, fmt.Errorf("the rotation threshold %v must be greater than twice the phase duration %v",
+			config.RotationThreshold, config.PhaseDuration)
+	}
+	if config.CACertTTL <= config.RotationThreshold {
+		return nil, fmt.Errorf("the CA certificate TTL %v must be greater than the rotation threshold %v",
+			config.CACertTTL, config.RotationThreshold)
+	}
+	return &RootCertRotator{
+		config:        config,
+		keyCertBundle: keyCertBundle,
+		now:           time.Now,
+	}, nil
+}
+
+// Run checks the rotation progress every check interval, until the stop channel is closed.
+func (r *RootCertRotator) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.reconcile(); err != nil {
+			log.Errorf("Failed to rotate the root certificate: %v", err)
+			rootRotationErrorCounts.Increment()
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// reconcile advances the rotation if the current phase is over, then applies the signing key and the
+// trusted root certificates from the CASecret to the CA, and publishes them with the rotation status.
+func (r *RootCertRotator) reconcile() error {
+	secret, err := r.config.Client.Secrets(r.config.Namespace).Get(CASecret, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to read the CA secret: %v", err)
+	}
+	if !r.config.ReadOnly {
+		if secret, err = r.advance(secret); err != nil {
+			return err
+		}
+	}
+
+	rootCerts, err := appendRootCerts(trustedRootCerts(secret.Data), r.config.RootCertFile)
+	if err != nil {
+		return fmt.Errorf("failed to append root certificates (%v)", err)
+	}
+	certBytes, privKeyBytes, certChainBytes, currentRootCerts := r.keyCertBundle.GetAllPem()
+	if !bytes.Equal(certBytes, secret.Data[caCertID]) || !bytes.Equal(privKeyBytes, secret.Data[caPrivateKeyID]) ||
+		!bytes.Equal(currentRootCerts, rootCerts) {
+		if err := r.keyCertBundle.VerifyAndSetAll(secret.Data[caCertID], secret.Data[caPrivateKeyID],
+			certChainBytes, rootCerts); err != nil {
+			return fmt.Errorf("failed to update the CA KeyCertBundle (%v)", err)
+		}
+		log.Infof("Updated the CA key and certificates in the %s phase of the root rotation", phaseOf(secret))
+		if err := updateCertInConfigmap(r.config.Namespace, r.config.Client, rootCerts); err != nil {
+			return fmt.Errorf("failed to write Citadel cert to configmap (%v)", err)
+		}
+	}
+
+	status, err := r.status(secret, rootCerts)
+	if err != nil {
+		return err
+	}
+	rootRotationPhase.Record(status.Phase.value())
+	trustedRootCertCount.Record(float64(len(status.TrustedRoots)))
+	return r.publish(rootCerts, status)
+}
+
+// advance moves the rotation to the next phase and persists it in the CASecret, if the current phase is over.
+// It returns the updated secret.
+func (r *RootCertRotator) advance(secret *v1.Secret) (*v1.Secret, error) {
+	phase := phaseOf(secret)
+	nextPhaseTime, err := r.nextPhaseTime(secret)
+	if err != nil {
+		return nil, err
+	}
+	now := r.now()
+	if now.Before(nextPhaseTime) {
+		return secret, nil
+	}
+
+	updated := secret.DeepCopy()
+	switch phase {
+	case RootRotationIdle:
+		pemCert, pemKey, err := util.GenCertKeyFromOptions(selfSignedCACertOptions(r.config.CACertTTL,
+			r.config.Org, r.config.DualUse, r.config.ECSigAlg))
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate the new root certificate and key (%v)", err)
+		}
+		updated.Data[nextCACertID] = pemCert
+		updated.Data[nextCAPrivateKeyID] = pemKey
+	case RootRotationTrustBundle:
+		updated.Data[previousCACertID] = updated.Data[caCertID]
+		updated.Data[previousCAPrivateKeyID] = updated.Data[caPrivateKeyID]
+		updated.Data[caCertID] = updated.Data[nextCACertID]
+		updated.Data[caPrivateKeyID] = updated.Data[nextCAPrivateKeyID]
+		delete(updated.Data, nextCACertID)
+		delete(updated.Data, nextCAPrivateKeyID)
+	case RootRotationSwitched:
+		delete(updated.Data, previousCACertID)
+		delete(updated.Data, previousCAPrivateKeyID)
+	}
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[rootRotationPhaseAnnotation] = string(phase.next())
+	updated.Annotations[rootRotationPhaseStartAnnotation] = now.UTC().Format(time.RFC3339)
+
+	if updated, err = r.config.Client.Secrets(r.config.Namespace).Update(updated); err != nil {
+		return nil, fmt.Errorf("failed to write the CA secret: %v", err)
+	}
+	log.Infof("Root certificate rotation moved from the %s to the %s phase", phase, phase.next())
+	if phase == RootRotationSwitched {
+		rootRotationCounts.Increment()
+	}
+	return updated, nil
+}
+
+// nextPhaseTime returns the earliest time the rotation moves to the next phase: when the signing root
+// expires within the rotation threshold if idle, or at the end of the phase duration otherwise.
+func (r *RootCertRotator) nextPhaseTime(secret *v1.Secret) (time.Time, error) {
+	if phaseOf(secret) == RootRotationIdle {
+		cert, err := util.ParsePemEncodedCertificate(secret.Data[caCertID])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse the CA certificate: %v", err)
+		}
+		return cert.NotAfter.Add(-r.config.RotationThreshold), nil
+	}
+	start, err := phaseStartOf(secret)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return start.Add(r.config.PhaseDuration), nil
+}
+
+// status returns the status of the rotation persisted in the secret.
+func (r *RootCertRotator) status(secret *v1.Secret, rootCerts []byte) (*RootRotationStatus, error) {
+	phase := phaseOf(secret)
+	start, err := phaseStartOf(secret)
+	if err != nil {
+		return nil, err
+	}
+	nextPhaseTime, err := r.nextPhaseTime(secret)
+	if err != nil {
+		return nil, err
+	}
+	signingCert, err := util.ParsePemEncodedCertificate(secret.Data[caCertID])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the CA certificate: %v", err)
+	}
+	status := &RootRotationStatus{
+		Phase:          phase,
+		PhaseStartTime: start,
+		NextPhase:      phase.next(),
+		NextPhaseTime:  nextPhaseTime,
+		SigningRoot:    rootCertInfo(signingCert),
+	}
+	for rest := rootCerts; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		cert, err := util.ParsePemEncodedCertificate(pem.EncodeToMemory(block))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse a trusted root certificate: %v", err)
+		}
+		status.TrustedRoots = append(status.TrustedRoots, rootCertInfo(cert))
+	}
+	return status, nil
+}
+
+// rootCertInfo returns the RootCertInfo describing the certificate.
+func rootCertInfo(cert *x509.Certificate) RootCertInfo {
+	return RootCertInfo{SerialNumber: formatSerialNumber(cert.SerialNumber), NotAfter: cert.NotAfter}
+}
+
+// publish writes the trusted root certificates and the rotation status to the RootCertConfigMap, if changed.
+func (r *RootCertRotator) publish(rootCerts []byte, status *RootRotationStatus) error {
+	statusData, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	data := map[string]string{
+		RootCertID:           string(rootCerts),
+		RootRotationStatusID: string(statusData),
+	}
+
+	configmap, err := r.config.Client.ConfigMaps(r.config.Namespace).Get(RootCertConfigMap, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to read the root certificates: %v", err)
+		}
+		_, err = r.config.Client.ConfigMaps(r.config.Namespace).Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      RootCertConfigMap,
+				Namespace: r.config.Namespace,
+			},
+			Data: data,
+		})
+	} else if !reflect.DeepEqual(configmap.Data, data) {
+		configmap.Data = data
+		_, err = r.config.Client.ConfigMaps(r.config.Namespace).Update(configmap)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write the root certificates: %v", err)
+	}
+	return nil
+}
+
+// phaseOf returns the phase of the root rotation persisted in the CA secret.
+func phaseOf(secret *v1.Secret) RootRotationPhase {
+	switch phase := RootRotationPhase(secret.Annotations[rootRotationPhaseAnnotation]); phase {
+	case RootRotationTrustBundle, RootRotationSwitched:
+		return phase
+	default:
+		return RootRotationIdle
+	}
+}
+
+// phaseStartOf returns the start time of the root rotation phase persisted in the CA secret, zero if the
+// CA never rotated its root.
+func phaseStartOf(secret *v1.Secret) (time.Time, error) {
+	value, ok := secret.Annotations[rootRotationPhaseStartAnnotation]
+	if !ok {
+		return time.Time{}, nil
+	}
+	start, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid root rotation phase start time %q: %v", value, err)
+	}
+	return start, nil
+}
+
+// trustedRootCerts returns the root certificates in the CA secret: the signing root, and the new or the
+// retiring root during a rotation.
+func trustedRootCerts(data map[string][]byte) []byte {
+	var rootCerts []byte
+	for _, id := range []string{caCertID, nextCACertID, previousCACertID} {
+		cert := data[id]
+		if len(cert) == 0 {
+			continue
+		}
+		if len(rootCerts) > 0 {
+			// Append a newline after the last cert
+			rootCerts = append(bytes.TrimSuffix(rootCerts, []byte("\n")), '\n')
+		}
+		rootCerts = append(rootCerts, cert...)
+	}
+	return rootCerts
+}
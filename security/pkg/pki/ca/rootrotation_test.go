@@ -0,0 +1,191 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"istio.io/istio/security/pkg/k8s/configmap"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+func TestRootCertRotator(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	// The root certificate expires within the rotation threshold, so the rotation starts right away.
+	caopts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, 10*time.Minute, 10*time.Minute,
+		"test.ca.org", false, "default", -1, client.CoreV1(), "", "")
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	ca, err := NewIstioCA(caopts)
+	if err != nil {
+		t.Fatalf("Got error while creating self-signed CA: %v", err)
+	}
+	config := RootCertRotatorConfig{
+		CheckInterval:     time.Minute,
+		RotationThreshold: 75 * time.Minute,
+		PhaseDuration:     15 * time.Minute,
+		CACertTTL:         2 * time.Hour,
+		Org:               "test.ca.org",
+		Namespace:         "default",
+		Client:            client.CoreV1(),
+	}
+	rotator, err := NewRootCertRotator(ca.GetCAKeyCertBundle(), config)
+	if err != nil {
+		t.Fatalf("Failed to create the root cert rotator: %v", err)
+	}
+	now := time.Now()
+	rotator.now = func() time.Time { return now }
+
+	// Another CA instance follows the rotation.
+	followerOpts, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, 10*time.Minute, 10*time.Minute,
+		"test.ca.org", false, "default", -1, client.CoreV1(), "", "")
+	if err != nil {
+		t.Fatalf("Failed to create a self-signed CA Options: %v", err)
+	}
+	followerConfig := config
+	followerConfig.ReadOnly = true
+	follower, err := NewRootCertRotator(followerOpts.KeyCertBundle, followerConfig)
+	if err != nil {
+		t.Fatalf("Failed to create the root cert rotator: %v", err)
+	}
+
+	oldRoot := ca.GetCAKeyCertBundle().GetRootCertPem()
+	steps := []struct {
+		elapsed          time.Duration
+		phase            RootRotationPhase
+		trustedRoots     int
+		signsWithOldRoot bool
+	}{
+		{elapsed: 0, phase: RootRotationTrustBundle, trustedRoots: 2, signsWithOldRoot: true},
+		{elapsed: 10 * time.Minute, phase: RootRotationTrustBundle, trustedRoots: 2, signsWithOldRoot: true},
+		{elapsed: 5 * time.Minute, phase: RootRotationSwitched, trustedRoots: 2, signsWithOldRoot: false},
+		{elapsed: 15 * time.Minute, phase: RootRotationIdle, trustedRoots: 1, signsWithOldRoot: false},
+		// The new root does not expire within the rotation threshold yet.
+		{elapsed: time.Minute, phase: RootRotationIdle, trustedRoots: 1, signsWithOldRoot: false},
+	}
+	for i, step := range steps {
+		now = now.Add(step.elapsed)
+		if err := rotator.reconcile(); err != nil {
+			t.Fatalf("Step %d: failed to rotate the root certificate: %v", i, err)
+		}
+		if err := follower.reconcile(); err != nil {
+			t.Fatalf("Step %d: failed to follow the root certificate rotation: %v", i, err)
+		}
+
+		signingCert, _, _, rootCerts := ca.GetCAKeyCertBundle().GetAllPem()
+		if bytes.Equal(signingCert, oldRoot) != step.signsWithOldRoot {
+			t.Errorf("Step %d: expected signing with the old root %v", i, step.signsWithOldRoot)
+		}
+		if step.phase != RootRotationIdle && !bytes.Contains(rootCerts, oldRoot) {
+			t.Errorf("Step %d: the old root is not trusted", i)
+		}
+		followerCert, _, _, followerRootCerts := followerOpts.KeyCertBundle.GetAllPem()
+		if !bytes.Equal(followerCert, signingCert) || !bytes.Equal(followerRootCerts, rootCerts) {
+			t.Errorf("Step %d: the follower CA does not use the same root certificates", i)
+		}
+
+		// A restarted CA loads the same root certificates.
+		reloaded, err := NewSelfSignedIstioCAOptions(context.Background(), time.Hour, 10*time.Minute, 10*time.Minute,
+			"test.ca.org", false, "default", -1, client.CoreV1(), "", "")
+		if err != nil {
+			t.Fatalf("Step %d: failed to reload the CA: %v", i, err)
+		}
+		if reloadedCert, _, _, reloadedRootCerts := reloaded.KeyCertBundle.GetAllPem(); !bytes.Equal(reloadedCert, signingCert) ||
+			!bytes.Equal(reloadedRootCerts, rootCerts) {
+			t.Errorf("Step %d: the reloaded CA does not use the same root certificates", i)
+		}
+
+		// Issued certificates are trusted by the published root certificates.
+		published, err := client.CoreV1().ConfigMaps("default").Get(RootCertConfigMap, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Step %d: failed to read the root certificates: %v", i, err)
+		}
+		if published.Data[RootCertID] != string(rootCerts) {
+			t.Errorf("Step %d: the published root certificates are not the trusted root certificates", i)
+		}
+		securityRootCert, err := configmap.NewController("default", client.CoreV1()).GetCATLSRootCert()
+		if err != nil {
+			t.Fatalf("Step %d: failed to read the CA TLS root certificate: %v", i, err)
+		}
+		if securityRootCert != base64.StdEncoding.EncodeToString(rootCerts) {
+			t.Errorf("Step %d: the CA TLS root certificate is not the trusted root certificates", i)
+		}
+		verifyIssuedCert(t, ca, []byte(published.Data[RootCertID]))
+
+		status := &RootRotationStatus{}
+		if err := json.Unmarshal([]byte(published.Data[RootRotationStatusID]), status); err != nil {
+			t.Fatalf("Step %d: failed to parse the root rotation status: %v", i, err)
+		}
+		if status.Phase != step.phase || len(status.TrustedRoots) != step.trustedRoots {
+			t.Errorf("Step %d: expected phase %s with %d trusted roots, got %s with %d trusted roots",
+				i, step.phase, step.trustedRoots, status.Phase, len(status.TrustedRoots))
+		}
+	}
+}
+
+func TestNewRootCertRotatorErrors(t *testing.T) {
+	cases := map[string]RootCertRotatorConfig{
+		"zero check interval": {
+			RotationThreshold: time.Hour, PhaseDuration: 10 * time.Minute, CACertTTL: 2 * time.Hour,
+		},
+		"threshold below twice the phase duration": {
+			CheckInterval: time.Minute, RotationThreshold: time.Hour, PhaseDuration: 30 * time.Minute, CACertTTL: 2 * time.Hour,
+		},
+		"CA cert TTL below the threshold": {
+			CheckInterval: time.Minute, RotationThreshold: time.Hour, PhaseDuration: 10 * time.Minute, CACertTTL: time.Hour,
+		},
+	}
+	for id, config := range cases {
+		if _, err := NewRootCertRotator(nil, config); err == nil {
+			t.Errorf("Case %s: expected an error", id)
+		}
+	}
+}
+
+func verifyIssuedCert(t *testing.T, ca *IstioCA, rootCerts []byte) {
+	t.Helper()
+	subjectID := "spiffe://example.com/ns/foo/sa/bar"
+	csrPEM, _, err := util.GenCSR(util.CertOptions{Host: subjectID, RSAKeySize: 2048})
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := ca.Sign(csrPEM, []string{subjectID}, 10*time.Minute, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := util.ParsePemEncodedCertificate(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(rootCerts)
+	intermediates := x509.NewCertPool()
+	_, _, certChain, _ := ca.GetCAKeyCertBundle().GetAllPem()
+	intermediates.AppendCertsFromPEM(certChain)
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+		t.Errorf("The issued certificate is not trusted by the root certificates: %v", err)
+	}
+}
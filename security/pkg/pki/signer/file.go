@@ -0,0 +1,41 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"crypto"
+	"fmt"
+	"io/ioutil"
+
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// NewFileSigner returns a crypto.Signer signing with the PEM-encoded private key in the file. It stands in for an
+// HSM in the external signer, keeping the key out of the CA.
+func NewFileSigner(keyFile string) (crypto.Signer, error) {
+	keyBytes, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the signing key: %v", err)
+	}
+	key, err := util.ParsePemEncodedKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the signing key: %v", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("the signing key %s cannot sign", keyFile)
+	}
+	return signer, nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signer signs with keys held outside of the CA, through the external signer gRPC protocol.
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	pb "istio.io/istio/security/proto"
+)
+
+// hashAlgorithms maps the hash algorithm names of the external signer protocol to the hash functions.
+var hashAlgorithms = map[string]crypto.Hash{
+	"SHA-256": crypto.SHA256,
+	"SHA-384": crypto.SHA384,
+	"SHA-512": crypto.SHA512,
+}
+
+// hashAlgorithmName returns the name of the hash function in the external signer protocol.
+func hashAlgorithmName(hash crypto.Hash) (string, error) {
+	for name, h := range hashAlgorithms {
+		if h == hash {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported hash function %v", hash)
+}
+
+// GRPCSignerConfig configures the connection to an external signer.
+type GRPCSignerConfig struct {
+	// Address is the address of the external signer, e.g. unix:///var/run/istio-signer/socket or host:port.
+	Address string
+	// RootCertFile verifies the TLS certificate of the external signer. The connection is in plaintext if
+	// empty, which is only suitable for a Unix domain socket.
+	RootCertFile string
+	// CertFile and KeyFile authenticate the CA to the external signer with mutual TLS, if set.
+	CertFile string
+	KeyFile  string
+	// Timeout is the timeout of the requests to the external signer.
+	Timeout time.Duration
+}
+
+// grpcSigner is a crypto.Signer signing with the key of an external signer.
+type grpcSigner struct {
+	client    pb.ExternalSignerServiceClient
+	publicKey crypto.PublicKey
+	timeout   time.Duration
+}
+
+// NewGRPCSigner connects to the external signer, and returns a crypto.Signer signing with its key.
+func NewGRPCSigner(config GRPCSignerConfig) (crypto.Signer, error) {
+	dialOption := grpc.WithInsecure()
+	if config.RootCertFile != "" {
+		tlsConfig, err := clientTLSConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		dialOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+	conn, err := grpc.Dial(config.Address, dialOption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial the external signer %s: %v", config.Address, err)
+	}
+
+	s := &grpcSigner{
+		client:  pb.NewExternalSignerServiceClient(conn),
+		timeout: config.Timeout,
+	}
+	ctx, cancel := s.context()
+	defer cancel()
+	resp, err := s.client.GetPublicKey(ctx, &pb.GetPublicKeyRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the public key from the external signer %s: %v", config.Address, err)
+	}
+	if s.publicKey, err = x509.ParsePKIXPublicKey(resp.PublicKey); err != nil {
+		return nil, fmt.Errorf("failed to parse the public key of the external signer: %v", err)
+	}
+	return s, nil
+}
+
+// Public returns the public key of the external signer.
+func (s *grpcSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs the digest with the key of the external signer. RSA-PSS is not supported.
+func (s *grpcSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, fmt.Errorf("RSA-PSS signatures are not supported by the external signer")
+	}
+	hashAlgorithm, err := hashAlgorithmName(opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := s.context()
+	defer cancel()
+	resp, err := s.client.Sign(ctx, &pb.SignRequest{Digest: digest, HashAlgorithm: hashAlgorithm})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with the external signer: %v", err)
+	}
+	return resp.Signature, nil
+}
+
+func (s *grpcSigner) context() (context.Context, context.CancelFunc) {
+	if s.timeout > 0 {
+		return context.WithTimeout(context.Background(), s.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// clientTLSConfig returns the TLS config of the connection to the external signer.
+func clientTLSConfig(config GRPCSignerConfig) (*tls.Config, error) {
+	rootCert, err := ioutil.ReadFile(config.RootCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the external signer root cert: %v", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(rootCert) {
+		return nil, fmt.Errorf("failed to parse the external signer root cert %s", config.RootCertFile)
+	}
+	tlsConfig := &tls.Config{RootCAs: roots}
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the external signer client cert: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build cgo
+
+// Package pkcs11 signs with keys of PKCS #11 tokens, e.g. HSMs or SoftHSM. It requires cgo.
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	p11 "github.com/miekg/pkcs11"
+)
+
+// digestInfoPrefixes are the DER prefixes of the DigestInfo structures signed by CKM_RSA_PKCS, as in crypto/rsa.
+var digestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// namedCurves maps the object identifiers in CKA_EC_PARAMS to the curves.
+var namedCurves = map[string]elliptic.Curve{
+	asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}.String(): elliptic.P256(),
+	asn1.ObjectIdentifier{1, 3, 132, 0, 34}.String():          elliptic.P384(),
+	asn1.ObjectIdentifier{1, 3, 132, 0, 35}.String():          elliptic.P521(),
+}
+
+// signer is a crypto.Signer signing with a private key of a PKCS #11 token.
+type signer struct {
+	ctx        *p11.Ctx
+	session    p11.SessionHandle
+	privateKey p11.ObjectHandle
+	publicKey  crypto.PublicKey
+	// mutex serializes the operations on the session, which PKCS #11 does not allow concurrently.
+	mutex sync.Mutex
+}
+
+// NewSigner loads the PKCS #11 module, logs in the token with the label, and returns a crypto.Signer signing
+// with the RSA or ECDSA private key with the label. The public key with the same label must be on the token.
+func NewSigner(module, tokenLabel, pin, keyLabel string) (crypto.Signer, error) {
+	ctx := p11.New(module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load the PKCS #11 module %s", module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize the PKCS #11 module: %v", err)
+	}
+	s := &signer{ctx: ctx}
+	if err := s.init(tokenLabel, pin, keyLabel); err != nil {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *signer) init(tokenLabel, pin, keyLabel string) error {
+	slot, err := s.findSlot(tokenLabel)
+	if err != nil {
+		return err
+	}
+	if s.session, err = s.ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION); err != nil {
+		return fmt.Errorf("failed to open a session with the token %s: %v", tokenLabel, err)
+	}
+	if err = s.ctx.Login(s.session, p11.CKU_USER, pin); err != nil {
+		return fmt.Errorf("failed to log in the token %s: %v", tokenLabel, err)
+	}
+	if s.privateKey, err = s.findObject(p11.CKO_PRIVATE_KEY, keyLabel); err != nil {
+		return err
+	}
+	publicKey, err := s.findObject(p11.CKO_PUBLIC_KEY, keyLabel)
+	if err != nil {
+		return err
+	}
+	s.publicKey, err = s.loadPublicKey(publicKey)
+	return err
+}
+
+// findSlot returns the slot of the token with the label.
+func (s *signer) findSlot(tokenLabel string) (uint, error) {
+	slots, err := s.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the PKCS #11 slots: %v", err)
+	}
+	for _, slot := range slots {
+		info, err := s.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the token info of slot %d: %v", slot, err)
+		}
+		if info.Label == tokenLabel {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("no PKCS #11 token with label %s", tokenLabel)
+}
+
+// findObject returns the only object of the class with the label.
+func (s *signer) findObject(class uint, label string) (p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, class),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, fmt.Errorf("failed to find the key %s: %v", label, err)
+	}
+	objects, _, err := s.ctx.FindObjects(s.session, 2)
+	if finalErr := s.ctx.FindObjectsFinal(s.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the key %s: %v", label, err)
+	}
+	if len(objects) != 1 {
+		return 0, fmt.Errorf("found %d keys of class %d with label %s, expected 1", len(objects), class, label)
+	}
+	return objects[0], nil
+}
+
+// loadPublicKey returns the RSA or ECDSA public key of the object.
+func (s *signer) loadPublicKey(object p11.ObjectHandle) (crypto.PublicKey, error) {
+	attrs, err := s.ctx.GetAttributeValue(s.session, object, []*p11.Attribute{p11.NewAttribute(p11.CKA_KEY_TYPE, nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the key type: %v", err)
+	}
+	switch keyType := bytesToUint(attrs[0].Value); keyType {
+	case p11.CKK_RSA:
+		attrs, err = s.ctx.GetAttributeValue(s.session, object, []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_MODULUS, nil),
+			p11.NewAttribute(p11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the RSA public key: %v", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attrs[0].Value),
+			E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+		}, nil
+	case p11.CKK_EC:
+		attrs, err = s.ctx.GetAttributeValue(s.session, object, []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_EC_PARAMS, nil),
+			p11.NewAttribute(p11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the ECDSA public key: %v", err)
+		}
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(attrs[0].Value, &oid); err != nil {
+			return nil, fmt.Errorf("failed to parse the EC parameters: %v", err)
+		}
+		curve, ok := namedCurves[oid.String()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %v", oid)
+		}
+		var point []byte
+		if _, err := asn1.Unmarshal(attrs[1].Value, &point); err != nil {
+			return nil, fmt.Errorf("failed to parse the EC point: %v", err)
+		}
+		x, y := elliptic.Unmarshal(curve, point)
+		if x == nil {
+			return nil, fmt.Errorf("invalid EC point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %d", keyType)
+	}
+}
+
+// Public returns the public key of the token key.
+func (s *signer) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign signs the digest with the token key, with PKCS #1 v1.5 for RSA keys. RSA-PSS is not supported.
+func (s *signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if _, ok := opts.(*rsa.PSSOptions); ok {
+		return nil, fmt.Errorf("RSA-PSS signatures are not supported by the PKCS #11 signer")
+	}
+	if len(digest) != opts.HashFunc().Size() {
+		return nil, fmt.Errorf("invalid digest length %d", len(digest))
+	}
+
+	var mechanism uint
+	data := digest
+	switch s.publicKey.(type) {
+	case *rsa.PublicKey:
+		prefix, ok := digestInfoPrefixes[opts.HashFunc()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported hash function %v", opts.HashFunc())
+		}
+		mechanism = p11.CKM_RSA_PKCS
+		data = append(append([]byte{}, prefix...), digest...)
+	case *ecdsa.PublicKey:
+		mechanism = p11.CKM_ECDSA
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.ctx.SignInit(s.session, []*p11.Mechanism{p11.NewMechanism(mechanism, nil)}, s.privateKey); err != nil {
+		return nil, fmt.Errorf("failed to initialize the signature: %v", err)
+	}
+	signature, err := s.ctx.Sign(s.session, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %v", err)
+	}
+	if mechanism == p11.CKM_ECDSA {
+		// PKCS #11 returns r || s, while crypto.Signer returns the ASN.1 encoding.
+		half := len(signature) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			R: new(big.Int).SetBytes(signature[:half]),
+			S: new(big.Int).SetBytes(signature[half:]),
+		})
+	}
+	return signature, nil
+}
+
+// bytesToUint decodes a CK_ULONG attribute value, in the little-endian byte order of the supported platforms.
+func bytesToUint(value []byte) uint {
+	var n uint
+	for i := len(value) - 1; i >= 0; i-- {
+		n = n<<8 | uint(value[i])
+	}
+	return n
+}
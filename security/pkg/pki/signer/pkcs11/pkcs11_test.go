@@ -0,0 +1,204 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	p11 "github.com/miekg/pkcs11"
+)
+
+const (
+	tokenLabel = "istio-test"
+	pin        = "1234"
+)
+
+// softHSMModule returns the path of the SoftHSM module, or skips the test if SoftHSM is not installed.
+func softHSMModule(t *testing.T) string {
+	candidates := []string{os.Getenv("SOFTHSM2_MODULE"), "/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so", "/usr/local/lib/softhsm/libsofthsm2.so"}
+	for _, module := range candidates {
+		if module == "" {
+			continue
+		}
+		if _, err := os.Stat(module); err == nil {
+			return module
+		}
+	}
+	t.Skip("SoftHSM is not installed")
+	return ""
+}
+
+// initToken initializes a SoftHSM token in a temporary directory, and generates RSA and ECDSA key pairs.
+func initToken(t *testing.T, module string) func() {
+	dir, err := ioutil.TempDir("", "softhsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(dir, "softhsm2.conf")
+	if err := ioutil.WriteFile(config, []byte(fmt.Sprintf("directories.tokendir = %s\n", dir)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldConfig, hadConfig := os.LookupEnv("SOFTHSM2_CONF")
+	_ = os.Setenv("SOFTHSM2_CONF", config)
+	cleanup := func() {
+		if hadConfig {
+			_ = os.Setenv("SOFTHSM2_CONF", oldConfig)
+		} else {
+			_ = os.Unsetenv("SOFTHSM2_CONF")
+		}
+		_ = os.RemoveAll(dir)
+	}
+
+	ctx := p11.New(module)
+	if err := ctx.Initialize(); err != nil {
+		cleanup()
+		t.Fatalf("Failed to initialize SoftHSM: %v", err)
+	}
+	defer func() {
+		_ = ctx.Finalize()
+		ctx.Destroy()
+	}()
+	slots, err := ctx.GetSlotList(false)
+	if err != nil || len(slots) == 0 {
+		cleanup()
+		t.Fatalf("Failed to list the SoftHSM slots: %v", err)
+	}
+	if err := ctx.InitToken(slots[0], pin, tokenLabel); err != nil {
+		cleanup()
+		t.Fatalf("Failed to initialize the token: %v", err)
+	}
+	// SoftHSM moves the initialized token to a new slot.
+	slot, err := (&signer{ctx: ctx}).findSlot(tokenLabel)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	session, err := ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION|p11.CKF_RW_SESSION)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	if err := ctx.Login(session, p11.CKU_SO, pin); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	if err := ctx.InitPIN(session, pin); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	_ = ctx.Logout(session)
+	if err := ctx.Login(session, p11.CKU_USER, pin); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+
+	keyPairs := []struct {
+		label     string
+		mechanism uint
+		public    []*p11.Attribute
+	}{
+		{
+			label:     "rsa",
+			mechanism: p11.CKM_RSA_PKCS_KEY_PAIR_GEN,
+			public: []*p11.Attribute{
+				p11.NewAttribute(p11.CKA_MODULUS_BITS, 2048),
+				p11.NewAttribute(p11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+			},
+		},
+		{
+			label:     "ecdsa",
+			mechanism: p11.CKM_EC_KEY_PAIR_GEN,
+			public: []*p11.Attribute{
+				// The DER encoding of the P-256 object identifier.
+				p11.NewAttribute(p11.CKA_EC_PARAMS, []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}),
+			},
+		},
+	}
+	for _, keyPair := range keyPairs {
+		public := append([]*p11.Attribute{
+			p11.NewAttribute(p11.CKA_TOKEN, true),
+			p11.NewAttribute(p11.CKA_VERIFY, true),
+			p11.NewAttribute(p11.CKA_LABEL, keyPair.label),
+		}, keyPair.public...)
+		private := []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_TOKEN, true),
+			p11.NewAttribute(p11.CKA_SIGN, true),
+			p11.NewAttribute(p11.CKA_SENSITIVE, true),
+			p11.NewAttribute(p11.CKA_LABEL, keyPair.label),
+		}
+		if _, _, err := ctx.GenerateKeyPair(session, []*p11.Mechanism{p11.NewMechanism(keyPair.mechanism, nil)},
+			public, private); err != nil {
+			cleanup()
+			t.Fatalf("Failed to generate the %s key pair: %v", keyPair.label, err)
+		}
+	}
+	_ = ctx.CloseSession(session)
+	return cleanup
+}
+
+func TestSigner(t *testing.T) {
+	module := softHSMModule(t)
+	cleanup := initToken(t, module)
+	defer cleanup()
+
+	digest := sha256.Sum256([]byte("message"))
+	for _, keyLabel := range []string{"rsa", "ecdsa"} {
+		s, err := NewSigner(module, tokenLabel, pin, keyLabel)
+		if err != nil {
+			t.Fatalf("Failed to create the %s signer: %v", keyLabel, err)
+		}
+		signature, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			t.Fatalf("Failed to sign with the %s key: %v", keyLabel, err)
+		}
+		switch publicKey := s.Public().(type) {
+		case *rsa.PublicKey:
+			if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature); err != nil {
+				t.Errorf("Invalid RSA signature: %v", err)
+			}
+		case *ecdsa.PublicKey:
+			var rs struct{ R, S *big.Int }
+			if _, err := asn1.Unmarshal(signature, &rs); err != nil || !ecdsa.Verify(publicKey, digest[:], rs.R, rs.S) {
+				t.Errorf("Invalid ECDSA signature: %v", err)
+			}
+		default:
+			t.Errorf("Unexpected public key type %T", publicKey)
+		}
+		if _, err := s.Sign(rand.Reader, digest[:], &rsa.PSSOptions{Hash: crypto.SHA256}); err == nil {
+			t.Errorf("Expected an error signing with RSA-PSS")
+		}
+	}
+
+	if _, err := NewSigner(module, "does-not-exist", pin, "rsa"); err == nil {
+		t.Errorf("Expected an error with an unknown token")
+	}
+	if _, err := NewSigner(module, tokenLabel, pin, "does-not-exist"); err == nil {
+		t.Errorf("Expected an error with an unknown key")
+	}
+}
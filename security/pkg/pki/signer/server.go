@@ -0,0 +1,66 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "istio.io/istio/security/proto"
+	"istio.io/pkg/log"
+)
+
+// Server implements the external signer gRPC service with a crypto.Signer, e.g. a key file or a PKCS #11 key.
+type Server struct {
+	signer crypto.Signer
+}
+
+// NewServer returns a Server signing with the signer.
+func NewServer(signer crypto.Signer) *Server {
+	return &Server{signer: signer}
+}
+
+// GetPublicKey returns the public key of the signer.
+func (s *Server) GetPublicKey(ctx context.Context, request *pb.GetPublicKeyRequest) (*pb.GetPublicKeyResponse, error) {
+	publicKey, err := x509.MarshalPKIXPublicKey(s.signer.Public())
+	if err != nil {
+		log.Errorf("Failed to marshal the public key: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to marshal the public key (%v)", err)
+	}
+	return &pb.GetPublicKeyResponse{PublicKey: publicKey}, nil
+}
+
+// Sign signs the digest with the signer.
+func (s *Server) Sign(ctx context.Context, request *pb.SignRequest) (*pb.SignResponse, error) {
+	hash, ok := hashAlgorithms[request.HashAlgorithm]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported hash algorithm %q", request.HashAlgorithm)
+	}
+	if len(request.Digest) != hash.Size() {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s digest length %d", request.HashAlgorithm,
+			len(request.Digest))
+	}
+	signature, err := s.signer.Sign(rand.Reader, request.Digest, hash)
+	if err != nil {
+		log.Errorf("Failed to sign the digest: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to sign the digest (%v)", err)
+	}
+	return &pb.SignResponse{Signature: signature}, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "istio.io/istio/security/proto"
+)
+
+const (
+	caKeyFile   = "../testdata/multilevelpki/int2-key.pem"
+	badKeyFile  = "../testdata/key-parse-fail.pem"
+	testTimeout = 10 * time.Second
+)
+
+func startServer(t *testing.T, signer crypto.Signer) (string, func()) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterExternalSignerServiceServer(server, NewServer(signer))
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	return lis.Addr().String(), server.Stop
+}
+
+func TestGRPCSigner(t *testing.T) {
+	fileSigner, err := NewFileSigner(caKeyFile)
+	if err != nil {
+		t.Fatalf("Failed to load the file signer: %v", err)
+	}
+	address, stop := startServer(t, fileSigner)
+	defer stop()
+
+	signer, err := NewGRPCSigner(GRPCSignerConfig{Address: address, Timeout: testTimeout})
+	if err != nil {
+		t.Fatalf("Failed to create the gRPC signer: %v", err)
+	}
+	if signer.Public().(*rsa.PublicKey).N.Cmp(fileSigner.Public().(*rsa.PublicKey).N) != 0 {
+		t.Fatalf("The gRPC signer does not have the public key of the external signer")
+	}
+
+	// The gRPC signer issues certificates through crypto/x509.
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"test.ca.org"}},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		t.Fatalf("Failed to sign a certificate with the gRPC signer: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse the certificate: %v", err)
+	}
+	if err := cert.CheckSignatureFrom(cert); err != nil {
+		t.Errorf("The certificate signature is invalid: %v", err)
+	}
+
+	digest := sha256.Sum256([]byte("message"))
+	if _, err := signer.Sign(rand.Reader, digest[:], &rsa.PSSOptions{Hash: crypto.SHA256}); err == nil {
+		t.Errorf("Expected an error signing with RSA-PSS")
+	}
+	if _, err := signer.Sign(rand.Reader, digest[:], crypto.SHA1); err == nil {
+		t.Errorf("Expected an error signing with SHA-1")
+	}
+}
+
+func TestServerSignErrors(t *testing.T) {
+	fileSigner, err := NewFileSigner(caKeyFile)
+	if err != nil {
+		t.Fatalf("Failed to load the file signer: %v", err)
+	}
+	server := NewServer(fileSigner)
+	cases := map[string]*pb.SignRequest{
+		"unsupported hash algorithm": {Digest: make([]byte, 20), HashAlgorithm: "SHA-1"},
+		"invalid digest length":      {Digest: make([]byte, 20), HashAlgorithm: "SHA-256"},
+	}
+	for id, request := range cases {
+		_, err := server.Sign(context.Background(), request)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Case %s: expected an InvalidArgument error, got %v", id, err)
+		}
+	}
+}
+
+func TestNewFileSignerErrors(t *testing.T) {
+	for _, keyFile := range []string{"does/not/exist.pem", badKeyFile} {
+		if _, err := NewFileSigner(keyFile); err == nil {
+			t.Errorf("Expected an error loading %s", keyFile)
+		}
+	}
+}
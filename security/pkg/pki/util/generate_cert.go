@@ -20,6 +20,8 @@ package util
 
 import (
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -34,6 +36,15 @@ import (
 	"istio.io/pkg/log"
 )
 
+// SupportedECSignatureAlgorithms are the types of EC signature algorithms
+// to be used in key generation (e.g. ECDSA or ED25519).
+type SupportedECSignatureAlgorithms string
+
+const (
+	// EcdsaSigAlg generates ECDSA keys on the P-256 curve. Only ECDSA is currently supported.
+	EcdsaSigAlg SupportedECSignatureAlgorithms = "ECDSA"
+)
+
 // CertOptions contains options for generating a new certificate.
 type CertOptions struct {
 	// Comma-separated hostnames and IPs to generate a certificate for.
@@ -59,6 +70,10 @@ type CertOptions struct {
 	// The size of RSA private key to be generated.
 	RSAKeySize int
 
+	// The type of elliptic curve signature algorithm used to generate the private key.
+	// If empty, an RSA key of RSAKeySize is generated.
+	ECSigAlg SupportedECSignatureAlgorithms
+
 	// Whether this certificate is used as signing cert for CA.
 	IsCA bool
 
@@ -80,14 +95,14 @@ type CertOptions struct {
 
 // GenCertKeyFromOptions generates a X.509 certificate and a private key with the given options.
 func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte, err error) {
-	// Generate a RSA private&public key pair.
+	// Generate a RSA or EC private&public key pair.
 	// The public key will be bound to the certificate generated below. The
 	// private key will be used to sign this certificate in the self-signed
 	// case, otherwise the certificate is signed by the signer private key
 	// as specified in the CertOptions.
-	priv, err := rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+	priv, err := genKey(options)
 	if err != nil {
-		return nil, nil, fmt.Errorf("cert generation fails at RSA key generation (%v)", err)
+		return nil, nil, fmt.Errorf("cert generation fails at key generation (%v)", err)
 	}
 	template, err := genCertTemplateFromOptions(options)
 	if err != nil {
@@ -97,7 +112,7 @@ func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte,
 	if !options.IsSelfSigned {
 		signerCert, signerKey = options.SignerCert, options.SignerPriv
 	}
-	certBytes, err := x509.CreateCertificate(rand.Reader, template, signerCert, &priv.PublicKey, signerKey)
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, signerCert, priv.Public(), signerKey)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cert generation fails at X509 cert creation (%v)", err)
 	}
@@ -109,6 +124,18 @@ func GenCertKeyFromOptions(options CertOptions) (pemCert []byte, pemKey []byte,
 	return
 }
 
+// genKey generates the private key of a certificate or CSR with the given options.
+func genKey(options CertOptions) (crypto.Signer, error) {
+	switch options.ECSigAlg {
+	case "":
+		return rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+	case EcdsaSigAlg:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported EC signature algorithm %q", options.ECSigAlg)
+	}
+}
+
 // GenCertFromCSR generates a X.509 certificate with the given CSR.
 func GenCertFromCSR(csr *x509.CertificateRequest, signingCert *x509.Certificate, publicKey interface{},
 	signingKey crypto.PrivateKey, subjectIDs []string, ttl time.Duration, isCA bool) (cert []byte, err error) {
@@ -147,18 +174,19 @@ func LoadSignerCredsFromFiles(signerCertFile string, signerPrivFile string) (*x5
 }
 
 // genCertTemplateFromCSR generates a certificate template with the given CSR.
-// The NotBefore value of the cert is set to current time.
+// The NotBefore value of the cert is set to current time. The signature algorithm
+// is left to match the signing key, which may be of a different type than the CSR key.
 func genCertTemplateFromCSR(csr *x509.CertificateRequest, subjectIDs []string, ttl time.Duration, isCA bool) (
 	*x509.Certificate, error) {
 	subjectIDsInString := strings.Join(subjectIDs, ",")
 	var keyUsage x509.KeyUsage
 	extKeyUsages := []x509.ExtKeyUsage{}
 	if isCA {
-		// If the cert is a CA cert, the private key is allowed to sign other certificates.
-		keyUsage = x509.KeyUsageCertSign
+		// If the cert is a CA cert, the private key is allowed to sign other certificates and revocation lists.
+		keyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
 	} else {
-		// Otherwise the private key is allowed for digital signature and key encipherment.
-		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+		// Otherwise the private key is allowed for digital signature, and key encipherment for RSA keys.
+		keyUsage = leafKeyUsage(csr.PublicKeyAlgorithm == x509.ECDSA)
 		// For now, we do not differentiate non-CA certs to be used on client auth or server auth.
 		extKeyUsages = append(extKeyUsages, x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth)
 	}
@@ -198,19 +226,18 @@ func genCertTemplateFromCSR(csr *x509.CertificateRequest, subjectIDs []string, t
 		ExtKeyUsage:           extKeyUsages,
 		IsCA:                  isCA,
 		BasicConstraintsValid: true,
-		ExtraExtensions:       exts,
-		SignatureAlgorithm:    csr.SignatureAlgorithm}, nil
+		ExtraExtensions:       exts}, nil
 }
 
 // genCertTemplateFromoptions generates a certificate template with the given options.
 func genCertTemplateFromOptions(options CertOptions) (*x509.Certificate, error) {
 	var keyUsage x509.KeyUsage
 	if options.IsCA {
-		// If the cert is a CA cert, the private key is allowed to sign other certificates.
-		keyUsage = x509.KeyUsageCertSign
+		// If the cert is a CA cert, the private key is allowed to sign other certificates and revocation lists.
+		keyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
 	} else {
-		// Otherwise the private key is allowed for digital signature and key encipherment.
-		keyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+		// Otherwise the private key is allowed for digital signature, and key encipherment for RSA keys.
+		keyUsage = leafKeyUsage(options.ECSigAlg != "")
 	}
 
 	extKeyUsages := []x509.ExtKeyUsage{}
@@ -265,6 +292,14 @@ func genCertTemplateFromOptions(options CertOptions) (*x509.Certificate, error)
 		ExtraExtensions:       exts}, nil
 }
 
+// leafKeyUsage returns the key usage of a non-CA certificate. EC keys can't be used for key encipherment.
+func leafKeyUsage(ecKey bool) x509.KeyUsage {
+	if ecKey {
+		return x509.KeyUsageDigitalSignature
+	}
+	return x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+}
+
 func genSerialNum() (*big.Int, error) {
 	serialNumLimit := new(big.Int).Lsh(big.NewInt(1), 128)
 	serialNum, err := rand.Int(rand.Reader, serialNumLimit)
@@ -274,7 +309,7 @@ func genSerialNum() (*big.Int, error) {
 	return serialNum, nil
 }
 
-func encodePem(isCSR bool, csrOrCert []byte, priv *rsa.PrivateKey, pkcs8 bool) (
+func encodePem(isCSR bool, csrOrCert []byte, priv crypto.PrivateKey, pkcs8 bool) (
 	csrOrCertPem []byte, privPem []byte, err error) {
 	encodeMsg := "CERTIFICATE"
 	if isCSR {
@@ -289,8 +324,18 @@ func encodePem(isCSR bool, csrOrCert []byte, priv *rsa.PrivateKey, pkcs8 bool) (
 		}
 		privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypePKCS8PrivateKey, Bytes: encodedKey})
 	} else {
-		encodedKey = x509.MarshalPKCS1PrivateKey(priv)
-		privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypeRSAPrivateKey, Bytes: encodedKey})
+		switch k := priv.(type) {
+		case *rsa.PrivateKey:
+			encodedKey = x509.MarshalPKCS1PrivateKey(k)
+			privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypeRSAPrivateKey, Bytes: encodedKey})
+		case *ecdsa.PrivateKey:
+			if encodedKey, err = x509.MarshalECPrivateKey(k); err != nil {
+				return nil, nil, err
+			}
+			privPem = pem.EncodeToMemory(&pem.Block{Type: blockTypeECPrivateKey, Bytes: encodedKey})
+		default:
+			return nil, nil, fmt.Errorf("unsupported private key type %T", priv)
+		}
 	}
 	err = nil
 	return
@@ -19,6 +19,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/pem"
 	"strings"
 	"testing"
 	"time"
@@ -56,7 +57,7 @@ func TestGenCertKeyFromOptions(t *testing.T) {
 		NotBefore:   caCertNotBefore,
 		TTL:         caCertTTL,
 		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
-		KeyUsage:    x509.KeyUsageCertSign,
+		KeyUsage:    x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		IsCA:        true,
 		Org:         "MyOrg",
 		Host:        caCertOptions.Host,
@@ -471,3 +472,98 @@ func TestLoadSignerCredsFromFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestGenCertFromECCSR(t *testing.T) {
+	rsaCACertPem, rsaCAPrivPem, err := GenCertKeyFromOptions(CertOptions{
+		Host:         "spiffe://test.com/ns/istio-system/sa/istio-citadel",
+		TTL:          time.Hour,
+		Org:          "MyOrg",
+		IsCA:         true,
+		IsSelfSigned: true,
+		RSAKeySize:   1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecCACertPem, ecCAPrivPem, err := GenCertKeyFromOptions(CertOptions{
+		Host:         "spiffe://test.com/ns/istio-system/sa/istio-citadel",
+		TTL:          time.Hour,
+		Org:          "MyOrg",
+		IsCA:         true,
+		IsSelfSigned: true,
+		ECSigAlg:     EcdsaSigAlg,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		caCertPem  []byte
+		caPrivPem  []byte
+		csrOptions CertOptions
+		keyUsage   x509.KeyUsage
+	}{
+		{
+			name:       "EC CSR signed by RSA CA",
+			caCertPem:  rsaCACertPem,
+			caPrivPem:  rsaCAPrivPem,
+			csrOptions: CertOptions{Host: "spiffe://test.com/ns/default/sa/foo", ECSigAlg: EcdsaSigAlg},
+			keyUsage:   x509.KeyUsageDigitalSignature,
+		},
+		{
+			name:       "EC CSR signed by EC CA",
+			caCertPem:  ecCACertPem,
+			caPrivPem:  ecCAPrivPem,
+			csrOptions: CertOptions{Host: "spiffe://test.com/ns/default/sa/foo", ECSigAlg: EcdsaSigAlg, PKCS8Key: true},
+			keyUsage:   x509.KeyUsageDigitalSignature,
+		},
+		{
+			name:       "RSA CSR signed by EC CA",
+			caCertPem:  ecCACertPem,
+			caPrivPem:  ecCAPrivPem,
+			csrOptions: CertOptions{Host: "spiffe://test.com/ns/default/sa/foo", RSAKeySize: 1024},
+			keyUsage:   x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			caCert, err := ParsePemEncodedCertificate(c.caCertPem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			caPriv, err := ParsePemEncodedKey(c.caPrivPem)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			csrPem, privPem, err := GenCSR(c.csrOptions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			csr, err := ParsePemEncodedCSR(csrPem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := csr.CheckSignature(); err != nil {
+				t.Fatalf("CSR signature is invalid: %v", err)
+			}
+
+			certDer, err := GenCertFromCSR(csr, caCert, csr.PublicKey, caPriv, []string{c.csrOptions.Host}, time.Hour, false)
+			if err != nil {
+				t.Fatalf("failed to sign the CSR: %v", err)
+			}
+			certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})
+
+			fields := &VerifyFields{
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+				KeyUsage:    c.keyUsage,
+				Host:        c.csrOptions.Host,
+			}
+			if err := VerifyCertificate(privPem, certPem, c.caCertPem, fields); err != nil {
+				t.Errorf("cert verification error: %v", err)
+			}
+		})
+	}
+}
@@ -21,7 +21,6 @@ package util
 import (
 	"crypto"
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
@@ -32,9 +31,9 @@ import (
 // GenCSR generates a X.509 certificate sign request and private key with the given options.
 func GenCSR(options CertOptions) ([]byte, []byte, error) {
 	// Generates a CSR
-	priv, err := rsa.GenerateKey(rand.Reader, options.RSAKeySize)
+	priv, err := genKey(options)
 	if err != nil {
-		return nil, nil, fmt.Errorf("RSA key generation failed (%v)", err)
+		return nil, nil, fmt.Errorf("key generation failed (%v)", err)
 	}
 	template, err := GenCSRTemplate(options)
 	if err != nil {
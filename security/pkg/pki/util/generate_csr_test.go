@@ -69,6 +69,43 @@ func TestGenCSRWithInvalidOption(t *testing.T) {
 	}
 }
 
+func TestGenCSRWithECDSA(t *testing.T) {
+	for _, pkcs8 := range []bool{false, true} {
+		csrPem, privPem, err := GenCSR(CertOptions{
+			Host:     "test_ca.com",
+			Org:      "MyOrg",
+			ECSigAlg: EcdsaSigAlg,
+			PKCS8Key: pkcs8,
+		})
+		if err != nil {
+			t.Fatalf("failed to gen CSR: %v", err)
+		}
+
+		csr, err := ParsePemEncodedCSR(csrPem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = csr.CheckSignature(); err != nil {
+			t.Errorf("csr signature is invalid: %v", err)
+		}
+		if csr.PublicKeyAlgorithm != x509.ECDSA {
+			t.Errorf("got public key algorithm %v, want ECDSA", csr.PublicKeyAlgorithm)
+		}
+
+		block, _ := pem.Decode(privPem)
+		if want := map[bool]string{false: blockTypeECPrivateKey, true: blockTypePKCS8PrivateKey}[pkcs8]; block.Type != want {
+			t.Errorf("got private key PEM type %q, want %q", block.Type, want)
+		}
+		if _, err := ParsePemEncodedKey(privPem); err != nil {
+			t.Errorf("failed to parse private key: %v", err)
+		}
+	}
+
+	if _, _, err := GenCSR(CertOptions{Host: "test_ca.com", ECSigAlg: "ED25519"}); err == nil {
+		t.Errorf("expected an error for an unsupported EC signature algorithm")
+	}
+}
+
 func TestGenCSRTemplateForDualUse(t *testing.T) {
 	tt := map[string]struct {
 		host       string
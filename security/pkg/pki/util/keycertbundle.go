@@ -19,7 +19,9 @@
 package util
 
 import (
+	"bytes"
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -101,6 +103,36 @@ func NewVerifiedKeyCertBundleFromFile(certFile, privKeyFile, certChainFile, root
 	return NewVerifiedKeyCertBundleFromPem(certBytes, privKeyBytes, certChainBytes, rootCertBytes)
 }
 
+// NewVerifiedKeyCertBundleFromSigner returns a new KeyCertBundle whose private key is held by the signer, e.g. in
+// an HSM, or error if the provided certs failed the verification. The private key PEM of the bundle is empty.
+func NewVerifiedKeyCertBundleFromSigner(certBytes []byte, signer crypto.Signer, certChainBytes, rootCertBytes []byte) (
+	*KeyCertBundleImpl, error) {
+	cert, err := verifyCertChain(certBytes, certChainBytes, rootCertBytes)
+	if err != nil {
+		return nil, err
+	}
+	certPublicKey, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the cert public key: %v", err)
+	}
+	signerPublicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the signer public key: %v", err)
+	}
+	if !bytes.Equal(certPublicKey, signerPublicKey) {
+		return nil, fmt.Errorf("the cert does not match the signer public key")
+	}
+	privKey := crypto.PrivateKey(signer)
+	return &KeyCertBundleImpl{
+		certBytes:      copyBytes(certBytes),
+		cert:           cert,
+		privKeyBytes:   []byte{},
+		privKey:        &privKey,
+		certChainBytes: copyBytes(certChainBytes),
+		rootCertBytes:  copyBytes(rootCertBytes),
+	}, nil
+}
+
 // NewKeyCertBundleWithRootCertFromFile returns a new KeyCertBundle with the root cert without verification.
 func NewKeyCertBundleWithRootCertFromFile(rootCertFile string) (*KeyCertBundleImpl, error) {
 	rootCertBytes, err := ioutil.ReadFile(rootCertFile)
@@ -186,23 +218,45 @@ func (b *KeyCertBundleImpl) CertOptions() (*CertOptions, error) {
 	if len(ids) != 1 {
 		return nil, fmt.Errorf("expect single id from the cert, found %v", ids)
 	}
-	size, err := GetRSAKeySize(*b.privKey)
-	if err != nil {
+	options := &CertOptions{
+		Host:      ids[0],
+		Org:       b.cert.Issuer.Organization[0],
+		IsCA:      b.cert.IsCA,
+		TTL:       b.cert.NotAfter.Sub(b.cert.NotBefore),
+		IsDualUse: ids[0] == b.cert.Subject.CommonName,
+	}
+	if _, ok := (*b.privKey).(*ecdsa.PrivateKey); ok {
+		options.ECSigAlg = EcdsaSigAlg
+		return options, nil
+	}
+	if options.RSAKeySize, err = GetRSAKeySize(*b.privKey); err != nil {
 		return nil, fmt.Errorf("failed to get RSA key size: %v", err)
 	}
-	return &CertOptions{
-		Host:       ids[0],
-		Org:        b.cert.Issuer.Organization[0],
-		IsCA:       b.cert.IsCA,
-		TTL:        b.cert.NotAfter.Sub(b.cert.NotBefore),
-		RSAKeySize: size,
-		IsDualUse:  ids[0] == b.cert.Subject.CommonName,
-	}, nil
+	return options, nil
 }
 
 // Verify that the cert chain, root cert and key/cert match.
 func Verify(certBytes, privKeyBytes, certChainBytes, rootCertBytes []byte) error {
-	// Verify the cert can be verified from the root cert through the cert chain.
+	if _, err := verifyCertChain(certBytes, certChainBytes, rootCertBytes); err != nil {
+		return err
+	}
+
+	// Verify that the key can be correctly parsed.
+	if _, err := ParsePemEncodedKey(privKeyBytes); err != nil {
+		return fmt.Errorf("failed to parse private key PEM: %v", err)
+	}
+
+	// Verify the cert and key match.
+	if _, err := tls.X509KeyPair(certBytes, privKeyBytes); err != nil {
+		return fmt.Errorf("the cert does not match the key")
+	}
+
+	return nil
+}
+
+// verifyCertChain verifies the cert can be verified from the root cert through the cert chain, and returns the
+// parsed cert.
+func verifyCertChain(certBytes, certChainBytes, rootCertBytes []byte) (*x509.Certificate, error) {
 	rcp := x509.NewCertPool()
 	rcp.AppendCertsFromPEM(rootCertBytes)
 
@@ -215,27 +269,16 @@ func Verify(certBytes, privKeyBytes, certChainBytes, rootCertBytes []byte) error
 	}
 	cert, err := ParsePemEncodedCertificate(certBytes)
 	if err != nil {
-		return fmt.Errorf("failed to parse cert PEM: %v", err)
+		return nil, fmt.Errorf("failed to parse cert PEM: %v", err)
 	}
 	chains, err := cert.Verify(opts)
 
 	if len(chains) == 0 || err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"cannot verify the cert with the provided root chain and cert "+
 				"pool with error: %v", err)
 	}
-
-	// Verify that the key can be correctly parsed.
-	if _, err = ParsePemEncodedKey(privKeyBytes); err != nil {
-		return fmt.Errorf("failed to parse private key PEM: %v", err)
-	}
-
-	// Verify the cert and key match.
-	if _, err := tls.X509KeyPair(certBytes, privKeyBytes); err != nil {
-		return fmt.Errorf("the cert does not match the key")
-	}
-
-	return nil
+	return cert, nil
 }
 
 func copyBytes(src []byte) []byte {
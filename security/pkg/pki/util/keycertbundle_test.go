@@ -15,6 +15,8 @@
 package util
 
 import (
+	"crypto"
+	"io/ioutil"
 	"strings"
 	"testing"
 	"time"
@@ -167,6 +169,31 @@ func TestCertOptionsAndRetrieveID(t *testing.T) {
 	}
 }
 
+func TestCertOptionsWithECKey(t *testing.T) {
+	certPem, keyPem, err := GenCertKeyFromOptions(CertOptions{
+		Host:         "spiffe://test.com/ns/istio-system/sa/istio-citadel",
+		TTL:          time.Hour,
+		Org:          "MyOrg",
+		IsCA:         true,
+		IsSelfSigned: true,
+		ECSigAlg:     EcdsaSigAlg,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewVerifiedKeyCertBundleFromPem(certPem, keyPem, nil, certPem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := k.CertOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.ECSigAlg != EcdsaSigAlg || opts.RSAKeySize != 0 {
+		t.Errorf("got EC signature algorithm %q and RSA key size %d, want ECDSA and 0", opts.ECSigAlg, opts.RSAKeySize)
+	}
+}
+
 func compareCertOptions(actual, expected *CertOptions, t *testing.T) {
 	if actual.Host != expected.Host {
 		t.Errorf("host does not match, %s vs %s", actual.Host, expected.Host)
@@ -303,3 +330,60 @@ func TestNewVerifiedKeyCertBundleFromFile(t *testing.T) {
 		}
 	}
 }
+
+func TestNewVerifiedKeyCertBundleFromSigner(t *testing.T) {
+	testCases := map[string]struct {
+		caKeyFile   string
+		expectedErr string
+	}{
+		"Success": {
+			caKeyFile:   int2KeyFile,
+			expectedErr: "",
+		},
+		"Failure - cert and signer do not match": {
+			caKeyFile:   anotherKeyFile,
+			expectedErr: "the cert does not match the signer public key",
+		},
+	}
+	certBytes, err := ioutil.ReadFile(int2CertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certChainBytes, err := ioutil.ReadFile(int2CertChainFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCertBytes, err := ioutil.ReadFile(rootCertFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, tc := range testCases {
+		keyBytes, err := ioutil.ReadFile(tc.caKeyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := ParsePemEncodedKey(keyBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundle, err := NewVerifiedKeyCertBundleFromSigner(certBytes, key.(crypto.Signer), certChainBytes, rootCertBytes)
+		if err != nil {
+			if tc.expectedErr == "" {
+				t.Errorf("%s: Unexpected error: %v", id, err)
+			} else if err.Error() != tc.expectedErr {
+				t.Errorf("%s: Unexpected error: %v VS (expected) %s", id, err, tc.expectedErr)
+			}
+			continue
+		}
+		if tc.expectedErr != "" {
+			t.Errorf("%s: Expected error %s but succeeded", id, tc.expectedErr)
+			continue
+		}
+		if _, privKey, _, _ := bundle.GetAll(); *privKey != key {
+			t.Errorf("%s: the bundle does not sign with the signer", id)
+		}
+		if _, privKeyBytes, _, _ := bundle.GetAllPem(); len(privKeyBytes) != 0 {
+			t.Errorf("%s: the bundle exposes a private key PEM", id)
+		}
+	}
+}
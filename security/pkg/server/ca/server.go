@@ -26,6 +26,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"istio.io/istio/security/pkg/pki/ca"
@@ -50,6 +51,12 @@ type authenticator interface {
 	AuthenticatorType() string
 }
 
+// revocationList keeps track of the revoked certificates, see ca.RevocationList.
+type revocationList interface {
+	Revoke(serialNumber string, reason int) ([]byte, error)
+	IsRevoked(cert *x509.Certificate) bool
+}
+
 // Server implements IstioCAService and IstioCertificateService and provides the services on the
 // specified port.
 type Server struct {
@@ -62,6 +69,11 @@ type Server struct {
 	certificate    *tls.Certificate
 	port           int
 	forCA          bool
+
+	// revocation is nil if certificate revocation is disabled.
+	revocation revocationList
+	// revocationAdmins are the identities allowed to revoke certificates.
+	revocationAdmins []string
 }
 
 // CreateCertificate handles an incoming certificate signing request (CSR). It does
@@ -102,6 +114,77 @@ func (s *Server) CreateCertificate(ctx context.Context, request *pb.IstioCertifi
 	return response, nil
 }
 
+// RevokeCertificate handles an incoming certificate revocation request. The caller must be authenticated
+// with one of the revocation admin identities. Upon validated, adds the certificate to the revoked
+// certificates and returns the updated certificate revocation list.
+func (s *Server) RevokeCertificate(ctx context.Context, request *pb.RevokeCertificateRequest) (
+	*pb.RevokeCertificateResponse, error) {
+	caller := s.authenticate(ctx)
+	if caller == nil {
+		log.Warn("request authentication failure")
+		s.monitoring.AuthnError.Increment()
+		return nil, status.Error(codes.Unauthenticated, "request authenticate failure")
+	}
+	if s.revocation == nil {
+		return nil, status.Error(codes.Unimplemented, "certificate revocation is not enabled")
+	}
+	if !s.isRevocationAdmin(caller) {
+		log.Warnf("certificate revocation denied for %v", caller.Identities)
+		return nil, status.Errorf(codes.PermissionDenied, "%v is not allowed to revoke certificates", caller.Identities)
+	}
+
+	crl, err := s.revocation.Revoke(request.SerialNumber, int(request.Reason))
+	if err != nil {
+		log.Errorf("certificate revocation error (%v)", err)
+		return nil, status.Errorf(err.(*ca.Error).HTTPErrorCode(), "certificate revocation error (%v)", err)
+	}
+	log.Infof("Certificate %s revoked by %v", request.SerialNumber, caller.Identities)
+
+	return &pb.RevokeCertificateResponse{Crl: string(crl)}, nil
+}
+
+// EnableRevocation enables the RevokeCertificate API for the admin identities, and rejects the requests
+// authenticated with a revoked client certificate.
+func (s *Server) EnableRevocation(revocation *ca.RevocationList, admins []string) {
+	if revocation == nil {
+		return
+	}
+	s.revocation = revocation
+	s.revocationAdmins = admins
+}
+
+func (s *Server) isRevocationAdmin(caller *authenticate.Caller) bool {
+	for _, id := range caller.Identities {
+		for _, admin := range s.revocationAdmins {
+			if id == admin {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isPeerCertRevoked returns whether the request is made with a revoked client certificate.
+func (s *Server) isPeerCertRevoked(ctx context.Context) bool {
+	if s.revocation == nil {
+		return false
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return false
+	}
+	for _, chain := range tlsInfo.State.VerifiedChains {
+		if len(chain) > 0 && s.revocation.IsRevoked(chain[0]) {
+			return true
+		}
+	}
+	return false
+}
+
 // extractRootCertExpiryTimestamp returns the unix timestamp when the root becomes expires.
 func extractRootCertExpiryTimestamp(ca ca.CertificateAuthority) float64 {
 	rb := ca.GetCAKeyCertBundle().GetRootCertPem()
@@ -252,13 +335,10 @@ func New(ca ca.CertificateAuthority, ttl time.Duration, forCA bool, hostlist []s
 }
 
 func (s *Server) createTLSServerOption() grpc.ServerOption {
-	cp := x509.NewCertPool()
-	rootCertBytes := s.ca.GetCAKeyCertBundle().GetRootCertPem()
-	cp.AppendCertsFromPEM(rootCertBytes)
-
 	config := &tls.Config{
-		ClientCAs:  cp,
 		ClientAuth: tls.VerifyClientCertIfGiven,
+		// The gRPC credentials only add HTTP/2 to their own copy of the config, not to the per-client configs.
+		NextProtos: []string{"h2"},
 		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
 			if s.certificate == nil || shouldRefresh(s.certificate) {
 				// Apply new certificate if there isn't one yet, or the one has become invalid.
@@ -271,6 +351,14 @@ func (s *Server) createTLSServerOption() grpc.ServerOption {
 			return s.certificate, nil
 		},
 	}
+	// The client CAs are read on each handshake, so that they follow the rotation of the CA root certificate.
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cp := x509.NewCertPool()
+		cp.AppendCertsFromPEM(s.ca.GetCAKeyCertBundle().GetRootCertPem())
+		clientConfig := config.Clone()
+		clientConfig.ClientCAs = cp
+		return clientConfig, nil
+	}
 	return grpc.Creds(credentials.NewTLS(config))
 }
 
@@ -302,6 +390,10 @@ func (s *Server) getServerCertificate() (*tls.Certificate, error) {
 // and authenticates if one of them is valid.
 func (s *Server) authenticate(ctx context.Context) *authenticate.Caller {
 	// TODO: apply different authenticators in specific order / according to configuration.
+	if s.isPeerCertRevoked(ctx) {
+		log.Warn("Authentication failed: the client certificate is revoked")
+		return nil
+	}
 	var errMsg string
 	for id, authn := range s.authenticators {
 		u, err := authn.Authenticate(ctx)
@@ -19,12 +19,15 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"math/big"
 	"os"
 	"testing"
 	"time"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"k8s.io/client-go/kubernetes/fake"
 
@@ -358,6 +361,101 @@ func TestHandleCSR(t *testing.T) {
 	}
 }
 
+type mockRevocationList struct {
+	revoked   map[string]bool
+	revokeErr error
+}
+
+func (rl *mockRevocationList) Revoke(serialNumber string, reason int) ([]byte, error) {
+	if rl.revokeErr != nil {
+		return nil, rl.revokeErr
+	}
+	rl.revoked[serialNumber] = true
+	return []byte("crl"), nil
+}
+
+func (rl *mockRevocationList) IsRevoked(cert *x509.Certificate) bool {
+	return rl.revoked[cert.SerialNumber.Text(16)]
+}
+
+func TestRevokeCertificate(t *testing.T) {
+	admin := "spiffe://cluster.local/ns/istio-system/sa/admin"
+	testCases := map[string]struct {
+		authenticators []authenticator
+		revocation     revocationList
+		peerSerial     int64
+		crl            string
+		code           codes.Code
+	}{
+		"Unauthenticated request": {
+			authenticators: []authenticator{&mockAuthenticator{errMsg: "Not authorized"}},
+			revocation:     &mockRevocationList{revoked: map[string]bool{}},
+			code:           codes.Unauthenticated,
+		},
+		"Revocation disabled": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{admin}}},
+			code:           codes.Unimplemented,
+		},
+		"Not an admin": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{"spiffe://cluster.local/ns/foo/sa/bar"}}},
+			revocation:     &mockRevocationList{revoked: map[string]bool{}},
+			code:           codes.PermissionDenied,
+		},
+		"Revoked client certificate": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{admin}}},
+			revocation:     &mockRevocationList{revoked: map[string]bool{"2a": true}},
+			peerSerial:     42,
+			code:           codes.Unauthenticated,
+		},
+		"Invalid request": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{admin}}},
+			revocation: &mockRevocationList{
+				revokeErr: ca.NewError(ca.RevocationError, fmt.Errorf("invalid serial number")),
+			},
+			code: codes.InvalidArgument,
+		},
+		"Failed to update the CRL": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{admin}}},
+			revocation: &mockRevocationList{
+				revokeErr: ca.NewError(ca.CRLError, fmt.Errorf("cannot write the CRL")),
+			},
+			code: codes.Internal,
+		},
+		"Successful revocation": {
+			authenticators: []authenticator{&mockAuthenticator{identities: []string{admin}}},
+			revocation:     &mockRevocationList{revoked: map[string]bool{}},
+			peerSerial:     43,
+			crl:            "crl",
+			code:           codes.OK,
+		},
+	}
+
+	for id, c := range testCases {
+		server := &Server{
+			ca:               &mockca.FakeCA{},
+			authenticators:   c.authenticators,
+			monitoring:       newMonitoringMetrics(),
+			revocation:       c.revocation,
+			revocationAdmins: []string{admin},
+		}
+		ctx := context.Background()
+		if c.peerSerial != 0 {
+			cert := &x509.Certificate{SerialNumber: big.NewInt(c.peerSerial)}
+			ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{
+				State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+			}})
+		}
+
+		response, err := server.RevokeCertificate(ctx, &pb.RevokeCertificateRequest{SerialNumber: "0A1B"})
+		s, _ := status.FromError(err)
+		if code := s.Code(); c.code != code {
+			t.Errorf("Case %s: expecting code to be (%d) but got (%d): %s", id, c.code, code, s.Message())
+		} else if c.code == codes.OK && response.Crl != c.crl {
+			t.Errorf("Case %s: expecting CRL to be (%s) but got (%s)", id, c.crl, response.Crl)
+		}
+	}
+}
+
 func TestShouldRefresh(t *testing.T) {
 	now := time.Now()
 	testCases := map[string]struct {
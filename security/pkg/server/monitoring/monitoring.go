@@ -36,6 +36,7 @@ var (
 // Monitor is the server that exposes Prometheus metrics about Citadel.
 type Monitor struct {
 	monitoringServer *http.Server
+	mux              *http.ServeMux
 	port             int
 	closed           chan bool
 }
@@ -53,6 +54,7 @@ func NewMonitor(port int, enableProfiling bool) (*Monitor, error) {
 	}
 
 	mux := http.NewServeMux()
+	m.mux = mux
 
 	exporter, err := ocprom.NewExporter(ocprom.Options{Registry: prometheus.DefaultRegisterer.(*prometheus.Registry)})
 	if err != nil {
@@ -82,6 +84,12 @@ func NewMonitor(port int, enableProfiling bool) (*Monitor, error) {
 	return m, nil
 }
 
+// Handle registers an additional handler for the pattern, e.g. to publish the certificate revocation list.
+// It must be called before Start.
+func (m *Monitor) Handle(pattern string, handler http.Handler) {
+	m.mux.Handle(pattern, handler)
+}
+
 // Start starts the monitor server.
 func (m *Monitor) Start(errCh chan<- error) {
 	// get the network stuff setup
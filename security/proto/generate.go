@@ -15,5 +15,6 @@
 //go:generate $GOPATH/src/istio.io/istio/bin/mixer_codegen.sh -f security/proto/ca_service.proto
 //go:generate $GOPATH/src/istio.io/istio/bin/mixer_codegen.sh -f security/proto/workload_service.proto
 //go:generate $GOPATH/src/istio.io/istio/bin/mixer_codegen.sh -f security/proto/istioca.proto
+//go:generate $GOPATH/src/istio.io/istio/bin/mixer_codegen.sh -f security/proto/external_signer.proto
 // nolint
 package istio_v1_auth
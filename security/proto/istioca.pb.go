@@ -137,34 +137,139 @@ func (m *IstioCertificateResponse) GetCertChain() []string {
 	return nil
 }
 
+// Certificate revocation request message.
+type RevokeCertificateRequest struct {
+	// Hex-encoded serial number of the certificate to revoke, as printed by 'openssl x509 -serial'.
+	SerialNumber string `protobuf:"bytes,1,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	// Optional: revocation reason code, as defined in RFC 5280 section 5.3.1.
+	Reason int32 `protobuf:"varint,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (m *RevokeCertificateRequest) Reset()      { *m = RevokeCertificateRequest{} }
+func (*RevokeCertificateRequest) ProtoMessage() {}
+func (*RevokeCertificateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9eff2d2b4471d6ff, []int{2}
+}
+func (m *RevokeCertificateRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RevokeCertificateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RevokeCertificateRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RevokeCertificateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokeCertificateRequest.Merge(m, src)
+}
+func (m *RevokeCertificateRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *RevokeCertificateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokeCertificateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokeCertificateRequest proto.InternalMessageInfo
+
+func (m *RevokeCertificateRequest) GetSerialNumber() string {
+	if m != nil {
+		return m.SerialNumber
+	}
+	return ""
+}
+
+func (m *RevokeCertificateRequest) GetReason() int32 {
+	if m != nil {
+		return m.Reason
+	}
+	return 0
+}
+
+// Certificate revocation response message.
+type RevokeCertificateResponse struct {
+	// PEM-encoded certificate revocation list signed by the CA, including the revoked certificate.
+	Crl string `protobuf:"bytes,1,opt,name=crl,proto3" json:"crl,omitempty"`
+}
+
+func (m *RevokeCertificateResponse) Reset()      { *m = RevokeCertificateResponse{} }
+func (*RevokeCertificateResponse) ProtoMessage() {}
+func (*RevokeCertificateResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9eff2d2b4471d6ff, []int{3}
+}
+func (m *RevokeCertificateResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *RevokeCertificateResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_RevokeCertificateResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *RevokeCertificateResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokeCertificateResponse.Merge(m, src)
+}
+func (m *RevokeCertificateResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *RevokeCertificateResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokeCertificateResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokeCertificateResponse proto.InternalMessageInfo
+
+func (m *RevokeCertificateResponse) GetCrl() string {
+	if m != nil {
+		return m.Crl
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*IstioCertificateRequest)(nil), "istio.v1.auth.IstioCertificateRequest")
 	proto.RegisterType((*IstioCertificateResponse)(nil), "istio.v1.auth.IstioCertificateResponse")
+	proto.RegisterType((*RevokeCertificateRequest)(nil), "istio.v1.auth.RevokeCertificateRequest")
+	proto.RegisterType((*RevokeCertificateResponse)(nil), "istio.v1.auth.RevokeCertificateResponse")
 }
 
 func init() { proto.RegisterFile("security/proto/istioca.proto", fileDescriptor_9eff2d2b4471d6ff) }
 
 var fileDescriptor_9eff2d2b4471d6ff = []byte{
-	// 294 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x91, 0xbd, 0x4a, 0x03, 0x41,
-	0x14, 0x85, 0xe7, 0xba, 0x20, 0x64, 0x40, 0x48, 0xb6, 0x71, 0x11, 0xbd, 0x84, 0x14, 0x1a, 0x10,
-	0x36, 0xf8, 0xd3, 0xd8, 0x1a, 0x9b, 0xb4, 0xf1, 0x01, 0xc2, 0x64, 0xf6, 0x86, 0x8c, 0x48, 0x36,
-	0xce, 0xdc, 0x8d, 0xa4, 0xd3, 0x37, 0xf0, 0x31, 0x7c, 0x14, 0xcb, 0x94, 0x29, 0xcd, 0x6c, 0x63,
-	0x99, 0x47, 0x90, 0x59, 0x23, 0x28, 0x41, 0xec, 0xe6, 0x7c, 0x67, 0xe0, 0x9c, 0xc3, 0x95, 0x87,
-	0x8e, 0x74, 0x61, 0x0d, 0xcf, 0x3b, 0x53, 0x9b, 0x73, 0xde, 0x31, 0x8e, 0x4d, 0xae, 0x55, 0x5a,
-	0xa9, 0x78, 0xaf, 0x92, 0xe9, 0xec, 0x2c, 0x55, 0x05, 0x8f, 0x5b, 0x8f, 0x72, 0xbf, 0x17, 0x40,
-	0x97, 0x2c, 0x9b, 0x91, 0xd1, 0x8a, 0xa9, 0x4f, 0x0f, 0x05, 0x39, 0x8e, 0xeb, 0x32, 0xd2, 0xce,
-	0x26, 0xd0, 0x84, 0x76, 0xad, 0x1f, 0x9e, 0xf1, 0x91, 0x94, 0xae, 0x18, 0xde, 0x91, 0xe6, 0x81,
-	0xc9, 0x92, 0x9d, 0xca, 0xa8, 0x6d, 0x48, 0x2f, 0x8b, 0x4f, 0x65, 0x63, 0xa6, 0xee, 0x4d, 0x66,
-	0x78, 0x3e, 0xc8, 0x0a, 0xab, 0xd8, 0xe4, 0x93, 0x24, 0x6a, 0x42, 0x3b, 0xea, 0xd7, 0xbf, 0x8d,
-	0x9b, 0x0d, 0x6f, 0x5d, 0xc9, 0x64, 0x3b, 0xd8, 0x4d, 0xf3, 0x89, 0xa3, 0x90, 0xa3, 0xc9, 0xf2,
-	0x40, 0x8f, 0x95, 0x99, 0x24, 0xd0, 0x8c, 0x42, 0x4e, 0x20, 0xdd, 0x00, 0xce, 0x9f, 0x61, 0xbb,
-	0xf4, 0x2d, 0xd9, 0x99, 0xd1, 0x14, 0x8f, 0x64, 0xa3, 0x6b, 0x49, 0x31, 0xfd, 0xf0, 0xe2, 0xe3,
-	0xf4, 0xd7, 0xe8, 0xf4, 0x8f, 0xc5, 0x07, 0x27, 0xff, 0xfe, 0xfb, 0x2a, 0xd8, 0x12, 0xd7, 0x97,
-	0x8b, 0x15, 0x8a, 0xe5, 0x0a, 0xc5, 0x7a, 0x85, 0xf0, 0xe4, 0x11, 0x5e, 0x3d, 0xc2, 0x9b, 0x47,
-	0x58, 0x78, 0x84, 0x77, 0x8f, 0xf0, 0xe1, 0x51, 0xac, 0x3d, 0xc2, 0x4b, 0x89, 0x62, 0x51, 0xa2,
-	0x58, 0x96, 0x28, 0x86, 0xbb, 0xd5, 0x0d, 0x2e, 0x3e, 0x03, 0x00, 0x00, 0xff, 0xff, 0x23, 0xaa,
-	0x00, 0x39, 0xa3, 0x01, 0x00, 0x00,
+	// 358 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0x85, 0x52, 0xbb, 0x4e, 0xc3, 0x30,
+	0x14, 0x6d, 0x88, 0xa8, 0x54, 0x8b, 0x4a, 0xad, 0x07, 0x08, 0x15, 0x44, 0x28, 0x48, 0x50, 0x09,
+	0x91, 0x8a, 0xc7, 0xc2, 0x4a, 0x58, 0xba, 0x30, 0x84, 0x81, 0x31, 0x72, 0x1d, 0x57, 0x35, 0x84,
+	0xa4, 0xd8, 0x4e, 0x10, 0x1b, 0x9f, 0xc0, 0x67, 0xf0, 0x29, 0x8c, 0x1d, 0x3b, 0xd2, 0xb2, 0x20,
+	0xb1, 0xf0, 0x09, 0x5c, 0xa7, 0x29, 0x02, 0xda, 0xaa, 0xc3, 0x91, 0x73, 0xcf, 0xbd, 0xbe, 0xe7,
+	0xf8, 0x28, 0x68, 0x4b, 0x32, 0x9a, 0x0a, 0xae, 0x1e, 0x5b, 0x7d, 0x91, 0xa8, 0xa4, 0xc5, 0xa5,
+	0xe2, 0x09, 0x25, 0x6e, 0x5e, 0xe1, 0x6a, 0x5e, 0xba, 0xd9, 0x91, 0x4b, 0x52, 0xd5, 0x73, 0x1e,
+	0xd0, 0x46, 0x5b, 0x13, 0x1e, 0x13, 0x8a, 0x77, 0x39, 0x25, 0x8a, 0xf9, 0xec, 0x3e, 0x65, 0x52,
+	0xe1, 0x1a, 0x32, 0xa9, 0x14, 0x96, 0xb1, 0x63, 0x34, 0x2b, 0xbe, 0xfe, 0xc4, 0xdb, 0x08, 0xc9,
+	0xb4, 0x73, 0xc3, 0xa8, 0x0a, 0x78, 0x68, 0xad, 0xe4, 0x8d, 0x4a, 0xc1, 0xb4, 0x43, 0x7c, 0x80,
+	0xea, 0x19, 0x89, 0x78, 0x08, 0xd2, 0x41, 0x98, 0x0a, 0x02, 0x6b, 0x63, 0xcb, 0x84, 0x29, 0xd3,
+	0xaf, 0x4d, 0x1b, 0x17, 0x05, 0xef, 0x9c, 0x21, 0x6b, 0x56, 0x58, 0xf6, 0x93, 0x58, 0x32, 0xad,
+	0x43, 0x81, 0x0e, 0x68, 0x8f, 0xf0, 0x18, 0x0c, 0x98, 0x5a, 0x47, 0x33, 0x9e, 0x26, 0x9c, 0x6b,
+	0x64, 0xf9, 0x2c, 0x4b, 0x6e, 0xd9, 0x1c, 0xd3, 0xbb, 0xa8, 0x2a, 0x99, 0xe0, 0x24, 0x0a, 0xe2,
+	0xf4, 0xae, 0xc3, 0xa6, 0xf6, 0xd7, 0x26, 0xe4, 0x65, 0xce, 0xe1, 0x75, 0x54, 0x16, 0x8c, 0x48,
+	0x70, 0xa7, 0xdf, 0xb0, 0xea, 0x17, 0x95, 0x73, 0x88, 0x36, 0xe7, 0x2c, 0x2e, 0x4c, 0xe9, 0x38,
+	0x44, 0xf4, 0x13, 0x87, 0x88, 0x8e, 0x3f, 0x8d, 0xd9, 0xf0, 0xae, 0x98, 0xc8, 0x38, 0x65, 0xb8,
+	0x8b, 0xea, 0x1e, 0x6c, 0x55, 0xbf, 0x57, 0xe1, 0x3d, 0xf7, 0x4f, 0xf8, 0xee, 0x82, 0xe4, 0x1b,
+	0xfb, 0x4b, 0xe7, 0x26, 0x9e, 0x9c, 0x12, 0xee, 0xa1, 0xfa, 0x8c, 0x65, 0xfc, 0xff, 0xfe, 0xa2,
+	0xb4, 0x1a, 0xcd, 0xe5, 0x83, 0x53, 0xa5, 0xf3, 0xd3, 0xc1, 0xc8, 0x2e, 0x0d, 0x01, 0x5f, 0x23,
+	0xdb, 0x78, 0x1a, 0xdb, 0xc6, 0x0b, 0xe0, 0x15, 0x30, 0x00, 0xbc, 0x01, 0x3e, 0xc6, 0xd0, 0x83,
+	0xf3, 0xf9, 0xdd, 0x2e, 0x0d, 0x00, 0x43, 0x40, 0xa7, 0x9c, 0xff, 0x75, 0x27, 0xdf, 0x2b, 0x64,
+	0xdc, 0x55, 0x95, 0x02, 0x00, 0x00,
 }
 
 func (this *IstioCertificateRequest) Equal(that interface{}) bool {
@@ -226,6 +331,57 @@ func (this *IstioCertificateResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *RevokeCertificateRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*RevokeCertificateRequest)
+	if !ok {
+		that2, ok := that.(RevokeCertificateRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.SerialNumber != that1.SerialNumber {
+		return false
+	}
+	if this.Reason != that1.Reason {
+		return false
+	}
+	return true
+}
+func (this *RevokeCertificateResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*RevokeCertificateResponse)
+	if !ok {
+		that2, ok := that.(RevokeCertificateResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Crl != that1.Crl {
+		return false
+	}
+	return true
+}
 func (this *IstioCertificateRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -248,6 +404,27 @@ func (this *IstioCertificateResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *RevokeCertificateRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&istio_v1_auth.RevokeCertificateRequest{")
+	s = append(s, "SerialNumber: "+fmt.Sprintf("%#v", this.SerialNumber)+",\n")
+	s = append(s, "Reason: "+fmt.Sprintf("%#v", this.Reason)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *RevokeCertificateResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&istio_v1_auth.RevokeCertificateResponse{")
+	s = append(s, "Crl: "+fmt.Sprintf("%#v", this.Crl)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringIstioca(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -271,6 +448,8 @@ const _ = grpc.SupportPackageIsVersion4
 type IstioCertificateServiceClient interface {
 	// Using provided CSR, returns a signed certificate.
 	CreateCertificate(ctx context.Context, in *IstioCertificateRequest, opts ...grpc.CallOption) (*IstioCertificateResponse, error)
+	// Revokes a certificate issued by the CA, and returns the updated certificate revocation list.
+	RevokeCertificate(ctx context.Context, in *RevokeCertificateRequest, opts ...grpc.CallOption) (*RevokeCertificateResponse, error)
 }
 
 type istioCertificateServiceClient struct {
@@ -290,10 +469,21 @@ func (c *istioCertificateServiceClient) CreateCertificate(ctx context.Context, i
 	return out, nil
 }
 
+func (c *istioCertificateServiceClient) RevokeCertificate(ctx context.Context, in *RevokeCertificateRequest, opts ...grpc.CallOption) (*RevokeCertificateResponse, error) {
+	out := new(RevokeCertificateResponse)
+	err := c.cc.Invoke(ctx, "/istio.v1.auth.IstioCertificateService/RevokeCertificate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // IstioCertificateServiceServer is the server API for IstioCertificateService service.
 type IstioCertificateServiceServer interface {
 	// Using provided CSR, returns a signed certificate.
 	CreateCertificate(context.Context, *IstioCertificateRequest) (*IstioCertificateResponse, error)
+	// Revokes a certificate issued by the CA, and returns the updated certificate revocation list.
+	RevokeCertificate(context.Context, *RevokeCertificateRequest) (*RevokeCertificateResponse, error)
 }
 
 // UnimplementedIstioCertificateServiceServer can be embedded to have forward compatible implementations.
@@ -303,6 +493,9 @@ type UnimplementedIstioCertificateServiceServer struct {
 func (*UnimplementedIstioCertificateServiceServer) CreateCertificate(ctx context.Context, req *IstioCertificateRequest) (*IstioCertificateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateCertificate not implemented")
 }
+func (*UnimplementedIstioCertificateServiceServer) RevokeCertificate(ctx context.Context, req *RevokeCertificateRequest) (*RevokeCertificateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeCertificate not implemented")
+}
 
 func RegisterIstioCertificateServiceServer(s *grpc.Server, srv IstioCertificateServiceServer) {
 	s.RegisterService(&_IstioCertificateService_serviceDesc, srv)
@@ -326,6 +519,24 @@ func _IstioCertificateService_CreateCertificate_Handler(srv interface{}, ctx con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _IstioCertificateService_RevokeCertificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeCertificateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IstioCertificateServiceServer).RevokeCertificate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/istio.v1.auth.IstioCertificateService/RevokeCertificate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IstioCertificateServiceServer).RevokeCertificate(ctx, req.(*RevokeCertificateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _IstioCertificateService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "istio.v1.auth.IstioCertificateService",
 	HandlerType: (*IstioCertificateServiceServer)(nil),
@@ -334,6 +545,10 @@ var _IstioCertificateService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "CreateCertificate",
 			Handler:    _IstioCertificateService_CreateCertificate_Handler,
 		},
+		{
+			MethodName: "RevokeCertificate",
+			Handler:    _IstioCertificateService_RevokeCertificate_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "security/proto/istioca.proto",
@@ -413,6 +628,71 @@ func (m *IstioCertificateResponse) MarshalToSizedBuffer(dAtA []byte) (int, error
 	return len(dAtA) - i, nil
 }
 
+func (m *RevokeCertificateRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RevokeCertificateRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RevokeCertificateRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Reason != 0 {
+		i = encodeVarintIstioca(dAtA, i, uint64(m.Reason))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.SerialNumber) > 0 {
+		i -= len(m.SerialNumber)
+		copy(dAtA[i:], m.SerialNumber)
+		i = encodeVarintIstioca(dAtA, i, uint64(len(m.SerialNumber)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *RevokeCertificateResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RevokeCertificateResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *RevokeCertificateResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Crl) > 0 {
+		i -= len(m.Crl)
+		copy(dAtA[i:], m.Crl)
+		i = encodeVarintIstioca(dAtA, i, uint64(len(m.Crl)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintIstioca(dAtA []byte, offset int, v uint64) int {
 	offset -= sovIstioca(v)
 	base := offset
@@ -459,6 +739,35 @@ func (m *IstioCertificateResponse) Size() (n int) {
 	return n
 }
 
+func (m *RevokeCertificateRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.SerialNumber)
+	if l > 0 {
+		n += 1 + l + sovIstioca(uint64(l))
+	}
+	if m.Reason != 0 {
+		n += 1 + sovIstioca(uint64(m.Reason))
+	}
+	return n
+}
+
+func (m *RevokeCertificateResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Crl)
+	if l > 0 {
+		n += 1 + l + sovIstioca(uint64(l))
+	}
+	return n
+}
+
 func sovIstioca(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -487,6 +796,27 @@ func (this *IstioCertificateResponse) String() string {
 	}, "")
 	return s
 }
+func (this *RevokeCertificateRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RevokeCertificateRequest{`,
+		`SerialNumber:` + fmt.Sprintf("%v", this.SerialNumber) + `,`,
+		`Reason:` + fmt.Sprintf("%v", this.Reason) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *RevokeCertificateResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&RevokeCertificateResponse{`,
+		`Crl:` + fmt.Sprintf("%v", this.Crl) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringIstioca(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -716,6 +1046,195 @@ func (m *IstioCertificateResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *RevokeCertificateRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIstioca
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RevokeCertificateRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RevokeCertificateRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SerialNumber", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIstioca
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SerialNumber = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Reason", wireType)
+			}
+			m.Reason = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIstioca
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Reason |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIstioca(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RevokeCertificateResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIstioca
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RevokeCertificateResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RevokeCertificateResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Crl", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIstioca
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Crl = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIstioca(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIstioca
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipIstioca(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
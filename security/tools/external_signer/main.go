@@ -0,0 +1,131 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Program external_signer serves the external signer protocol of Citadel, signing with a PKCS #11 key, or with
+// a key file standing in for an HSM in tests.
+package main
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"istio.io/istio/pkg/cmd"
+	"istio.io/istio/security/pkg/pki/signer"
+	"istio.io/istio/security/pkg/pki/signer/pkcs11"
+	pb "istio.io/istio/security/proto"
+)
+
+const unixPrefix = "unix://"
+
+var (
+	address         = flag.String("address", "unix:///var/run/istio-signer/socket", "The address to listen on, unix:///path or host:port.")
+	keyFile         = flag.String("key-file", "", "The PEM-encoded signing key file. Only for testing, use a PKCS #11 key otherwise.")
+	pkcs11Module    = flag.String("pkcs11-module", "", "The PKCS #11 module, e.g. /usr/lib/softhsm/libsofthsm2.so.")
+	pkcs11Token     = flag.String("pkcs11-token-label", "", "The label of the PKCS #11 token. The PIN is read from $PKCS11_PIN.")
+	pkcs11KeyLabel  = flag.String("pkcs11-key-label", "", "The label of the signing key in the PKCS #11 token.")
+	tlsCertFile     = flag.String("tls-cert", "", "The TLS certificate of the server. Only a unix socket can be served in plaintext.")
+	tlsKeyFile      = flag.String("tls-key", "", "The TLS key of the server.")
+	tlsClientCAFile = flag.String("tls-client-root-cert", "", "The root cert verifying the client certificates. Optional on a unix socket only.")
+)
+
+func newSigner() crypto.Signer {
+	switch {
+	case *keyFile != "" && *pkcs11Module != "":
+		log.Fatalf("--key-file and --pkcs11-module are mutually exclusive")
+	case *keyFile != "":
+		s, err := signer.NewFileSigner(*keyFile)
+		if err != nil {
+			log.Fatalf("Failed to load the signing key: %v", err)
+		}
+		return s
+	case *pkcs11Module != "":
+		s, err := pkcs11.NewSigner(*pkcs11Module, *pkcs11Token, os.Getenv("PKCS11_PIN"), *pkcs11KeyLabel)
+		if err != nil {
+			log.Fatalf("Failed to load the PKCS #11 key: %v", err)
+		}
+		return s
+	}
+	log.Fatalf("Either --key-file or --pkcs11-module is required")
+	return nil
+}
+
+func serverOptions() []grpc.ServerOption {
+	if !strings.HasPrefix(*address, unixPrefix) && (*tlsCertFile == "" || *tlsClientCAFile == "") {
+		// Anyone reaching a TCP address could have certificates signed, so the clients must be authenticated.
+		log.Fatalf("Listening on %s requires mutual TLS, set --tls-cert, --tls-key and --tls-client-root-cert", *address)
+	}
+	if *tlsCertFile == "" {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(*tlsCertFile, *tlsKeyFile)
+	if err != nil {
+		log.Fatalf("Failed to load the TLS certificate: %v", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if *tlsClientCAFile != "" {
+		clientCA, err := ioutil.ReadFile(*tlsClientCAFile)
+		if err != nil {
+			log.Fatalf("Failed to read the client root cert: %v", err)
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(clientCA) {
+			log.Fatalf("Failed to parse the client root cert %s", *tlsClientCAFile)
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(config))}
+}
+
+func listen() net.Listener {
+	network, addr := "tcp", *address
+	if strings.HasPrefix(addr, unixPrefix) {
+		network, addr = "unix", strings.TrimPrefix(addr, unixPrefix)
+		// Remove the socket left by a previous instance.
+		if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
+			log.Fatalf("Failed to remove the socket %s: %v", addr, err)
+		}
+	}
+	lis, err := net.Listen(network, addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *address, err)
+	}
+	return lis
+}
+
+func main() {
+	flag.Parse()
+
+	server := grpc.NewServer(serverOptions()...)
+	pb.RegisterExternalSignerServiceServer(server, signer.NewServer(newSigner()))
+	lis := listen()
+	go func() {
+		log.Printf("Serving the external signer on %s", *address)
+		if err := server.Serve(lis); err != nil {
+			log.Fatalf("Failed to serve: %v", err)
+		}
+	}()
+
+	cmd.WaitSignal(make(chan struct{}))
+	server.GracefulStop()
+}
@@ -54,6 +54,7 @@ var (
 	outCert        = flag.String("out-cert", "cert.pem", "Output certificate file.")
 	outPriv        = flag.String("out-priv", "priv.pem", "Output private key file.")
 	keySize        = flag.Int("key-size", 2048, "Size of the generated private key")
+	ecSigAlg       = flag.String("ec-sig-alg", "", "Generate an elliptical curve private key with the specified algorithm")
 	mode           = flag.String("mode", selfSignedMode, "Supported mode: self-signed, signer, citadel")
 )
 
@@ -145,6 +146,7 @@ func main() {
 		IsSelfSigned: *mode == selfSignedMode,
 		IsClient:     *isClient,
 		RSAKeySize:   *keySize,
+		ECSigAlg:     util.SupportedECSignatureAlgorithms(*ecSigAlg),
 	}
 	certPem, privPem, err := util.GenCertKeyFromOptions(opts)
 
@@ -27,11 +27,12 @@ import (
 )
 
 var (
-	host    = flag.String("host", "", "Comma-separated hostnames and IPs to generate a certificate for.")
-	org     = flag.String("organization", "Juju org", "Organization for the cert.")
-	outCsr  = flag.String("out-csr", "csr.pem", "Output csr file.")
-	outPriv = flag.String("out-priv", "priv.pem", "Output private key file.")
-	keySize = flag.Int("key-size", 2048, "Size of the generated private key")
+	host     = flag.String("host", "", "Comma-separated hostnames and IPs to generate a certificate for.")
+	org      = flag.String("organization", "Juju org", "Organization for the cert.")
+	outCsr   = flag.String("out-csr", "csr.pem", "Output csr file.")
+	outPriv  = flag.String("out-priv", "priv.pem", "Output private key file.")
+	keySize  = flag.Int("key-size", 2048, "Size of the generated private key")
+	ecSigAlg = flag.String("ec-sig-alg", "", "Generate an elliptical curve private key with the specified algorithm")
 )
 
 func fatalf(template string, args ...interface{}) {
@@ -58,6 +59,7 @@ func main() {
 		Host:       *host,
 		Org:        *org,
 		RSAKeySize: *keySize,
+		ECSigAlg:   util.SupportedECSignatureAlgorithms(*ecSigAlg),
 	})
 
 	if err != nil {
@@ -179,7 +179,6 @@ func startPilot() error {
 	}
 	bootstrap.PilotCertDir = env.IstioSrc + "/tests/testdata/certs/pilot"
 
-	bootstrap.FilepathWalkInterval = 5 * time.Second
 	// Static testdata, should include all configs we want to test.
 	args.Config.FileDir = os.Getenv("ISTIO_CONFIG")
 	if args.Config.FileDir == "" {